@@ -23,7 +23,13 @@ go install simgpgg
 # run the tests
 go test sim
 go test simgpgg
+go test simpgg
+go test simbase
+go test simcmd
+go test testutil
 
 # build the runsim command and put it in the bin directory
 go build -o $BIN/runsim $GOPATH/src/runsim.go
 go build -o $BIN/runsimgpgg $GOPATH/src/runsimgpgg.go
+go build -o $BIN/runsimpgg $GOPATH/src/runsimpgg.go
+go build -o $BIN/simtool $GOPATH/src/simtool.go
@@ -41,12 +41,12 @@ shift # remove the number of sims from list of arguments
 
 # echo the command that will be used to execute each simulation
 >&2 echo "  execute following for each graph instance:"
->&2 echo "    $BIN/runsimgpgg $@ -d graph<num> &> graph<num>.log"
+>&2 echo "    $BIN/runsimgpgg $@ -ng 1 -d graph<num> &> graph<num>.log"
 
 for i in `seq -f "%02g" 1 $NUMEXPS`;
 do
   >&2 echo "  executing simulations for graph instance $i..."
-  $BIN/runsimgpgg "$@" -d "graph$i" &> "graph$i.log"
+  $BIN/runsimgpgg "$@" -ng 1 -d "graph$i" &> "graph$i.log"
 done
 
 cd ..
@@ -63,7 +63,7 @@ do
   for R in 2 3 4 5 6 7 8 9;
   do
     >&2 echo "  executing experiment for r=$R..."
-    time $BIN/runexpgpgg.sh r$R $NUMGRAPHS -s $NUMSIMS -a $N -z $Z -g $NGENS -r $R -c 1 -w 0 -gtype $GTYPE &
+    time $BIN/runexpgpgg.sh r$R $NUMGRAPHS -spg $NUMSIMS -a $N -z $Z -g $NGENS -r $R -c 1 -w 0 -gtype $GTYPE &
   done
   wait
   cd ..
@@ -0,0 +1,275 @@
+package goraph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Attributed adds named vertex and edge attributes to a graph. Attributes
+// are removed along with their vertices and edges, so they stay correct
+// after RemoveVertex and RemoveEdge, and WriteEdgeList writes them as
+// name=value pairs. Vertices and edges must be removed through the
+// Attributed graph rather than the graph it wraps.
+type Attributed struct {
+	Graph
+	vertexAttrs []attribute
+	edgeAttrs   []attribute
+}
+
+// NewAttributed creates an attributed graph that stores its vertices and
+// edges in g.
+func NewAttributed(g Graph) *Attributed {
+	return &Attributed{Graph: g}
+}
+
+// Unwrap returns the graph that stores the vertices and edges.
+func (g *Attributed) Unwrap() Graph {
+	return g.Graph
+}
+
+// the untyped operations that the graph needs on each attribute
+type attribute interface {
+	name() string
+	removeVertex(g Graph, v Vertex)
+	removeEdge(u, v Vertex)
+	formatVertex(v Vertex) (string, bool)
+	formatEdge(e Edge) (string, bool)
+	clone() attribute
+}
+
+func (g *Attributed) RemoveVertex(v Vertex) {
+	for _, a := range g.vertexAttrs {
+		a.removeVertex(g.Graph, v)
+	}
+	for _, a := range g.edgeAttrs {
+		a.removeVertex(g.Graph, v)
+	}
+	g.Graph.RemoveVertex(v)
+}
+
+func (g *Attributed) RemoveEdge(u, v Vertex) {
+	for _, a := range g.edgeAttrs {
+		a.removeEdge(u, v)
+	}
+	g.Graph.RemoveEdge(u, v)
+}
+
+// return the name=value pairs of the attributes of a vertex
+func (g *Attributed) vertexPairs(v Vertex) []string {
+	var pairs []string
+	for _, a := range g.vertexAttrs {
+		if s, ok := a.formatVertex(v); ok {
+			pairs = append(pairs, a.name()+"="+s)
+		}
+	}
+	return pairs
+}
+
+// return the name=value pairs of the attributes of an edge
+func (g *Attributed) edgePairs(e Edge) []string {
+	var pairs []string
+	for _, a := range g.edgeAttrs {
+		if s, ok := a.formatEdge(e); ok {
+			pairs = append(pairs, a.name()+"="+s)
+		}
+	}
+	return pairs
+}
+
+// format a value so that it is a single field of an edge list
+func formatValue(value interface{}) string {
+	s := fmt.Sprint(value)
+	if (s == "") || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// VertexAttr holds a value of type T for some of the vertices of a graph.
+type VertexAttr[T any] struct {
+	attrName string
+	values   map[Vertex]T
+}
+
+// NewVertexAttr adds an empty vertex attribute with the specified name to
+// the graph. It panics if the graph already has a vertex attribute with
+// that name.
+func NewVertexAttr[T any](g *Attributed, name string) *VertexAttr[T] {
+	for _, a := range g.vertexAttrs {
+		if a.name() == name {
+			panic(fmt.Sprintf("duplicate vertex attribute: %v", name))
+		}
+	}
+	a := &VertexAttr[T]{attrName: name, values: make(map[Vertex]T)}
+	g.vertexAttrs = append(g.vertexAttrs, a)
+	return a
+}
+
+// VertexAttrOf returns the vertex attribute of the graph with the specified
+// name and false if it has no such attribute of type T.
+func VertexAttrOf[T any](g *Attributed, name string) (*VertexAttr[T], bool) {
+	for _, a := range g.vertexAttrs {
+		if a.name() == name {
+			typed, ok := a.(*VertexAttr[T])
+			return typed, ok
+		}
+	}
+	return nil, false
+}
+
+// Get returns the value of the attribute for v and false if it has none.
+func (a *VertexAttr[T]) Get(v Vertex) (T, bool) {
+	value, ok := a.values[v]
+	return value, ok
+}
+
+// Set sets the value of the attribute for v.
+func (a *VertexAttr[T]) Set(v Vertex, value T) {
+	a.values[v] = value
+}
+
+// Delete removes the value of the attribute for v.
+func (a *VertexAttr[T]) Delete(v Vertex) {
+	delete(a.values, v)
+}
+
+// Len returns the number of vertices with a value.
+func (a *VertexAttr[T]) Len() int {
+	return len(a.values)
+}
+
+func (a *VertexAttr[T]) name() string {
+	return a.attrName
+}
+
+func (a *VertexAttr[T]) removeVertex(g Graph, v Vertex) {
+	delete(a.values, v)
+}
+
+func (a *VertexAttr[T]) removeEdge(u, v Vertex) {
+}
+
+func (a *VertexAttr[T]) formatVertex(v Vertex) (string, bool) {
+	value, ok := a.values[v]
+	if !ok {
+		return "", false
+	}
+	return formatValue(value), true
+}
+
+func (a *VertexAttr[T]) formatEdge(e Edge) (string, bool) {
+	return "", false
+}
+
+func (a *VertexAttr[T]) clone() attribute {
+	c := &VertexAttr[T]{attrName: a.attrName, values: make(map[Vertex]T, len(a.values))}
+	for v, value := range a.values {
+		c.values[v] = value
+	}
+	return c
+}
+
+// EdgeAttr holds a value of type T for some of the edges of a graph. The
+// values of undirected edges are the same in both directions.
+type EdgeAttr[T any] struct {
+	attrName string
+	values   map[Edge]T
+	directed bool
+}
+
+// NewEdgeAttr adds an empty edge attribute with the specified name to the
+// graph. It panics if the graph already has an edge attribute with that
+// name.
+func NewEdgeAttr[T any](g *Attributed, name string) *EdgeAttr[T] {
+	for _, a := range g.edgeAttrs {
+		if a.name() == name {
+			panic(fmt.Sprintf("duplicate edge attribute: %v", name))
+		}
+	}
+	_, directed := AsDirected(g.Graph)
+	a := &EdgeAttr[T]{attrName: name, values: make(map[Edge]T), directed: directed}
+	g.edgeAttrs = append(g.edgeAttrs, a)
+	return a
+}
+
+// EdgeAttrOf returns the edge attribute of the graph with the specified name
+// and false if it has no such attribute of type T.
+func EdgeAttrOf[T any](g *Attributed, name string) (*EdgeAttr[T], bool) {
+	for _, a := range g.edgeAttrs {
+		if a.name() == name {
+			typed, ok := a.(*EdgeAttr[T])
+			return typed, ok
+		}
+	}
+	return nil, false
+}
+
+// return the key of the edge between u and v in the values map
+func (a *EdgeAttr[T]) key(u, v Vertex) Edge {
+	if !a.directed && v < u {
+		return Edge{v, u}
+	}
+	return Edge{u, v}
+}
+
+// Get returns the value of the attribute for the edge between u and v and
+// false if it has none.
+func (a *EdgeAttr[T]) Get(u, v Vertex) (T, bool) {
+	value, ok := a.values[a.key(u, v)]
+	return value, ok
+}
+
+// Set sets the value of the attribute for the edge between u and v.
+func (a *EdgeAttr[T]) Set(u, v Vertex, value T) {
+	a.values[a.key(u, v)] = value
+}
+
+// Delete removes the value of the attribute for the edge between u and v.
+func (a *EdgeAttr[T]) Delete(u, v Vertex) {
+	delete(a.values, a.key(u, v))
+}
+
+// Len returns the number of edges with a value.
+func (a *EdgeAttr[T]) Len() int {
+	return len(a.values)
+}
+
+func (a *EdgeAttr[T]) name() string {
+	return a.attrName
+}
+
+func (a *EdgeAttr[T]) removeVertex(g Graph, v Vertex) {
+	for _, n := range g.NeighborsView(v) {
+		a.Delete(v, n)
+	}
+	if d, ok := AsDirected(g); ok {
+		for _, n := range d.InNeighborsView(v) {
+			a.Delete(n, v)
+		}
+	}
+}
+
+func (a *EdgeAttr[T]) removeEdge(u, v Vertex) {
+	a.Delete(u, v)
+}
+
+func (a *EdgeAttr[T]) formatVertex(v Vertex) (string, bool) {
+	return "", false
+}
+
+func (a *EdgeAttr[T]) formatEdge(e Edge) (string, bool) {
+	value, ok := a.Get(e.U, e.V)
+	if !ok {
+		return "", false
+	}
+	return formatValue(value), true
+}
+
+func (a *EdgeAttr[T]) clone() attribute {
+	c := &EdgeAttr[T]{attrName: a.attrName, values: make(map[Edge]T, len(a.values)), directed: a.directed}
+	for e, value := range a.values {
+		c.values[e] = value
+	}
+	return c
+}
@@ -0,0 +1,125 @@
+package goraph
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAttributesRemoveVertex(t *testing.T) {
+	g := NewAttributed(NewAdjacencyList())
+	strategy := NewVertexAttr[string](g, "strategy")
+	payout := NewVertexAttr[float64](g, "payout")
+	created := NewEdgeAttr[int](g, "created")
+	for i := 0; i < 4; i++ {
+		v := g.AddVertex()
+		strategy.Set(v, "C")
+		payout.Set(v, float64(i))
+	}
+	g.AddEdge(0, 1)
+	g.AddEdge(1, 2)
+	g.AddEdge(2, 3)
+	created.Set(0, 1, 5)
+	created.Set(2, 1, 6)
+	created.Set(2, 3, 7)
+
+	// undirected edges have the same value in both directions
+	if c, ok := created.Get(1, 2); !ok || (c != 6) {
+		t.Errorf("created(1,2) = %v, %v", c, ok)
+	}
+
+	g.RemoveVertex(1)
+	if _, ok := strategy.Get(1); ok {
+		t.Errorf("removed vertex has a strategy")
+	}
+	if (strategy.Len() != 3) || (payout.Len() != 3) {
+		t.Errorf("vertex attributes not removed: %v %v", strategy.Len(), payout.Len())
+	}
+	if p, _ := payout.Get(3); p != 3 {
+		t.Errorf("payout(3) = %v", p)
+	}
+	if _, ok := created.Get(0, 1); ok {
+		t.Errorf("edge of removed vertex has a value")
+	}
+	if created.Len() != 1 {
+		t.Errorf("edge attributes not removed: %v", created.Len())
+	}
+
+	g.RemoveEdge(3, 2)
+	if created.Len() != 0 {
+		t.Errorf("edge attribute not removed with edge")
+	}
+}
+
+func TestAttributesDirected(t *testing.T) {
+	g := NewAttributed(NewDigraph())
+	created := NewEdgeAttr[int](g, "created")
+	for i := 0; i < 3; i++ {
+		g.AddVertex()
+	}
+	g.AddEdge(0, 1)
+	g.AddEdge(1, 0)
+	g.AddEdge(2, 1)
+	created.Set(0, 1, 1)
+	created.Set(1, 0, 2)
+	created.Set(2, 1, 3)
+	if c, _ := created.Get(1, 0); c != 2 {
+		t.Errorf("created(1,0) = %v", c)
+	}
+	if _, ok := AsDirected(g); !ok {
+		t.Errorf("attributed digraph is not directed")
+	}
+
+	// both in-edges and out-edges lose their values
+	g.RemoveVertex(1)
+	if created.Len() != 0 {
+		t.Errorf("edge attributes not removed: %v", created.Len())
+	}
+}
+
+func TestAttributesDuplicateName(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("duplicate attribute name did not panic")
+		}
+	}()
+	g := NewAttributed(NewAdjacencyList())
+	NewVertexAttr[int](g, "x")
+	NewVertexAttr[string](g, "x")
+}
+
+func TestEdgeListAttributes(t *testing.T) {
+	g := NewAttributed(NewWeighted(NewAdjacencyList()))
+	strategy := NewVertexAttr[string](g, "strategy")
+	label := NewEdgeAttr[string](g, "label")
+	for i := 0; i < 3; i++ {
+		g.AddVertex()
+	}
+	w, ok := AsWeighted(g)
+	if !ok {
+		t.Fatal("attributed weighted graph is not weighted")
+	}
+	w.AddWeightedEdge(1, 0, 0.5)
+	g.AddEdge(1, 2)
+	strategy.Set(0, "D")
+	label.Set(0, 1, "weak tie")
+
+	var buf bytes.Buffer
+	if err := WriteEdgeList(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"v 0 strategy=D\n", "v 1\n", "e 0 1 0.5 label=\"weak tie\"\n", "e 1 2 1\n"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("missing %q in edge list:\n%s", line, buf.String())
+		}
+	}
+
+	// the attributes are skipped when reading
+	c := NewWeighted(NewAdjacencyList())
+	if err := ReadEdgeList(strings.NewReader(buf.String()), c); err != nil {
+		t.Fatal(err)
+	}
+	if weight, _ := c.Weight(0, 1); weight != 0.5 {
+		t.Errorf("weight(0,1) = %v", weight)
+	}
+}
@@ -0,0 +1,98 @@
+package goraph
+
+import (
+	"fmt"
+)
+
+// Clone returns a deep copy of g that shares no state with it, so that
+// either graph can be changed without affecting the other. The copy has the
+// same type as g and new vertices get the same IDs in both graphs. Clone
+// panics if g is not one of the graph types of this package.
+func Clone(g Graph) Graph {
+	switch t := g.(type) {
+	case *AdjacencyList:
+		return t.Clone()
+	case *DenseGraph:
+		return t.Clone()
+	case *Digraph:
+		return t.Clone()
+	case *Weighted:
+		return t.Clone()
+	case *Attributed:
+		return t.Clone()
+	}
+	panic(fmt.Sprintf("cannot clone graph of type %T", g))
+}
+
+// Clone returns a deep copy of the graph.
+func (g *AdjacencyList) Clone() *AdjacencyList {
+	c := &AdjacencyList{edges: make(map[Vertex][]Vertex, len(g.edges)), nextVertex: g.nextVertex}
+	for v, neighbors := range g.edges {
+		c.edges[v] = VertexSlice(neighbors).Copy()
+	}
+	return c
+}
+
+// Clone returns a deep copy of the graph.
+func (g *DenseGraph) Clone() *DenseGraph {
+	c := &DenseGraph{
+		adj:     cloneAdjacency(g.adj),
+		index:   make([]map[Vertex]int32, len(g.index)),
+		removed: append([]bool(nil), g.removed...),
+		nlive:   g.nlive,
+	}
+	for v, positions := range g.index {
+		if positions != nil {
+			c.index[v] = make(map[Vertex]int32, len(positions))
+			for n, idx := range positions {
+				c.index[v][n] = idx
+			}
+		}
+	}
+	return c
+}
+
+// Clone returns a deep copy of the graph.
+func (g *Digraph) Clone() *Digraph {
+	return &Digraph{
+		out:     cloneAdjacency(g.out),
+		in:      cloneAdjacency(g.in),
+		removed: append([]bool(nil), g.removed...),
+		nlive:   g.nlive,
+	}
+}
+
+// Clone returns a deep copy of the graph and its weights.
+func (g *Weighted) Clone() *Weighted {
+	c := &Weighted{Graph: Clone(g.Graph), weights: make(map[Edge]float64, len(g.weights)), directed: g.directed}
+	for e, w := range g.weights {
+		c.weights[e] = w
+	}
+	return c
+}
+
+// Clone returns a deep copy of the graph and its attributes. Attribute values
+// are copied by assignment, so values that are pointers, maps or slices are
+// shared by both graphs. The attributes of the copy are found by name with
+// VertexAttrOf and EdgeAttrOf.
+func (g *Attributed) Clone() *Attributed {
+	c := &Attributed{Graph: Clone(g.Graph)}
+	for _, a := range g.vertexAttrs {
+		c.vertexAttrs = append(c.vertexAttrs, a.clone())
+	}
+	for _, a := range g.edgeAttrs {
+		c.edgeAttrs = append(c.edgeAttrs, a.clone())
+	}
+	return c
+}
+
+// copy the neighbour lists of a graph
+func cloneAdjacency(adj [][]Vertex) [][]Vertex {
+	c := make([][]Vertex, len(adj))
+	for v, neighbors := range adj {
+		if neighbors != nil {
+			c[v] = VertexSlice(neighbors).Copy()
+		}
+	}
+	return c
+}
@@ -0,0 +1,84 @@
+package goraph
+
+import (
+	"bytes"
+	"testing"
+)
+
+// return the edge list of a graph as a string
+func edgeListString(t *testing.T, g Graph) string {
+	var buf bytes.Buffer
+	if err := WriteEdgeList(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestClone(t *testing.T) {
+	graphs := []Graph{NewAdjacencyList(), NewDenseGraph(), NewDigraph(), NewWeighted(NewAdjacencyList())}
+	for _, g := range graphs {
+		for i := 0; i < 40; i++ {
+			g.AddVertex()
+		}
+		// vertex 0 has enough neighbours to be indexed by DenseGraph
+		for i := 1; i < 30; i++ {
+			g.AddEdge(0, Vertex(i))
+		}
+		g.AddEdge(1, 2)
+		g.RemoveVertex(39)
+
+		c := Clone(g)
+		before := edgeListString(t, g)
+		if edgeListString(t, c) != before {
+			t.Errorf("%T: clone differs:\n%s\n%s", g, before, edgeListString(t, c))
+		}
+
+		// changes to the clone don't change the original
+		c.RemoveEdge(0, 5)
+		c.RemoveVertex(1)
+		c.AddEdge(3, 4)
+		if edgeListString(t, g) != before {
+			t.Errorf("%T: original changed by its clone", g)
+		}
+		if c.HasEdge(0, 5) || g.HasEdge(3, 4) || !g.HasEdge(0, 5) {
+			t.Errorf("%T: graphs share edges", g)
+		}
+
+		// both graphs give new vertices the same ID
+		if u, v := g.AddVertex(), c.AddVertex(); u != v {
+			t.Errorf("%T: new vertex IDs differ: %v %v", g, u, v)
+		}
+	}
+}
+
+func TestCloneAttributed(t *testing.T) {
+	g := NewAttributed(NewWeighted(NewAdjacencyList()))
+	strategy := NewVertexAttr[string](g, "strategy")
+	created := NewEdgeAttr[int](g, "created")
+	for i := 0; i < 3; i++ {
+		strategy.Set(g.AddVertex(), "C")
+	}
+	g.AddEdge(0, 1)
+	created.Set(0, 1, 4)
+
+	c := g.Clone()
+	cstrategy, ok := VertexAttrOf[string](c, "strategy")
+	if !ok {
+		t.Fatal("clone has no strategy attribute")
+	}
+	if _, ok := EdgeAttrOf[string](c, "created"); ok {
+		t.Errorf("attribute found with the wrong type")
+	}
+	ccreated, _ := EdgeAttrOf[int](c, "created")
+	cstrategy.Set(0, "D")
+	c.RemoveEdge(0, 1)
+	if s, _ := strategy.Get(0); s != "C" {
+		t.Errorf("original strategy changed: %v", s)
+	}
+	if (created.Len() != 1) || (ccreated.Len() != 0) {
+		t.Errorf("edge attributes shared: %v %v", created.Len(), ccreated.Len())
+	}
+	if _, ok := AsWeighted(c); !ok {
+		t.Errorf("clone is not weighted")
+	}
+}
@@ -0,0 +1,217 @@
+package goraph
+
+import (
+	"fmt"
+)
+
+// Vertices with more neighbours than this keep an index of the positions of
+// their neighbours. Smaller neighbour lists are searched directly, which is
+// faster than a map lookup and saves memory on large sparse graphs.
+const denseIndexThreshold = 16
+
+// DenseGraph implements an undirected graph whose vertices are dense
+// indices into slices. Membership tests and edge removals take constant
+// time for a fixed degree threshold, and NeighborsView iterates over the
+// neighbours of a vertex without allocating. Vertex IDs are not reused.
+type DenseGraph struct {
+	adj     [][]Vertex         // neighbours of each vertex
+	index   []map[Vertex]int32 // position of each neighbour in adj (high degree vertices only)
+	removed []bool             // true for vertices that have been removed
+	nlive   int                // number of vertices that have not been removed
+}
+
+// NewDenseGraph creates an empty graph.
+func NewDenseGraph() *DenseGraph {
+	return &DenseGraph{}
+}
+
+// NewDenseGraphFrom creates a DenseGraph with the same vertices and edges as
+// g. Vertices keep their IDs.
+func NewDenseGraphFrom(g Graph) *DenseGraph {
+	vertices := VertexSlice(g.Vertices())
+	vertices.Sort()
+	d := NewDenseGraph()
+	if len(vertices) == 0 {
+		return d
+	}
+	// create the vertices up to the largest ID and remove the gaps
+	for i := Vertex(0); i <= vertices[len(vertices)-1]; i++ {
+		d.AddVertex()
+	}
+	next := 0
+	for i := Vertex(0); i <= vertices[len(vertices)-1]; i++ {
+		if vertices[next] == i {
+			next++
+		} else {
+			d.RemoveVertex(i)
+		}
+	}
+	// add each edge once
+	for _, u := range vertices {
+		for _, v := range g.NeighborsView(u) {
+			if u < v {
+				d.AddEdge(u, v)
+			}
+		}
+	}
+	return d
+}
+
+func (g *DenseGraph) HasVertex(v Vertex) bool {
+	return (v >= 0) && (int(v) < len(g.adj)) && !g.removed[v]
+}
+
+// check that v is a vertex of the graph
+func (g *DenseGraph) check(v Vertex) {
+	if !g.HasVertex(v) {
+		panic(fmt.Sprintf("vertex not in graph: %v", v))
+	}
+}
+
+func (g *DenseGraph) AddVertex() Vertex {
+	v := Vertex(len(g.adj))
+	g.adj = append(g.adj, nil)
+	g.index = append(g.index, nil)
+	g.removed = append(g.removed, false)
+	g.nlive++
+	return v
+}
+
+func (g *DenseGraph) RemoveVertex(v Vertex) {
+	g.check(v)
+	// remove v from its neighbours' edges
+	for _, n := range g.adj[v] {
+		g.removeHalfEdge(n, v)
+	}
+	g.adj[v] = nil
+	g.index[v] = nil
+	g.removed[v] = true
+	g.nlive--
+}
+
+func (g *DenseGraph) AddEdge(u, v Vertex) {
+	g.check(u)
+	g.check(v)
+	if (u == v) || g.HasEdge(u, v) {
+		panic(fmt.Sprintf("attempt to insert duplicate edge: (%v,%v)", u, v))
+	}
+	g.addHalfEdge(u, v)
+	g.addHalfEdge(v, u)
+}
+
+func (g *DenseGraph) addHalfEdge(u, v Vertex) {
+	g.adj[u] = append(g.adj[u], v)
+	if g.index[u] != nil {
+		g.index[u][v] = int32(len(g.adj[u]) - 1)
+	} else if len(g.adj[u]) > denseIndexThreshold {
+		// the vertex has become a high degree vertex
+		g.index[u] = make(map[Vertex]int32, 2*len(g.adj[u]))
+		for i, n := range g.adj[u] {
+			g.index[u][n] = int32(i)
+		}
+	}
+}
+
+func (g *DenseGraph) RemoveEdge(u, v Vertex) {
+	if !g.HasEdge(u, v) {
+		return
+	}
+	g.removeHalfEdge(u, v)
+	g.removeHalfEdge(v, u)
+}
+
+// remove v from the neighbours of u by moving the last neighbour into its
+// position - v must be a neighbour of u
+func (g *DenseGraph) removeHalfEdge(u, v Vertex) {
+	idx := g.position(u, v)
+	neighbors := g.adj[u]
+	last := len(neighbors) - 1
+	neighbors[idx] = neighbors[last]
+	g.adj[u] = neighbors[:last]
+	if g.index[u] != nil {
+		g.index[u][neighbors[idx]] = int32(idx)
+		delete(g.index[u], v)
+	}
+}
+
+// return the position of v in the neighbours of u or -1 if v is not a
+// neighbour of u
+func (g *DenseGraph) position(u, v Vertex) int {
+	if g.index[u] != nil {
+		if idx, ok := g.index[u][v]; ok {
+			return int(idx)
+		}
+		return -1
+	}
+	for idx, n := range g.adj[u] {
+		if n == v {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (g *DenseGraph) HasEdge(u, v Vertex) bool {
+	if (u < 0) || (v < 0) || (int(u) >= len(g.adj)) || (int(v) >= len(g.adj)) {
+		return false
+	}
+	// search the vertex with fewer neighbours
+	if len(g.adj[v]) < len(g.adj[u]) {
+		u, v = v, u
+	}
+	return g.position(u, v) >= 0
+}
+
+func (g *DenseGraph) Vertices() []Vertex {
+	vertices := make([]Vertex, 0, g.nlive)
+	for v := range g.adj {
+		if !g.removed[v] {
+			vertices = append(vertices, Vertex(v))
+		}
+	}
+	return vertices
+}
+
+func (g *DenseGraph) Edges() []Edge {
+	var edges []Edge
+	for u, neighbors := range g.adj {
+		for _, n := range neighbors {
+			// to prevent duplicates, only add the edge if (u < n)
+			if Vertex(u) < n {
+				edges = append(edges, Edge{Vertex(u), n})
+			}
+		}
+	}
+	return edges
+}
+
+func (g *DenseGraph) Neighbors(v Vertex) []Vertex {
+	// make a copy of the neighbors so that changes don't impact the graph
+	return VertexSlice(g.NeighborsView(v)).Copy()
+}
+
+func (g *DenseGraph) NeighborsView(v Vertex) []Vertex {
+	if (v < 0) || (int(v) >= len(g.adj)) {
+		return nil
+	}
+	return g.adj[v]
+}
+
+func (g *DenseGraph) Degree(v Vertex) int {
+	return len(g.NeighborsView(v))
+}
+
+// NumVertices returns the number of vertices in the graph.
+func (g *DenseGraph) NumVertices() int {
+	return g.nlive
+}
+
+func (g *DenseGraph) String() string {
+	s := ""
+	for v, neighbors := range g.adj {
+		if !g.removed[v] {
+			s = fmt.Sprintf("%s%v: %v\n", s, v, neighbors)
+		}
+	}
+	return s
+}
@@ -0,0 +1,227 @@
+package goraph
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestDenseGraph(t *testing.T) {
+	g := NewDenseGraph()
+	for i := 0; i < 40; i++ {
+		g.AddVertex()
+	}
+	// vertex 0 becomes a high degree vertex with an index
+	for i := 1; i < 40; i++ {
+		g.AddEdge(0, Vertex(i))
+	}
+	g.AddEdge(1, 2)
+	if !g.HasEdge(0, 39) || !g.HasEdge(39, 0) || !g.HasEdge(2, 1) || g.HasEdge(1, 3) {
+		t.Error("unexpected edge membership")
+	}
+	if g.Degree(0) != 39 || len(g.Edges()) != 40 {
+		t.Errorf("degree %v, edges %v", g.Degree(0), len(g.Edges()))
+	}
+
+	// removing an edge moves the last neighbour into its place
+	g.RemoveEdge(0, 5)
+	g.RemoveEdge(0, 5)
+	if g.HasEdge(0, 5) || g.HasEdge(5, 0) || g.Degree(0) != 38 || g.Degree(5) != 0 {
+		t.Error("edge (0,5) was not removed")
+	}
+	for _, n := range g.NeighborsView(0) {
+		if !g.HasEdge(0, n) {
+			t.Errorf("index of vertex 0 is out of date for %v", n)
+		}
+	}
+
+	// removing a vertex removes its edges
+	g.RemoveVertex(1)
+	if g.NumVertices() != 39 || len(g.Vertices()) != 39 || g.HasEdge(0, 1) || g.Degree(2) != 1 {
+		t.Error("vertex 1 was not removed")
+	}
+	AssertVertexEqual(t, g.AddVertex(), Vertex(40))
+}
+
+func TestDenseGraphDuplicateEdge(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate edge did not panic")
+		}
+	}()
+	g := NewDenseGraph()
+	u, v := g.AddVertex(), g.AddVertex()
+	g.AddEdge(u, v)
+	g.AddEdge(v, u)
+}
+
+func TestGraphConversion(t *testing.T) {
+	a := NewAdjacencyList()
+	for i := 0; i < 5; i++ {
+		a.AddVertex()
+	}
+	a.AddEdge(0, 1)
+	a.AddEdge(1, 2)
+	a.AddEdge(3, 4)
+	a.RemoveVertex(2)
+
+	d := NewDenseGraphFrom(a)
+	if d.NumVertices() != 4 || len(d.Edges()) != 2 || !d.HasEdge(1, 0) || !d.HasEdge(3, 4) {
+		t.Errorf("dense copy differs:\n%v", d)
+	}
+	b := NewAdjacencyListFrom(d)
+	if len(b.Vertices()) != 4 || len(b.Edges()) != 2 || !b.HasEdge(0, 1) || b.Degree(1) != 1 {
+		t.Errorf("adjacency list copy differs:\n%v", b)
+	}
+	AssertVertexEqual(t, b.AddVertex(), Vertex(5))
+}
+
+func TestVertexSliceContains(t *testing.T) {
+	p := VertexSlice{3, 1, 2}
+	if !p.Contains(1) || p.Contains(4) {
+		t.Error("unexpected membership")
+	}
+	// the slice is not reordered
+	if p[0] != 3 || p[1] != 1 || p[2] != 2 {
+		t.Errorf("slice was reordered: %v", p)
+	}
+}
+
+// build a ring of n vertices, each linked to its 2 nearest neighbours on
+// either side, with a tenth of the edges moved to random vertices
+func benchGraph(g Graph, n int) Graph {
+	rnGen := rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		g.AddVertex()
+	}
+	for i := 0; i < n; i++ {
+		for j := 1; j <= 2; j++ {
+			u, v := Vertex(i), Vertex((i+j)%n)
+			if rnGen.Intn(10) == 0 {
+				v = Vertex(rnGen.Intn(n))
+			}
+			if u != v && !g.HasEdge(u, v) {
+				g.AddEdge(u, v)
+			}
+		}
+	}
+	return g
+}
+
+var benchSizes = []int{100000, 1000000}
+
+// run the benchmark on graphs of each size and implementation
+func benchGraphs(b *testing.B, bench func(b *testing.B, g Graph, n int)) {
+	for _, n := range benchSizes {
+		impls := []struct {
+			name string
+			g    Graph
+		}{
+			{"AdjacencyList", NewAdjacencyList()},
+			{"DenseGraph", NewDenseGraph()},
+		}
+		for _, impl := range impls {
+			g := benchGraph(impl.g, n)
+			b.Run(fmt.Sprintf("%s/n=%d", impl.name, n), func(b *testing.B) {
+				bench(b, g, n)
+			})
+		}
+	}
+}
+
+func BenchmarkNeighbors(b *testing.B) {
+	benchGraphs(b, func(b *testing.B, g Graph, n int) {
+		b.ReportAllocs()
+		sum := 0
+		for i := 0; i < b.N; i++ {
+			sum += len(g.Neighbors(Vertex(i % n)))
+		}
+	})
+}
+
+func BenchmarkNeighborsView(b *testing.B) {
+	benchGraphs(b, func(b *testing.B, g Graph, n int) {
+		b.ReportAllocs()
+		sum := Vertex(0)
+		for i := 0; i < b.N; i++ {
+			for _, v := range g.NeighborsView(Vertex(i % n)) {
+				sum += v
+			}
+		}
+	})
+}
+
+func BenchmarkHasEdge(b *testing.B) {
+	benchGraphs(b, func(b *testing.B, g Graph, n int) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			g.HasEdge(Vertex(i%n), Vertex((i+1)%n))
+		}
+	})
+}
+
+// remove an edge and add it back, as a rewiring update does
+func BenchmarkRewire(b *testing.B) {
+	benchGraphs(b, func(b *testing.B, g Graph, n int) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			u := Vertex(i % n)
+			N := g.NeighborsView(u)
+			if len(N) > 0 {
+				v := N[0]
+				g.RemoveEdge(u, v)
+				g.AddEdge(u, v)
+			}
+		}
+	})
+}
+
+// each run removes vertices from a freshly built graph
+func BenchmarkRemoveVertex(b *testing.B) {
+	for _, n := range benchSizes {
+		impls := []struct {
+			name    string
+			newImpl func() Graph
+		}{
+			{"AdjacencyList", func() Graph { return NewAdjacencyList() }},
+			{"DenseGraph", func() Graph { return NewDenseGraph() }},
+		}
+		for _, impl := range impls {
+			b.Run(fmt.Sprintf("%s/n=%d", impl.name, n), func(b *testing.B) {
+				b.StopTimer()
+				g := benchGraph(impl.newImpl(), n)
+				b.StartTimer()
+				for i := 0; i < b.N; i++ {
+					if i%n == 0 && i > 0 {
+						b.StopTimer()
+						g = benchGraph(impl.newImpl(), n)
+						b.StartTimer()
+					}
+					g.RemoveVertex(Vertex(i % n))
+				}
+			})
+		}
+	}
+}
+
+func TestTryAddEdge(t *testing.T) {
+	for _, g := range []Graph{NewAdjacencyList(), NewDenseGraph(), NewDigraph()} {
+		u, v := g.AddVertex(), g.AddVertex()
+		if err := TryAddEdge(g, u, v); err != nil {
+			t.Error(err)
+		}
+		if err := TryAddEdge(g, u, v); !errors.Is(err, ErrDuplicateEdge) {
+			t.Errorf("duplicate edge: %v", err)
+		}
+		if err := TryAddEdge(g, u, u); !errors.Is(err, ErrCircularEdge) {
+			t.Errorf("circular edge: %v", err)
+		}
+		if err := TryAddEdge(g, u, 7); !errors.Is(err, ErrMissingVertex) {
+			t.Errorf("missing vertex: %v", err)
+		}
+		if len(g.Vertices()) != 2 || len(g.Edges()) != 1 {
+			t.Errorf("graph changed by failed edges:\n%v", g)
+		}
+	}
+}
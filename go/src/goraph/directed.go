@@ -0,0 +1,197 @@
+package goraph
+
+import (
+	"fmt"
+)
+
+// DirectedGraph is implemented by graphs whose edges go from U to V. The
+// Graph methods follow the direction of the edges: Neighbors and
+// NeighborsView return the out-neighbours of a vertex, Degree returns its
+// out-degree and HasEdge(u, v) is true only for an edge from u to v.
+type DirectedGraph interface {
+	Graph
+
+	// InNeighbors returns a slice of the vertices with an edge to v.
+	InNeighbors(v Vertex) []Vertex
+
+	// InNeighborsView returns the vertices with an edge to v without
+	// copying them. The slice must not be modified and is only valid until
+	// the graph is next changed.
+	InNeighborsView(v Vertex) []Vertex
+
+	// InDegree returns the number of edges to v.
+	InDegree(v Vertex) int
+}
+
+var (
+	_ DirectedGraph = &Digraph{}
+)
+
+// AsDirected returns g as a DirectedGraph if its edges are directed. Graphs
+// wrapped by Weighted or Attributed are unwrapped.
+func AsDirected(g Graph) (DirectedGraph, bool) {
+	switch t := g.(type) {
+	case DirectedGraph:
+		return t, true
+	case wrapper:
+		return AsDirected(t.Unwrap())
+	}
+	return nil, false
+}
+
+// implemented by graphs that add data to another graph
+type wrapper interface {
+	Unwrap() Graph
+}
+
+// Digraph implements a directed graph whose vertices are dense indices into
+// slices of out-neighbours and in-neighbours. Vertex IDs are not reused.
+type Digraph struct {
+	out     [][]Vertex // vertices that each vertex has an edge to
+	in      [][]Vertex // vertices that have an edge to each vertex
+	removed []bool     // true for vertices that have been removed
+	nlive   int        // number of vertices that have not been removed
+}
+
+// NewDigraph creates an empty directed graph.
+func NewDigraph() *Digraph {
+	return &Digraph{}
+}
+
+func (g *Digraph) HasVertex(v Vertex) bool {
+	return (v >= 0) && (int(v) < len(g.out)) && !g.removed[v]
+}
+
+// check that v is a vertex of the graph
+func (g *Digraph) check(v Vertex) {
+	if !g.HasVertex(v) {
+		panic(fmt.Sprintf("vertex not in graph: %v", v))
+	}
+}
+
+func (g *Digraph) AddVertex() Vertex {
+	v := Vertex(len(g.out))
+	g.out = append(g.out, nil)
+	g.in = append(g.in, nil)
+	g.removed = append(g.removed, false)
+	g.nlive++
+	return v
+}
+
+func (g *Digraph) RemoveVertex(v Vertex) {
+	g.check(v)
+	for _, n := range g.out[v] {
+		g.in[n] = removeFromSlice(g.in[n], v)
+	}
+	for _, n := range g.in[v] {
+		g.out[n] = removeFromSlice(g.out[n], v)
+	}
+	g.out[v] = nil
+	g.in[v] = nil
+	g.removed[v] = true
+	g.nlive--
+}
+
+// AddEdge adds an edge from u to v.
+func (g *Digraph) AddEdge(u, v Vertex) {
+	g.check(u)
+	g.check(v)
+	if (u == v) || g.HasEdge(u, v) {
+		panic(fmt.Sprintf("attempt to insert duplicate edge: (%v,%v)", u, v))
+	}
+	g.out[u] = append(g.out[u], v)
+	g.in[v] = append(g.in[v], u)
+}
+
+// RemoveEdge removes the edge from u to v.
+func (g *Digraph) RemoveEdge(u, v Vertex) {
+	if !g.HasEdge(u, v) {
+		return
+	}
+	g.out[u] = removeFromSlice(g.out[u], v)
+	g.in[v] = removeFromSlice(g.in[v], u)
+}
+
+// remove v from the slice by moving the last vertex into its position
+func removeFromSlice(vertices []Vertex, v Vertex) []Vertex {
+	for idx, candidate := range vertices {
+		if candidate == v {
+			last := len(vertices) - 1
+			vertices[idx] = vertices[last]
+			return vertices[:last]
+		}
+	}
+	return vertices
+}
+
+// HasEdge returns true if there is an edge from u to v.
+func (g *Digraph) HasEdge(u, v Vertex) bool {
+	if (u < 0) || (v < 0) || (int(u) >= len(g.out)) || (int(v) >= len(g.out)) {
+		return false
+	}
+	// search the shorter list
+	if len(g.in[v]) < len(g.out[u]) {
+		return VertexSlice(g.in[v]).Contains(u)
+	}
+	return VertexSlice(g.out[u]).Contains(v)
+}
+
+func (g *Digraph) Vertices() []Vertex {
+	vertices := make([]Vertex, 0, g.nlive)
+	for v := range g.out {
+		if !g.removed[v] {
+			vertices = append(vertices, Vertex(v))
+		}
+	}
+	return vertices
+}
+
+func (g *Digraph) Edges() []Edge {
+	var edges []Edge
+	for u, neighbors := range g.out {
+		for _, n := range neighbors {
+			edges = append(edges, Edge{Vertex(u), n})
+		}
+	}
+	return edges
+}
+
+func (g *Digraph) Neighbors(v Vertex) []Vertex {
+	return VertexSlice(g.NeighborsView(v)).Copy()
+}
+
+func (g *Digraph) NeighborsView(v Vertex) []Vertex {
+	if (v < 0) || (int(v) >= len(g.out)) {
+		return nil
+	}
+	return g.out[v]
+}
+
+func (g *Digraph) Degree(v Vertex) int {
+	return len(g.NeighborsView(v))
+}
+
+func (g *Digraph) InNeighbors(v Vertex) []Vertex {
+	return VertexSlice(g.InNeighborsView(v)).Copy()
+}
+
+func (g *Digraph) InNeighborsView(v Vertex) []Vertex {
+	if (v < 0) || (int(v) >= len(g.in)) {
+		return nil
+	}
+	return g.in[v]
+}
+
+func (g *Digraph) InDegree(v Vertex) int {
+	return len(g.InNeighborsView(v))
+}
+
+func (g *Digraph) String() string {
+	s := ""
+	for v, neighbors := range g.out {
+		if !g.removed[v] {
+			s = fmt.Sprintf("%s%v -> %v\n", s, v, neighbors)
+		}
+	}
+	return s
+}
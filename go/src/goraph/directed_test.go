@@ -0,0 +1,46 @@
+package goraph
+
+import (
+	"testing"
+)
+
+func TestDigraph(t *testing.T) {
+	g := NewDigraph()
+	for i := 0; i < 4; i++ {
+		g.AddVertex()
+	}
+	g.AddEdge(0, 1)
+	g.AddEdge(1, 0)
+	g.AddEdge(0, 2)
+	g.AddEdge(3, 0)
+
+	// the Graph methods follow the direction of the edges
+	if !g.HasEdge(0, 2) || g.HasEdge(2, 0) || g.Degree(0) != 2 || g.InDegree(0) != 2 {
+		t.Errorf("unexpected edges:\n%v", g)
+	}
+	if len(g.Edges()) != 4 || !VertexSlice(g.InNeighbors(0)).Contains(3) {
+		t.Errorf("unexpected edges:\n%v", g)
+	}
+
+	// removing an edge only removes one direction
+	g.RemoveEdge(1, 0)
+	if g.HasEdge(1, 0) || !g.HasEdge(0, 1) || g.InDegree(0) != 1 || g.Degree(1) != 0 {
+		t.Errorf("edge (1,0) was not removed:\n%v", g)
+	}
+
+	// removing a vertex removes the edges to and from it
+	g.RemoveVertex(0)
+	if len(g.Edges()) != 0 || g.InDegree(1) != 0 || g.Degree(3) != 0 || len(g.Vertices()) != 3 {
+		t.Errorf("vertex 0 was not removed:\n%v", g)
+	}
+
+	if _, ok := AsDirected(g); !ok {
+		t.Error("digraph is not directed")
+	}
+	if _, ok := AsDirected(NewWeighted(g)); !ok {
+		t.Error("weighted digraph is not directed")
+	}
+	if _, ok := AsDirected(NewAdjacencyList()); ok {
+		t.Error("adjacency list is directed")
+	}
+}
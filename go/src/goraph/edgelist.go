@@ -0,0 +1,125 @@
+package goraph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteEdgeList writes the graph as text with one vertex or edge per line:
+//
+//	v <id> [<name>=<value> ...]
+//	e <u> <v> [<weight>] [<name>=<value> ...]
+//
+// Weights are written for weighted graphs and the attributes of Attributed
+// graphs are written as name=value pairs, with values that contain spaces
+// quoted. The edges of directed graphs go from u to v and undirected edges
+// are written once. Lines starting with # are comments.
+func WriteEdgeList(w io.Writer, g Graph) error {
+	_, directed := AsDirected(g)
+	wg, weighted := AsWeighted(g)
+	ag, attributed := g.(*Attributed)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# goraph edge list directed=%t weighted=%t\n", directed, weighted)
+	vertices := VertexSlice(g.Vertices())
+	vertices.Sort()
+	for _, v := range vertices {
+		fmt.Fprintf(bw, "v %d", v)
+		if attributed {
+			writePairs(bw, ag.vertexPairs(v))
+		}
+		fmt.Fprintln(bw)
+	}
+	edges := EdgeSlice(g.Edges())
+	edges.Sort()
+	for _, e := range edges {
+		fmt.Fprintf(bw, "e %d %d", e.U, e.V)
+		if weighted {
+			weight, _ := wg.Weight(e.U, e.V)
+			fmt.Fprintf(bw, " %s", strconv.FormatFloat(weight, 'g', -1, 64))
+		}
+		if attributed {
+			writePairs(bw, ag.edgePairs(e))
+		}
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
+
+func writePairs(w io.Writer, pairs []string) {
+	for _, pair := range pairs {
+		fmt.Fprintf(w, " %s", pair)
+	}
+}
+
+// ReadEdgeList adds the vertices and edges written by WriteEdgeList to the
+// empty graph g. Vertices keep their IDs. Weights are ignored unless g is a
+// WeightedGraph and attributes are ignored because their types are unknown.
+func ReadEdgeList(r io.Reader, g Graph) error {
+	if len(g.Vertices()) > 0 {
+		return fmt.Errorf("graph is not empty")
+	}
+	wg, weighted := AsWeighted(g)
+	present := map[Vertex]bool{}
+	next := Vertex(0)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if (len(fields) == 0) || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		// drop the attributes, which start at the first name=value pair
+		for idx, f := range fields {
+			if strings.Contains(f, "=") {
+				fields = fields[:idx]
+				break
+			}
+		}
+		ids := make([]Vertex, 0, 2)
+		for _, f := range fields[1:min(len(fields), 3)] {
+			id, err := strconv.Atoi(f)
+			if (err != nil) || (id < 0) {
+				return fmt.Errorf("line %d: invalid vertex %q", line, f)
+			}
+			ids = append(ids, Vertex(id))
+		}
+		switch {
+		case (fields[0] == "v") && (len(fields) == 2):
+			// create the vertices up to and including this one
+			for ; next <= ids[0]; next++ {
+				g.AddVertex()
+			}
+			present[ids[0]] = true
+		case (fields[0] == "e") && ((len(fields) == 3) || (len(fields) == 4)):
+			if !present[ids[0]] || !present[ids[1]] {
+				return fmt.Errorf("line %d: edge with an undeclared vertex", line)
+			}
+			if g.HasEdge(ids[0], ids[1]) || (ids[0] == ids[1]) {
+				return fmt.Errorf("line %d: duplicate edge", line)
+			}
+			if weighted && (len(fields) == 4) {
+				weight, err := strconv.ParseFloat(fields[3], 64)
+				if err != nil {
+					return fmt.Errorf("line %d: invalid weight %q", line, fields[3])
+				}
+				wg.AddWeightedEdge(ids[0], ids[1], weight)
+			} else {
+				g.AddEdge(ids[0], ids[1])
+			}
+		default:
+			return fmt.Errorf("line %d: invalid line %q", line, scanner.Text())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	// remove the vertices that were created to fill gaps between IDs
+	for v := Vertex(0); v < next; v++ {
+		if !present[v] {
+			g.RemoveVertex(v)
+		}
+	}
+	return nil
+}
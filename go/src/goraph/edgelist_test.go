@@ -0,0 +1,61 @@
+package goraph
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEdgeList(t *testing.T) {
+	g := NewWeighted(NewDigraph())
+	for i := 0; i < 4; i++ {
+		g.AddVertex()
+	}
+	g.AddWeightedEdge(0, 1, 0.5)
+	g.AddWeightedEdge(1, 0, 2)
+	g.AddEdge(3, 0)
+	g.RemoveVertex(2)
+
+	var buf bytes.Buffer
+	if err := WriteEdgeList(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "e 0 1 0.5\n") {
+		t.Errorf("unexpected edge list:\n%s", buf.String())
+	}
+
+	// the copy has the same vertices, edges and weights
+	c := NewWeighted(NewDigraph())
+	if err := ReadEdgeList(strings.NewReader(buf.String()), c); err != nil {
+		t.Fatal(err)
+	}
+	var buf2 bytes.Buffer
+	WriteEdgeList(&buf2, c)
+	if buf.String() != buf2.String() {
+		t.Errorf("copy differs:\n%s\n%s", buf.String(), buf2.String())
+	}
+
+	// weights are dropped when reading into an unweighted graph
+	a := NewAdjacencyList()
+	if err := ReadEdgeList(strings.NewReader("v 0\nv 1\ne 0 1 7\n"), a); err != nil {
+		t.Fatal(err)
+	}
+	if !a.HasEdge(1, 0) {
+		t.Error("edge (0,1) was not read")
+	}
+}
+
+func TestEdgeListErrors(t *testing.T) {
+	bad := []string{
+		"v 0\ne 0 1\n",
+		"v 0\nv 1\ne 0 1\ne 1 0\n",
+		"v x\n",
+		"v 0\nv 1\ne 0 1 heavy\n",
+		"x 0\n",
+	}
+	for _, s := range bad {
+		if err := ReadEdgeList(strings.NewReader(s), NewWeighted(NewAdjacencyList())); err == nil {
+			t.Errorf("no error reading %q", s)
+		}
+	}
+}
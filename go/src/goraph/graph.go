@@ -5,8 +5,9 @@
 package goraph
 
 import (
-	"sort"
+	"errors"
 	"fmt"
+	"sort"
 )
 
 // Graph is implemented by all of the graph types. All of the graph
@@ -36,10 +37,45 @@ type Graph interface {
 
 	// Returns the degree of the vertex (number of neighbors)
 	Degree(v Vertex) int
+
+	// NeighborsView returns the vertices that neighbour v without copying
+	// them. The slice must not be modified and is only valid until the
+	// graph is next changed.
+	NeighborsView(v Vertex) []Vertex
+
+	// HasEdge returns true if there is an edge between u and v.
+	HasEdge(u, v Vertex) bool
+
+	// HasVertex returns true if v is a vertex of the graph.
+	HasVertex(v Vertex) bool
+}
+
+// Errors returned by the checked graph mutations
+var (
+	ErrMissingVertex = errors.New("vertex not in graph")
+	ErrCircularEdge  = errors.New("attempt to insert circular edge")
+	ErrDuplicateEdge = errors.New("attempt to insert duplicate edge")
+)
+
+// TryAddEdge adds an edge between u and v like AddEdge but returns an error
+// instead of panicking or creating a vertex when the edge can't be added.
+func TryAddEdge(g Graph, u, v Vertex) error {
+	if !g.HasVertex(u) || !g.HasVertex(v) {
+		return fmt.Errorf("%w: (%v,%v)", ErrMissingVertex, u, v)
+	}
+	if u == v {
+		return fmt.Errorf("%w: (%v,%v)", ErrCircularEdge, u, v)
+	}
+	if g.HasEdge(u, v) {
+		return fmt.Errorf("%w: (%v,%v)", ErrDuplicateEdge, u, v)
+	}
+	g.AddEdge(u, v)
+	return nil
 }
 
 var (
 	_ Graph = &AdjacencyList{}
+	_ Graph = &DenseGraph{}
 )
 
 // Vertex represents a node in the graph. Users should create
@@ -61,6 +97,19 @@ func NewAdjacencyList() *AdjacencyList {
 	return &AdjacencyList{edges: make(map[Vertex][]Vertex)}
 }
 
+// NewAdjacencyListFrom creates an AdjacencyList with the same vertices and
+// edges as g. Vertices keep their IDs.
+func NewAdjacencyListFrom(g Graph) *AdjacencyList {
+	a := NewAdjacencyList()
+	for _, v := range g.Vertices() {
+		a.edges[v] = VertexSlice(g.NeighborsView(v)).Copy()
+		if v >= a.nextVertex {
+			a.nextVertex = v + 1
+		}
+	}
+	return a
+}
+
 func (g *AdjacencyList) AddVertex() Vertex {
 	v := g.nextVertex
 	g.edges[v] = make([]Vertex, 0)
@@ -69,21 +118,15 @@ func (g *AdjacencyList) AddVertex() Vertex {
 }
 
 func (g *AdjacencyList) RemoveVertex(v Vertex) {
-	// remove the edges for v
-	delete(g.edges, v)
-	// remove v from other vertices' edges
-	for vtx, vertices := range g.edges {
-		for idx, candidate := range vertices {
-			if candidate == v {
-				if (idx+1 >= len(vertices)) {
-					g.edges[vtx] = make([]Vertex, idx)
-					copy(g.edges[vtx], vertices[:idx])
-				} else {
-					g.edges[vtx] = append(vertices[:idx], vertices[idx+1:len(vertices)]...)
-				}
-			}
+	// remove v from its neighbours' edges
+	// -- only the neighbours of v can hold an edge to v
+	for _, n := range g.edges[v] {
+		if n != v {
+			g.removeHalfEdge(n, v)
 		}
 	}
+	// remove the edges for v
+	delete(g.edges, v)
 }
 
 func (g *AdjacencyList) AddEdge(u, v Vertex) {
@@ -137,14 +180,14 @@ func (p VertexSlice) Len() int           { return len(p) }
 func (p VertexSlice) Less(i, j int) bool { return p[i] < p[j] }
 func (p VertexSlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 func (p VertexSlice) Sort()              { sort.Sort(p) }
+// Contains does a linear search so that the slice is left unchanged.
 func (p VertexSlice) Contains(v Vertex) bool {
-	p.Sort()
-	i := sort.Search(len(p), func(i int) bool { return p[i] >= v })
-	if (i < len(p) && p[i] == v) {
-		return true
-	} else {
-		return false
+	for _, candidate := range p {
+		if candidate == v {
+			return true
+		}
 	}
+	return false
 }
 func (p VertexSlice) Copy() VertexSlice {
 	newSlice := make([]Vertex, len(p))
@@ -219,6 +262,23 @@ func (g *AdjacencyList) Degree(v Vertex) int {
 	return len(g.edges[v])
 }
 
+func (g *AdjacencyList) HasVertex(v Vertex) bool {
+	_, ok := g.edges[v]
+	return ok
+}
+
+func (g *AdjacencyList) NeighborsView(v Vertex) []Vertex {
+	return g.edges[v]
+}
+
+func (g *AdjacencyList) HasEdge(u, v Vertex) bool {
+	// search the shorter list of neighbours
+	if len(g.edges[v]) < len(g.edges[u]) {
+		u, v = v, u
+	}
+	return VertexSlice(g.edges[u]).Contains(v)
+}
+
 func (g *AdjacencyList) String() string {
 	s := ""
 	for key, val := range g.edges {
@@ -0,0 +1,106 @@
+package goraph
+
+// The weight of edges added without one
+const DefaultWeight = 1.0
+
+// WeightedGraph is implemented by graphs whose edges carry a weight. Edges
+// added with AddEdge have the DefaultWeight.
+type WeightedGraph interface {
+	Graph
+
+	// AddWeightedEdge adds an edge between u and v with weight w.
+	AddWeightedEdge(u, v Vertex, w float64)
+
+	// Weight returns the weight of the edge between u and v and false if
+	// there is no such edge.
+	Weight(u, v Vertex) (float64, bool)
+
+	// SetWeight changes the weight of the existing edge between u and v.
+	SetWeight(u, v Vertex, w float64)
+}
+
+var (
+	_ WeightedGraph = &Weighted{}
+)
+
+// Weighted adds edge weights to a directed or undirected graph. The weights
+// of undirected edges are the same in both directions.
+type Weighted struct {
+	Graph
+	weights  map[Edge]float64
+	directed bool
+}
+
+// NewWeighted creates a weighted graph that stores its vertices and edges in
+// g. Any edges that g already has are given the DefaultWeight.
+func NewWeighted(g Graph) *Weighted {
+	_, directed := AsDirected(g)
+	w := &Weighted{Graph: g, weights: make(map[Edge]float64), directed: directed}
+	for _, e := range g.Edges() {
+		w.weights[w.key(e.U, e.V)] = DefaultWeight
+	}
+	return w
+}
+
+// AsWeighted returns g as a WeightedGraph if its edges carry weights. Graphs
+// wrapped by Attributed are unwrapped.
+func AsWeighted(g Graph) (WeightedGraph, bool) {
+	switch t := g.(type) {
+	case WeightedGraph:
+		return t, true
+	case wrapper:
+		return AsWeighted(t.Unwrap())
+	}
+	return nil, false
+}
+
+// Unwrap returns the graph that stores the vertices and edges.
+func (g *Weighted) Unwrap() Graph {
+	return g.Graph
+}
+
+// return the key of the edge between u and v in the weights map
+func (g *Weighted) key(u, v Vertex) Edge {
+	if !g.directed && v < u {
+		return Edge{v, u}
+	}
+	return Edge{u, v}
+}
+
+func (g *Weighted) AddEdge(u, v Vertex) {
+	g.AddWeightedEdge(u, v, DefaultWeight)
+}
+
+func (g *Weighted) AddWeightedEdge(u, v Vertex, w float64) {
+	g.Graph.AddEdge(u, v)
+	g.weights[g.key(u, v)] = w
+}
+
+func (g *Weighted) RemoveEdge(u, v Vertex) {
+	g.Graph.RemoveEdge(u, v)
+	delete(g.weights, g.key(u, v))
+}
+
+func (g *Weighted) RemoveVertex(v Vertex) {
+	for _, n := range g.NeighborsView(v) {
+		delete(g.weights, g.key(v, n))
+	}
+	if d, ok := AsDirected(g.Graph); ok {
+		for _, n := range d.InNeighborsView(v) {
+			delete(g.weights, g.key(n, v))
+		}
+	}
+	g.Graph.RemoveVertex(v)
+}
+
+func (g *Weighted) Weight(u, v Vertex) (float64, bool) {
+	w, ok := g.weights[g.key(u, v)]
+	return w, ok
+}
+
+func (g *Weighted) SetWeight(u, v Vertex, w float64) {
+	if !g.HasEdge(u, v) {
+		panic("attempt to set the weight of a missing edge")
+	}
+	g.weights[g.key(u, v)] = w
+}
@@ -0,0 +1,43 @@
+package goraph
+
+import (
+	"testing"
+)
+
+func TestWeighted(t *testing.T) {
+	// undirected weights are the same in both directions
+	g := NewWeighted(NewDenseGraph())
+	for i := 0; i < 3; i++ {
+		g.AddVertex()
+	}
+	g.AddWeightedEdge(0, 1, 2.5)
+	g.AddEdge(1, 2)
+	if w, ok := g.Weight(1, 0); !ok || w != 2.5 {
+		t.Errorf("weight of (1,0) is %v", w)
+	}
+	if w, _ := g.Weight(2, 1); w != DefaultWeight {
+		t.Errorf("weight of (2,1) is %v", w)
+	}
+	g.SetWeight(2, 1, 4)
+	if w, _ := g.Weight(1, 2); w != 4 {
+		t.Errorf("weight of (1,2) is %v", w)
+	}
+	g.RemoveVertex(1)
+	if _, ok := g.Weight(0, 1); ok || len(g.weights) != 0 {
+		t.Error("weights of vertex 1 were not removed")
+	}
+
+	// directed weights depend on the direction
+	d := NewWeighted(NewDigraph())
+	d.AddVertex()
+	d.AddVertex()
+	d.AddWeightedEdge(0, 1, 3)
+	d.AddWeightedEdge(1, 0, 5)
+	if w, _ := d.Weight(0, 1); w != 3 {
+		t.Errorf("weight of (0,1) is %v", w)
+	}
+	d.RemoveEdge(1, 0)
+	if _, ok := d.Weight(1, 0); ok {
+		t.Error("weight of (1,0) was not removed")
+	}
+}
@@ -1,17 +0,0 @@
-package main
-
-import "sim"
-
-func main() {
-  sim.ChooseDonateManualTest()
-
-  sim.CloneWithMutationsManualTest()
-
-  sim.AssignRepManualTest()
-
-  sim.AssignRolesManualTest()
-
-  sim.SelectParentManualTest()
-
-  sim.ConflictManualTest()
-}
@@ -0,0 +1,127 @@
+package main
+
+import "errors"
+import "simpgg"
+import "simbase"
+import "simcmd"
+import "fmt"
+import "flag"
+import "time"
+import "os"
+import "path"
+import "math/rand"
+
+/*
+Run the tribal public goods game simulation with the specified arguments.
+The flags are those of "simtool pgg run" except that the stats are written
+to the file set by -f.
+
+Arguments:
+  tribes  - number of tribes
+  agents  - number of agents per tribe
+  cost    - contribution c made by a contributor
+  r       - contribution multiplier
+
+Author: John Maloney
+*/
+func main() {
+  // parse command line arguments
+  pf      := simcmd.RegisterPGGFlags(flag.CommandLine)
+  seed    := flag.Int64(simcmd.SEED_F, 0, "seed for the simulation (0 = use time)")
+  fname   := flag.String(simbase.FNAME_F, simbase.FNAME, "file to collect stats")
+  httpAddr := flag.String(simcmd.HTTP_F, "", "address (e.g. :8080) to serve the progress of the run as JSON at /status and Server-Sent Events at /events")
+  flag.String(simbase.CONFIG_F, "", "JSON or TOML file of parameter values keyed by flag name (flags override the file)")
+  flag.Parse()
+
+  // -- values from a config file are used unless set on the command line
+  err := simbase.LoadConfigFlag(flag.CommandLine)
+  if (err != nil) {
+    fmt.Fprintf(os.Stderr, "ERROR: invalid parameters: %v\n", err)
+    os.Exit(2)
+  }
+
+  // check the parameters before any output is written
+  params, err := pf.Check()
+  if (err != nil) {
+    fmt.Fprintf(os.Stderr, "ERROR: invalid parameters: %v\n", err)
+    os.Exit(2)
+  }
+
+  // serve the progress of the run if requested
+  mon, err := simcmd.StartMonitor(*httpAddr, "runsimpgg", flag.CommandLine)
+  if (err != nil) {
+    fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+    os.Exit(1)
+  }
+  defer mon.Close()
+
+  // record the parameters of the run, including the seed, next to its
+  // results
+  if (*seed == 0) {
+    *seed = time.Now().UnixNano()
+  }
+  err = simbase.WriteConfigFile(simbase.ConfigFileFor(*fname), flag.CommandLine)
+  if (err != nil) {
+    fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+    os.Exit(1)
+  }
+  m := simcmd.NewManifest("runsimpgg", os.Args[1:], simcmd.ManifestFileFor(*fname), *seed)
+  m.Config = path.Base(simbase.ConfigFileFor(*fname))
+  m.AddFile(path.Base(*fname))
+  err = m.Write()
+  if (err != nil) {
+    fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+    os.Exit(1)
+  }
+
+  // set up the output file
+  // -- it is written as <fname>.partial and renamed when the run ends
+  ofile, err := simcmd.CreateOutputFile(*fname)
+  if (err != nil) {
+    finish(m, err)
+  }
+  defer ofile.Close()
+  simcmd.WritePGGHeader(ofile)
+
+  start := time.Now()
+
+  // create simulation
+
+  // finish the current generation on SIGINT or SIGTERM
+  stop := simcmd.NotifyInterrupt()
+  defer stop.Stop()
+  s := simpgg.NewSimEngineWithRNG(*pf.NumTribes, *pf.NumAgents, params, rand.New(rand.NewSource(*seed)))
+
+  // output simulation parameters
+  fmt.Println("[")
+  simcmd.WritePGGSimParams(s, *pf.Gens, *seed, *fname)
+  fmt.Println(",")
+
+  // execute simulation
+  done := simcmd.RunPGG(s, *pf.Gens, *pf.NumTribes, *pf.NumAgents, ofile, stop.Requested, mon)
+  end := time.Now()
+
+  simcmd.WriteRuntime(end.Sub(start), stop.Requested())
+  fmt.Println("]")
+
+  // keep the stats and record how the run ended
+  m.Progress = fmt.Sprintf("%d of %d generations", done, *pf.Gens)
+  err = ofile.Commit()
+  if (err == nil) {
+    err = stop.Err()
+  }
+  finish(m, err)
+}
+
+// Record the end of the run in its manifest and exit with a status that
+// reports how it ended
+func finish(m *simcmd.Manifest, err error) {
+  err = m.Finish(err)
+  if (errors.Is(err, simcmd.ErrInterrupted)) {
+    os.Exit(simcmd.EXIT_INTERRUPTED)
+  }
+  if (err != nil) {
+    fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+    os.Exit(1)
+  }
+}
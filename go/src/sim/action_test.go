@@ -1,6 +1,8 @@
 package sim
 
 import "testing"
+import "testutil"
+import "math/rand"
 
 func TestCloneWithMutation(u *testing.T) {
   pactmut := float64(0.0)
@@ -41,3 +43,39 @@ func TestActionGetBit(u *testing.T) {
   AssertIntEqual(u, am.GetBit(2), 1)
   AssertIntEqual(u, am.GetBit(3), 0)
 }
+
+func TestCloneWithMutationRate(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(1))
+  const N = 10000
+  // CO action module
+  co := NewActionModule(true, false, true, false, PEXEERR)
+  for _, pactmut := range []float64{ 0.01, 0.1, 0.5 } {
+    // each bit mutates independently with probability pactmut
+    var mutations [4]int
+    for i := 0; i < N; i++ {
+      clone := co.CloneWithMutations(pactmut, rnGen)
+      for j := 0; j < 4; j++ {
+        if (co.bits[j] != clone.bits[j]) { mutations[j]++ }
+      }
+    }
+    for j := 0; j < 4; j++ {
+      testutil.AssertBinomial(u, mutations[j], N, pactmut)
+    }
+  }
+}
+
+func TestChooseDonateErrorRate(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(1))
+  const N = 10000
+  for _, pexeerr := range []float32{ 0.01, 0.1, 0.5 } {
+    // CO action module with and without execution errors
+    am := NewActionModule(true, false, true, false, pexeerr)
+    exact := NewActionModule(true, false, true, false, 0)
+    errors := 0
+    for i := 0; i < N; i++ {
+      donor, recip := RandRep(rnGen), RandRep(rnGen)
+      if (am.ChooseDonate(donor, recip, rnGen) != exact.ChooseDonate(donor, recip, rnGen)) { errors++ }
+    }
+    testutil.AssertBinomial(u, errors, N, float64(pexeerr))
+  }
+}
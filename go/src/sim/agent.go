@@ -59,41 +59,44 @@ func (self *Agent) ChooseDonate(recipient *Agent, rnGen *rand.Rand) bool {
   return self.actMod.ChooseDonate(self.rep, recipient.rep, rnGen)
 }
 
-// Play a round of the IR game with this agent playing the role of the
-// donor agent.  The total payout earned by both agents is returned.
+// Play a round of the IR donation game with this agent playing the role of
+// the donor agent.  The total payout earned by both agents is returned.
 func (self *Agent) PlayRound(recipient *Agent, cost int32, benefit int32, rnGen *rand.Rand) int32 {
+  return self.PlayGame(recipient, NewDonationGame(cost, benefit), rnGen)
+}
+
+// Play a round of the specified game with this agent playing the role of the
+// donor agent.  The total payout earned by both agents is returned.
+func (self *Agent) PlayGame(recipient *Agent, game Game, rnGen *rand.Rand) int32 {
   // increase number of games played
   self.numGames += 1
   recipient.numGames += 1
 
-  // keep track of total payment earned by agents
-  var totalPayout int32 = 0
-
-  // set default action
+  // choose actions
+  // -- the recipient only acts in simultaneous games
   action := REFUSE
-
-  // play round
   if (self.ChooseDonate(recipient, rnGen)) {
-    // donor donates
     action = DONATE
-    // -- recipient receives benefit
-    recipient.payout += benefit
-    // -- donor pays cost
-    self.payout -= cost
-    // update total payout
-    totalPayout += (benefit - cost)
+  }
+  recipAction := REFUSE
+  if (game.Simultaneous() && recipient.ChooseDonate(self, rnGen)) {
+    recipAction = DONATE
   }
 
-  // update donor's reputation
-  self.rep = self.tribe.assessMod.AssignRep(self.rep, recipient.rep, action, rnGen)
+  // pay the agents
+  pd, pr := game.Payoffs(action, recipAction)
+  self.payout += pd
+  recipient.payout += pr
 
-  // to prevent negative payout, each agent receives cost
-  self.payout += cost
-  recipient.payout += cost
-  totalPayout += (2*cost)
+  // update reputations based on the reputations before the round
+  donorRep := self.rep
+  self.rep = self.tribe.assessMod.AssignRep(donorRep, recipient.rep, action, rnGen)
+  if (game.Simultaneous()) {
+    recipient.rep = self.tribe.assessMod.AssignRep(recipient.rep, donorRep, recipAction, rnGen)
+  }
 
   // return total payout earned by both agents
-  return totalPayout
+  return pd + pr
 }
 
 func (self *Agent) WriteSimParams() {
@@ -1,6 +1,8 @@
 package sim
 
 import "testing"
+import "testutil"
+import "math/rand"
 
 func TestAssignRep(u *testing.T) {
   rnGen := NewRandNumGen()
@@ -65,3 +67,21 @@ func TestAssessGetBit(u *testing.T) {
   AssertIntEqual(u, am.GetBit(6), 0)
   AssertIntEqual(u, am.GetBit(7), 1)
 }
+
+func TestAssignRepErrorRate(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(1))
+  const N = 10000
+  for _, passerr := range []float32{ 0.01, 0.1, 0.5 } {
+    // stern judging assessment module with and without assessment errors
+    am := NewAssessModule(GOOD, BAD, BAD, GOOD, GOOD, BAD, BAD, GOOD, passerr)
+    exact := NewAssessModule(GOOD, BAD, BAD, GOOD, GOOD, BAD, BAD, GOOD, 0)
+    errors := 0
+    for i := 0; i < N; i++ {
+      donor, recip := RandRep(rnGen), RandRep(rnGen)
+      act := DONATE
+      if (RandBool(rnGen)) { act = REFUSE }
+      if (am.AssignRep(donor, recip, act, rnGen) != exact.AssignRep(donor, recip, act, rnGen)) { errors++ }
+    }
+    testutil.AssertBinomial(u, errors, N, float64(passerr))
+  }
+}
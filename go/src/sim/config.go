@@ -0,0 +1,79 @@
+package sim
+
+import "fmt"
+
+// The parameters of an indirect reciprocity simulation.  Start from
+// DefaultConfig and change the fields that differ from the defaults.
+type Config struct {
+  NumTribes int
+  NumAgents int     // agents per tribe
+  Beta float64      // conflict selection strength
+  Eta float64       // bit switch selection strength
+  Pcon float64      // probability of tribal conflict
+  Pmig float64      // probability of migration
+  Passmut float64   // probability of assess module bit mutation
+  Pactmut float64   // probability of action module bit mutation
+  Passerr float64   // probability of assessment error
+  Pexeerr float64   // probability of execution error
+  SingleDef bool    // each tribe can only be defeated once per generation
+  PassmutAll bool   // attempt mutation on all assess module bits
+  UseAM bool        // use adaptive mutation
+  UseMP bool        // play the tribes' rounds on multiple CPUs
+  Seed int64        // seed for the random number generators (0 = seed from the current time)
+}
+
+// Return the default configuration for the specified number of tribes and
+// agents per tribe
+func DefaultConfig(numTribes int, numAgents int) Config {
+  return Config { NumTribes: numTribes, NumAgents: numAgents,
+                  Beta: BETA, Eta: ETA, Pcon: PCON, Pmig: PMIG,
+                  Passmut: PASSMUT, Pactmut: PACTMUT, Passerr: PASSERR, Pexeerr: PEXEERR,
+                  SingleDef: SINGLE_DEF, PassmutAll: PASSMUT_ALL, UseAM: USEAM, UseMP: !NOMP }
+}
+
+// Return the configuration held in parameter maps keyed by flag names.
+// Parameters missing from the maps take their default values.
+func ConfigFromParams(numTribes int, numAgents int, params map[string]float64, bparams map[string]bool) Config {
+  cfg := DefaultConfig(numTribes, numAgents)
+  floats := map[string]*float64 { BETA_F: &cfg.Beta, ETA_F: &cfg.Eta, PCON_F: &cfg.Pcon,
+                                  PMIG_F: &cfg.Pmig, PASSM_F: &cfg.Passmut, PACTM_F: &cfg.Pactmut,
+                                  PASSE_F: &cfg.Passerr, PEXEE_F: &cfg.Pexeerr }
+  for f, p := range floats {
+    if v, ok := params[f]; ok { *p = v }
+  }
+  bools := map[string]*bool { SINGLE_DEF_F: &cfg.SingleDef, PASSMUT_ALL_F: &cfg.PassmutAll,
+                              USEAM_F: &cfg.UseAM }
+  for f, p := range bools {
+    if v, ok := bparams[f]; ok { *p = v }
+  }
+  if noMP, ok := bparams[NOMP_F]; ok {
+    cfg.UseMP = !noMP
+  }
+  return cfg
+}
+
+// Return an error describing the first invalid parameter.  The seed can
+// take any value.
+func (self Config) Validate() error {
+  switch {
+  case (self.NumTribes < 1):
+    return fmt.Errorf("-%s: number of tribes must be at least 1", TRIBES_F)
+  case (self.NumAgents < 2):
+    return fmt.Errorf("-%s: number of agents must be at least 2", AGENTS_F)
+  case (self.Beta < 0):
+    return fmt.Errorf("-%s: selection strength must not be negative", BETA_F)
+  case (self.Eta < 0):
+    return fmt.Errorf("-%s: selection strength must not be negative", ETA_F)
+  }
+  // probabilities must be between 0 and 1
+  probs := []struct { flag string; p float64 } {
+    { PCON_F, self.Pcon }, { PMIG_F, self.Pmig }, { PASSM_F, self.Passmut },
+    { PACTM_F, self.Pactmut }, { PASSE_F, self.Passerr }, { PEXEE_F, self.Pexeerr },
+  }
+  for _, prob := range probs {
+    if ((prob.p < 0) || (prob.p > 1)) {
+      return fmt.Errorf("-%s: probability must be between 0 and 1", prob.flag)
+    }
+  }
+  return nil
+}
@@ -0,0 +1,93 @@
+package sim
+
+import "testing"
+
+func TestConfigFromParams(u *testing.T) {
+  // missing parameters take their default values
+  cfg := ConfigFromParams(2, 3, map[string]float64{}, map[string]bool{})
+  AssertTrue(u, cfg == DefaultConfig(2, 3))
+
+  params := map[string]float64{ BETA_F: 2, PCON_F: 0.5 }
+  bparams := map[string]bool{ USEAM_F: true, NOMP_F: true }
+  cfg = ConfigFromParams(2, 3, params, bparams)
+  AssertFloat64Equal(u, cfg.Beta, 2)
+  AssertFloat64Equal(u, cfg.Pcon, 0.5)
+  AssertFloat64Equal(u, cfg.Eta, ETA)
+  AssertTrue(u, cfg.UseAM)
+  AssertFalse(u, cfg.UseMP)
+  AssertTrue(u, cfg.PassmutAll == PASSMUT_ALL)
+
+  // a missing am key doesn't change passmutall
+  bparams = map[string]bool{ PASSMUT_ALL_F: true }
+  s := NewSimEngine(2, 3, params, bparams)
+  AssertTrue(u, s.passmutall)
+  AssertTrue(u, s.useAM == USEAM)
+}
+
+func TestConfigValidate(u *testing.T) {
+  AssertTrue(u, DefaultConfig(NUMTRIBES, NUMAGENTS).Validate() == nil)
+
+  invalid := []func(cfg *Config) {
+    func(cfg *Config) { cfg.NumTribes = 0 },
+    func(cfg *Config) { cfg.NumAgents = 1 },
+    func(cfg *Config) { cfg.Beta = -1 },
+    func(cfg *Config) { cfg.Eta = -0.1 },
+    func(cfg *Config) { cfg.Pcon = 1.5 },
+    func(cfg *Config) { cfg.Pexeerr = -0.5 },
+  }
+  for _, change := range invalid {
+    cfg := DefaultConfig(2, 2)
+    change(&cfg)
+    AssertFalse(u, cfg.Validate() == nil)
+    s, err := NewSimEngineWithConfig(cfg)
+    AssertTrue(u, s == nil)
+    AssertFalse(u, err == nil)
+  }
+
+  cfg := DefaultConfig(2, 2)
+  cfg.UseMP = false
+  s, err := NewSimEngineWithConfig(cfg)
+  AssertTrue(u, err == nil)
+  AssertIntEqual(u, len(s.tribes), 2)
+  AssertFalse(u, s.useMP)
+}
+
+func TestConfigLenient(u *testing.T) {
+  // the flag map constructors accept the parameters that Validate rejects
+  s := NewDefaultSimEngine(2, 1, false, false)
+  AssertIntEqual(u, len(s.tribes), 2)
+  params := map[string]float64{ PMIG_F: 1.5 }
+  bparams := map[string]bool{ NOMP_F: true }
+  s = NewSimEngine(2, 1, params, bparams)
+  AssertFloat64Equal(u, float64(s.pmig), 1.5)
+
+  // the configuration constructors reject them
+  _, err := NewSimEngineWithConfig(ConfigFromParams(2, 1, params, bparams))
+  AssertFalse(u, err == nil)
+  defer func() {
+    AssertTrue(u, recover() != nil)
+  }()
+  MustNewSimEngine(ConfigFromParams(2, 1, params, bparams))
+}
+
+func TestConfigSeed(u *testing.T) {
+  // engines with the same seed evolve in the same way
+  cfg := DefaultConfig(4, 4)
+  cfg.UseMP = false
+  cfg.Seed = 7
+  game := NewDonationGame(COST, BENEFIT)
+  var payouts [2][]int32
+  for i := range payouts {
+    s := MustNewSimEngine(cfg)
+    minPO, maxPO := s.MinMaxGamePayouts(game)
+    for g := 0; g < 5; g++ {
+      nextGen := s.PlayGameRounds(game)
+      payouts[i] = append(payouts[i], s.GetTotalPayouts())
+      s.EvolveTribes(nextGen, minPO, maxPO)
+      s.Reset()
+    }
+  }
+  for g := range payouts[0] {
+    AssertInt32Equal(u, payouts[0][g], payouts[1][g])
+  }
+}
@@ -0,0 +1,157 @@
+package sim
+
+import "fmt"
+
+// A two-player game played by a donor and a recipient.  The donor uses its
+// action module to choose whether to cooperate (DONATE) or defect (REFUSE).
+// In a simultaneous game the recipient also chooses an action and is
+// assessed in the same way as the donor; otherwise the recipient's action is
+// always REFUSE and only the donor is assessed.  Payoffs must not be negative
+// because agents reproduce in proportion to their payouts.
+type Game interface {
+  // Payoffs returns the payoffs of the donor and the recipient when they
+  // take the specified actions
+  Payoffs(donor Act, recip Act) (pd int32, pr int32)
+
+  // Simultaneous returns true if the recipient also chooses an action
+  Simultaneous() bool
+}
+
+// Types of two-player game
+const (
+  GAME_DONATION = iota // donation game defined by cost and benefit
+  GAME_PD              // simultaneous prisoner's dilemma defined by cost and benefit
+  GAME_MATRIX          // game defined by the payoffs R, S, T and P
+)
+
+// Create a two-player game of the specified type.  Only the parameters used
+// by the type of game are checked.
+func NewGame(gameType int, cost int32, benefit int32, R int32, S int32, T int32, P int32) (Game, error) {
+  switch gameType {
+  case GAME_DONATION, GAME_PD:
+    if ((cost < 0) || (benefit < 0)) {
+      return nil, fmt.Errorf("cost and benefit must not be negative")
+    }
+    if (gameType == GAME_PD) {
+      return NewPrisonersDilemma(cost, benefit), nil
+    }
+    return NewDonationGame(cost, benefit), nil
+  case GAME_MATRIX:
+    return NewMatrixGame(R, S, T, P), nil
+  }
+  return nil, fmt.Errorf("unknown game type: %d", gameType)
+}
+
+// The donation game: the donor pays cost c for the recipient to receive
+// benefit b.  To prevent negative payouts, each player also receives c.
+type DonationGame struct {
+  cost int32
+  benefit int32
+}
+
+func NewDonationGame(cost int32, benefit int32) *DonationGame {
+  return &DonationGame { cost: cost, benefit: benefit }
+}
+
+func (self *DonationGame) Payoffs(donor Act, recip Act) (pd int32, pr int32) {
+  if (donor == DONATE) {
+    return 0, self.benefit + self.cost
+  }
+  return self.cost, self.cost
+}
+
+func (self *DonationGame) Simultaneous() bool {
+  return false
+}
+
+func (self *DonationGame) String() string {
+  return fmt.Sprintf("donation(c=%d,b=%d)", self.cost, self.benefit)
+}
+
+// A symmetric game defined by a 2x2 payoff matrix in which both players
+// choose an action:
+//
+//               DONATE   REFUSE
+//     DONATE    R, R     S, T
+//     REFUSE    T, S     P, P
+//
+// The prisoner's dilemma has T > R > P > S, the snowdrift game T > R > S > P
+// and the stag hunt R > T >= P > S.  If any payoff is negative then all of
+// the payoffs are shifted up so that the smallest is zero.
+type MatrixGame struct {
+  R, S, T, P int32
+  shift int32 // added to each payoff to prevent negative payouts
+}
+
+func NewMatrixGame(R int32, S int32, T int32, P int32) *MatrixGame {
+  shift := -min(R, S, T, P, 0)
+  return &MatrixGame { R: R, S: S, T: T, P: P, shift: shift }
+}
+
+// Create the prisoner's dilemma in which each player that cooperates pays
+// cost c for the other player to receive benefit b.
+func NewPrisonersDilemma(cost int32, benefit int32) *MatrixGame {
+  return NewMatrixGame(benefit - cost, -cost, benefit, 0)
+}
+
+func (self *MatrixGame) Payoffs(donor Act, recip Act) (pd int32, pr int32) {
+  switch {
+  case (donor == DONATE) && (recip == DONATE):
+    pd, pr = self.R, self.R
+  case (donor == DONATE):
+    pd, pr = self.S, self.T
+  case (recip == DONATE):
+    pd, pr = self.T, self.S
+  default:
+    pd, pr = self.P, self.P
+  }
+  return pd + self.shift, pr + self.shift
+}
+
+func (self *MatrixGame) Simultaneous() bool {
+  return true
+}
+
+func (self *MatrixGame) String() string {
+  return fmt.Sprintf("matrix(R=%d,S=%d,T=%d,P=%d)", self.R, self.S, self.T, self.P)
+}
+
+// A game defined by a payoff function
+type GameFunc struct {
+  payoffs func(donor Act, recip Act) (int32, int32)
+  simultaneous bool
+}
+
+// Create a game from a payoff function.  The recipient chooses an action
+// only if the game is simultaneous.
+func NewGameFunc(payoffs func(donor Act, recip Act) (int32, int32), simultaneous bool) *GameFunc {
+  return &GameFunc { payoffs: payoffs, simultaneous: simultaneous }
+}
+
+func (self *GameFunc) Payoffs(donor Act, recip Act) (pd int32, pr int32) {
+  return self.payoffs(donor, recip)
+}
+
+func (self *GameFunc) Simultaneous() bool {
+  return self.simultaneous
+}
+
+// Calculate the minimum and maximum total payout that the two players can
+// earn in a single round of the game
+func MinMaxRoundPayouts(game Game) (min int32, max int32) {
+  acts := []Act{DONATE, REFUSE}
+  first := true
+  for _, ad := range acts {
+    for _, ar := range acts {
+      if (!game.Simultaneous() && (ar == DONATE)) {
+        // the recipient always refuses
+        continue
+      }
+      pd, pr := game.Payoffs(ad, ar)
+      if (first || (pd+pr < min)) { min = pd + pr }
+      if (first || (pd+pr > max)) { max = pd + pr }
+      first = false
+    }
+  }
+  return min, max
+}
@@ -0,0 +1,66 @@
+package sim
+
+import "testing"
+
+func TestDonationGame(u *testing.T) {
+  game := NewDonationGame(1, 3)
+  pd, pr := game.Payoffs(DONATE, REFUSE)
+  AssertInt32Equal(u, pd, 0)
+  AssertInt32Equal(u, pr, 4)
+  pd, pr = game.Payoffs(REFUSE, DONATE)
+  AssertInt32Equal(u, pd, 1)
+  AssertInt32Equal(u, pr, 1)
+
+  // the game gives the same payouts as before games were pluggable
+  min, max := CalcMinMaxGamePayouts(4, game)
+  AssertInt32Equal(u, min, 6*2)
+  AssertInt32Equal(u, max, 6*4)
+}
+
+func TestMatrixGame(u *testing.T) {
+  // snowdrift payoffs
+  game := NewMatrixGame(3, 1, 4, 0)
+  pd, pr := game.Payoffs(DONATE, REFUSE)
+  AssertInt32Equal(u, pd, 1)
+  AssertInt32Equal(u, pr, 4)
+  pd, pr = game.Payoffs(REFUSE, DONATE)
+  AssertInt32Equal(u, pd, 4)
+  AssertInt32Equal(u, pr, 1)
+  min, max := MinMaxRoundPayouts(game)
+  AssertInt32Equal(u, min, 0)
+  AssertInt32Equal(u, max, 6)
+
+  // negative payoffs are shifted so that the smallest is zero
+  pdg := NewPrisonersDilemma(1, 3)
+  pd, pr = pdg.Payoffs(DONATE, REFUSE)
+  AssertInt32Equal(u, pd, 0)
+  AssertInt32Equal(u, pr, 4)
+  pd, pr = pdg.Payoffs(DONATE, DONATE)
+  AssertInt32Equal(u, pd, 3)
+  AssertInt32Equal(u, pr, 3)
+
+  _, err := NewGame(GAME_MATRIX+1, 1, 3, 0, 0, 0, 0)
+  AssertFalse(u, err == nil)
+}
+
+func TestPlayGameSimultaneous(u *testing.T) {
+  rnGen := NewRandNumGen()
+  t := NewTribe(2, 0, PACTMUT, 0, rnGen)
+  // cooperation with GOOD agents and refusal is BAD
+  t.assessMod = NewAssessModule(GOOD, BAD, GOOD, BAD, GOOD, BAD, GOOD, BAD, 0)
+  don := t.agents[0]
+  rec := t.agents[1]
+  don.actMod = NewActionModule(true, true, true, true, 0)
+  rec.actMod = NewActionModule(false, false, false, false, 0)
+
+  // stag hunt payoffs - the recipient chooses too
+  game := NewGameFunc(func(d Act, r Act) (int32, int32) {
+    return NewMatrixGame(4, 0, 3, 2).Payoffs(d, r)
+  }, true)
+  AssertInt32Equal(u, don.PlayGame(rec, game, rnGen), 3)
+  AssertInt32Equal(u, don.payout, 0)
+  AssertInt32Equal(u, rec.payout, 3)
+  // both agents are assessed
+  AssertRepEqual(u, don.rep, GOOD)
+  AssertRepEqual(u, rec.rep, BAD)
+}
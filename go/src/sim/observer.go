@@ -0,0 +1,83 @@
+package sim
+
+// An observer of the events of a simulation.  The engine reports each event
+// to its observers in the order they were added.  Tribes are identified by
+// their position in the engine, which is the same in every generation.
+// Embed NopObserver to observe only some of the events.
+type Observer interface {
+  // called before the games of a generation are played
+  GenStart(s *SimEngine, gen int)
+
+  // called once a generation has been evolved and the next generation is
+  // ready - payouts is the total payout earned in the generation
+  GenEnd(s *SimEngine, gen int, payouts int32)
+
+  // the winner defeated the loser in a conflict
+  Conflict(s *SimEngine, winner int, loser int)
+
+  // n agents of the losing tribe took the action modules of agents of the
+  // winning tribe
+  Migration(s *SimEngine, from int, to int, n int)
+
+  // the assessment module (the social norm) of a tribe changed - the
+  // modules are given by their bits (see AssessModule.GetBits)
+  StrategyChange(s *SimEngine, tribe int, oldBits int, newBits int)
+}
+
+// An observer that ignores every event
+type NopObserver struct {}
+
+func (self NopObserver) GenStart(s *SimEngine, gen int) {}
+func (self NopObserver) GenEnd(s *SimEngine, gen int, payouts int32) {}
+func (self NopObserver) Conflict(s *SimEngine, winner int, loser int) {}
+func (self NopObserver) Migration(s *SimEngine, from int, to int, n int) {}
+func (self NopObserver) StrategyChange(s *SimEngine, tribe int, oldBits int, newBits int) {}
+
+// Add an observer that is notified of the events of every generation
+func (self *SimEngine) AddObserver(o Observer) {
+  self.observers = append(self.observers, o)
+}
+
+// Set a function that Run calls after each generation to find out whether
+// it should stop early, e.g. because the program was interrupted
+func (self *SimEngine) SetStop(stop func() bool) {
+  self.stop = stop
+}
+
+// Simulate the specified number of generations of the game.  The observers
+// passed to Run are notified of the events of this run along with the
+// observers added to the engine.  Returns the number of generations
+// completed, which is less than gens if the stop function returned true.
+func (self *SimEngine) Run(game Game, gens int, observers ...Observer) int {
+  numObservers := len(self.observers)
+  self.observers = append(self.observers, observers...)
+  defer func() { self.observers = self.observers[:numObservers] }()
+
+  minPO, maxPO := self.MinMaxGamePayouts(game)
+  for g := 0; g < gens; g++ {
+    for _, o := range self.observers {
+      o.GenStart(self, g)
+    }
+    nextGen := self.PlayGameRounds(game)
+    p := self.GetTotalPayouts()
+    self.EvolveTribes(nextGen, minPO, maxPO)
+    self.Reset()
+    for _, o := range self.observers {
+      o.GenEnd(self, g, p)
+    }
+    if ((self.stop != nil) && self.stop()) {
+      return g + 1
+    }
+  }
+  return gens
+}
+
+// Return the number of tribes
+func (self *SimEngine) NumTribes() int {
+  return self.numTribes
+}
+
+// Return the number of agents in each tribe
+func (self *SimEngine) NumAgents() int {
+  return self.tribes[0].numAgents
+}
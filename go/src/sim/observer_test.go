@@ -0,0 +1,65 @@
+package sim
+
+import "testing"
+
+// an observer that counts the events of a simulation
+type countingObserver struct {
+  NopObserver
+  t *testing.T
+  starts, ends, conflicts, migrations, changes int
+}
+
+func (self *countingObserver) GenStart(s *SimEngine, gen int) {
+  self.starts++
+}
+
+func (self *countingObserver) GenEnd(s *SimEngine, gen int, payouts int32) {
+  self.ends++
+}
+
+func (self *countingObserver) Conflict(s *SimEngine, winner int, loser int) {
+  AssertFalse(self.t, winner == loser)
+  self.conflicts++
+}
+
+func (self *countingObserver) Migration(s *SimEngine, from int, to int, n int) {
+  // every agent migrates when pmig is 1
+  AssertIntEqual(self.t, n, s.NumAgents())
+  self.migrations++
+}
+
+func (self *countingObserver) StrategyChange(s *SimEngine, tribe int, oldBits int, newBits int) {
+  AssertTrue(self.t, (tribe >= 0) && (tribe < s.NumTribes()))
+  AssertFalse(self.t, oldBits == newBits)
+  self.changes++
+}
+
+func TestObserver(u *testing.T) {
+  cfg := DefaultConfig(4, 4)
+  cfg.Pcon = 1
+  cfg.Pmig = 1
+  cfg.Seed = 1
+  s := MustNewSimEngine(cfg)
+  game := NewDonationGame(COST, BENEFIT)
+
+  added := &countingObserver { t: u }
+  s.AddObserver(added)
+  o := &countingObserver { t: u }
+  AssertIntEqual(u, s.Run(game, 3, o), 3)
+  // every pair of tribes is in conflict in every generation
+  AssertIntEqual(u, o.starts, 3)
+  AssertIntEqual(u, o.ends, 3)
+  AssertIntEqual(u, o.conflicts, 3*6)
+  AssertIntEqual(u, o.migrations, o.conflicts)
+  AssertTrue(u, *added == *o)
+
+  // the observers passed to Run only observe that run
+  calls := 0
+  s.SetStop(func() bool {
+    calls++
+    return calls >= 2
+  })
+  AssertIntEqual(u, s.Run(game, 3), 2)
+  AssertIntEqual(u, o.ends, 3)
+  AssertIntEqual(u, added.ends, 5)
+}
@@ -26,71 +26,58 @@ type SimEngine struct {
   passmut float64 // prob of assess module bit mutation: recommended 0.0001
   passmutall bool // false if only matching assmod bits shoudl be mutated
   useAM bool // indicates whether adaptive mutation should be used
+  observers []Observer // notified of the events of each generation
+  stop func() bool // if not nil, Run stops early once it returns true
 }
 
 func NewDefaultSimEngine(numTribes int, numAgents int, useAM bool, useMP bool) *SimEngine {
-  // create parameter map for floats
-  var params = make(map[string]float64)
-
-  // populate arg map for floats
-  params[PASSE_F] = PASSERR
-  params[PACTM_F] = PACTMUT
-  params[PEXEE_F] = PEXEERR
-  params[PCON_F]  = PCON
-  params[BETA_F]  = BETA
-  params[ETA_F]   = ETA
-  params[PMIG_F]  = PMIG
-  params[PASSM_F] = PASSMUT
-
-  // create parameter map for booleans
-  var bparams = make(map[string]bool)
+  cfg := DefaultConfig(numTribes, numAgents)
+  cfg.UseAM = useAM
+  cfg.UseMP = useMP
+  return newSimEngine(cfg)
+}
 
-  // populate arg map for booleans
-  bparams[SINGLE_DEF_F]  = SINGLE_DEF
-  bparams[PASSMUT_ALL_F] = PASSMUT_ALL
-  bparams[USEAM_F]       = useAM
-  bparams[NOMP_F]        = !useMP
+// Make a new simulation engine from parameter maps keyed by flag names.
+// Parameters missing from the maps take their default values.  Like the
+// default engine, the parameters are used as given without being validated;
+// use NewSimEngineWithConfig to reject invalid parameters.
+func NewSimEngine(numTribes int, numAgents int, params map[string]float64, bparams map[string]bool) *SimEngine {
+  return newSimEngine(ConfigFromParams(numTribes, numAgents, params, bparams))
+}
 
-  // create simulation engine with default values
-  return NewSimEngine(numTribes, numAgents, params, bparams)
+// Make a new simulation engine, panicking if the configuration is invalid
+func MustNewSimEngine(cfg Config) *SimEngine {
+  s, err := NewSimEngineWithConfig(cfg)
+  if (err != nil) {
+    panic(err)
+  }
+  return s
 }
 
-// Make a new simulation engine.
-func NewSimEngine(numTribes int, numAgents int, params map[string]float64, bparams map[string]bool) *SimEngine {
-  // get float parameters
-  passerr, ok := params[PASSE_F]
-  if (!ok) { passerr = PASSERR }
-  pactmut, ok := params[PACTM_F]
-  if (!ok) { pactmut = PACTMUT }
-  pexeerr, ok := params[PEXEE_F]
-  if (!ok) { pexeerr = PEXEERR }
-  pcon, ok := params[PCON_F]
-  if (!ok) { pcon = PCON }
-  beta, ok := params[BETA_F]
-  if (!ok) { beta = BETA }
-  eta, ok := params[ETA_F]
-  if (!ok) { eta = ETA }
-  pmig, ok := params[PMIG_F]
-  if (!ok) { pmig = PMIG }
-  passmut, ok := params[PASSM_F]
-  if (!ok) { passmut = PASSMUT }
+// Make a new simulation engine.  Returns an error if the configuration is
+// invalid.
+func NewSimEngineWithConfig(cfg Config) (*SimEngine, error) {
+  err := cfg.Validate()
+  if (err != nil) {
+    return nil, err
+  }
+  return newSimEngine(cfg), nil
+}
 
-  // get boolean parameters
-  singledef, ok := bparams[SINGLE_DEF_F]
-  if (!ok) { singledef = SINGLE_DEF }
-  passmutall, ok := bparams[PASSMUT_ALL_F]
-  if (!ok) { passmutall = PASSMUT_ALL }
-  useAM, ok := bparams[USEAM_F]
-  if (!ok) { passmutall = USEAM }
-  noMP, ok := bparams[NOMP_F]
-  if (!ok) { noMP = NOMP }
-  useMP := !noMP
+// make a new simulation engine without validating the configuration
+func newSimEngine(cfg Config) *SimEngine {
+  numTribes := cfg.NumTribes
+  useMP := cfg.UseMP
 
   // create tribes
   tribes := make([]*Tribe, numTribes)
-  rnGen := rand.New(rand.NewSource(time.Now().UnixNano()))
+  seed := cfg.Seed
+  if (seed == 0) {
+    seed = time.Now().UnixNano()
+  }
+  rnGen := rand.New(rand.NewSource(seed))
   for i := 0; i < numTribes; i++ {
-    tribes[i] = NewTribe(numAgents, float32(passerr), pactmut, float32(pexeerr), rnGen)
+    tribes[i] = NewTribe(cfg.NumAgents, float32(cfg.Passerr), cfg.Pactmut, float32(cfg.Pexeerr), rnGen)
   }
   // figure out multiprocessing parameters if MP enabled
   ncpu := runtime.NumCPU()
@@ -101,7 +88,7 @@ func NewSimEngine(numTribes int, numAgents int, params map[string]float64, bpara
     tasksPerCpu := int(math.Ceil(float64(numTribes)/float64(ncpu)))
     taskSum := 0
     for i := 0; i < ncpu; i++ {
-      cpuRNG[i] = NewRandNumGen()
+      cpuRNG[i] = rand.New(rand.NewSource(rnGen.Int63()))
       if ((numTribes - taskSum) > tasksPerCpu) {
         cpuTasks[i] = tasksPerCpu
         taskSum += tasksPerCpu
@@ -114,10 +101,10 @@ func NewSimEngine(numTribes int, numAgents int, params map[string]float64, bpara
 
   // create sim engine
   return &SimEngine { tribes: tribes, numTribes: numTribes, totalPayouts: 0,
-                      pcon: float32(pcon), beta: beta, eta: eta, pmig: float32(pmig),
+                      pcon: float32(cfg.Pcon), beta: cfg.Beta, eta: cfg.Eta, pmig: float32(cfg.Pmig),
                       useMP: useMP, numCpu: ncpu, cpuTasks: cpuTasks, cpuRNG: cpuRNG,
-                      rnGen: rnGen, passmut: passmut, passmutall: passmutall,
-                      singdef: singledef, useAM: useAM }
+                      rnGen: rnGen, passmut: cfg.Passmut, passmutall: cfg.PassmutAll,
+                      singdef: cfg.SingleDef, useAM: cfg.UseAM }
 }
 
 // Get the total payouts earned by al tribes in the most recent generation
@@ -138,9 +125,15 @@ func (self *SimEngine) Reset() {
   }
 }
 
-// Play the required rounds of the IR game to complete the current generation.
-// Create and return the next generation.
+// Play the required rounds of the IR donation game to complete the current
+// generation.  Create and return the next generation.
 func (self *SimEngine) PlayRounds(cost int32, benefit int32) (nextGen []*Tribe) {
+  return self.PlayGameRounds(NewDonationGame(cost, benefit))
+}
+
+// Play the required rounds of the specified game to complete the current
+// generation.  Create and return the next generation.
+func (self *SimEngine) PlayGameRounds(game Game) (nextGen []*Tribe) {
   nextGen = make([]*Tribe, self.numTribes)
   if (self.useMP) {
     // create channel to collect payouts from each parallel task
@@ -153,7 +146,7 @@ func (self *SimEngine) PlayRounds(cost int32, benefit int32) (nextGen []*Tribe)
       go func (tribeStart int, tribeEnd int, rnGen *rand.Rand) {
         task_payouts := int32(0)
         for j := tribeStart; j < tribeEnd; j++ {
-          task_payouts += self.tribes[j].PlayRounds(cost, benefit, rnGen)
+          task_payouts += self.tribes[j].PlayGameRounds(game, rnGen)
           nextGen[j] = self.tribes[j].CreateNextGen(rnGen)
         }
         payouts <- task_payouts
@@ -165,7 +158,7 @@ func (self *SimEngine) PlayRounds(cost int32, benefit int32) (nextGen []*Tribe)
     }
   } else {
     for i := 0; i < self.numTribes; i++ {
-      self.totalPayouts += self.tribes[i].PlayRounds(cost, benefit, self.rnGen)
+      self.totalPayouts += self.tribes[i].PlayGameRounds(game, self.rnGen)
       nextGen[i] = self.tribes[i].CreateNextGen(self.rnGen)
     }
   }
@@ -178,6 +171,12 @@ func (self *SimEngine) MinMaxTribalPayouts(cost int32, benefit int32) (min int32
   return CalcMinMaxTribalPayouts(self.tribes[0].numAgents, cost, benefit)
 }
 
+// Calculate the minimum and maximum total payout that can be earned by a tribe
+// in a single generation of the specified game
+func (self *SimEngine) MinMaxGamePayouts(game Game) (min int32, max int32) {
+  return CalcMinMaxGamePayouts(self.tribes[0].numAgents, game)
+}
+
 // Evolve the tribal assessment modules based on the average payouts
 // earned by each tribe during the last generation
 func (self *SimEngine) EvolveTribes(nextGen []*Tribe, minPO, maxPO int32) {
@@ -189,11 +188,23 @@ func (self *SimEngine) EvolveTribes(nextGen []*Tribe, minPO, maxPO int32) {
   var currentWinner *Tribe
   var ok bool
 
+  // map each tribe to its position so that observers can be told which
+  // tribes changed
+  // -- winners come from the original list and losers from the new list
+  position := make(map[*Tribe]int, 2*self.numTribes)
+  for i := 0; i < self.numTribes; i++ {
+    position[self.tribes[i]] = i
+    position[nextGen[i]] = i
+  }
+
   // iterate over the tribes and select pairs for confict
   for i := 0; i < self.numTribes; i++ {
     for j := i+1; j < self.numTribes; j++ {
       if (RandPercent(self.rnGen) < float64(self.pcon)) {
         w, l := self.Conflict(i, j, self.rnGen)
+        for _, o := range self.observers {
+          o.Conflict(self, w, l)
+        }
         // append the loser to the winner's list of defeated tribes
         // -- take winner from original list (it will be source of modifications)
         winner := self.tribes[w]
@@ -241,8 +252,7 @@ func (self *SimEngine) EvolveTribes(nextGen []*Tribe, minPO, maxPO int32) {
       }
     } else { // not self.useMP
       for loser, winner := range loserToWinner {
-        self.ShiftAssessMod(winner, loser, self.useAM, minPO, maxPO, self.rnGen)
-        self.MigrateAgents(winner, loser, self.rnGen)
+        self.evolveLoser(winner, loser, position, minPO, maxPO)
       }
     }
   } else {
@@ -268,8 +278,7 @@ func (self *SimEngine) EvolveTribes(nextGen []*Tribe, minPO, maxPO int32) {
       for _, loser := range losers {
         // winner comes from original list (source of modifications)
         // loser comes from new list (will be modified)
-        self.ShiftAssessMod(winner, loser, self.useAM, minPO, maxPO, self.rnGen)
-        self.MigrateAgents(winner, loser, self.rnGen)
+        self.evolveLoser(winner, loser, position, minPO, maxPO)
       }
     }
   }
@@ -278,13 +287,35 @@ func (self *SimEngine) EvolveTribes(nextGen []*Tribe, minPO, maxPO int32) {
   self.tribes = nextGen
 }
 
-// Migrate some agents from the first tribe to the second tribe
-func (self *SimEngine) MigrateAgents(from *Tribe, to *Tribe, rnGen *rand.Rand) {
+// shift the loser's assessment module toward the winner's and migrate agents
+// from the winner to the loser, telling the observers what changed
+func (self *SimEngine) evolveLoser(winner *Tribe, loser *Tribe, position map[*Tribe]int,
+                                   minPO int32, maxPO int32) {
+  oldBits := loser.assessMod.GetBits()
+  self.ShiftAssessMod(winner, loser, self.useAM, minPO, maxPO, self.rnGen)
+  n := self.MigrateAgents(winner, loser, self.rnGen)
+  newBits := loser.assessMod.GetBits()
+  for _, o := range self.observers {
+    if (newBits != oldBits) {
+      o.StrategyChange(self, position[loser], oldBits, newBits)
+    }
+    if (n > 0) {
+      o.Migration(self, position[winner], position[loser], n)
+    }
+  }
+}
+
+// Migrate some agents from the first tribe to the second tribe.  Returns the
+// number of agents that migrated.
+func (self *SimEngine) MigrateAgents(from *Tribe, to *Tribe, rnGen *rand.Rand) int {
+  n := 0
   for i := 0; i < to.numAgents; i++ {
     if (RandPercent(rnGen) < float64(self.pmig)) {
       to.agents[i].actMod = from.agents[i].actMod
+      n++
     }
   }
+  return n
 }
 
 // Collect statistics for the most recently completed generation
@@ -1,6 +1,7 @@
 package sim
 
 import "testing"
+import "testutil"
 import "math"
 import "math/rand"
 
@@ -67,6 +68,32 @@ func TestConflict(u *testing.T) {
   AssertTrue(u, (l ==0) || (l == 1))
 }
 
+func TestConflictWinRate(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(1))
+  const N = 10000
+  numAgents := 2
+  s := NewDefaultSimEngine(2, numAgents, true, true)
+
+  for _, beta := range []float64{ 0.1, 0.6, 1 } {
+    for _, payouts := range [][2]int32{ { 10, 10 }, { 10, 12 }, { 10, 20 }, { 20, 10 } } {
+      s.beta = beta
+      s.tribes[0].totalPayouts = payouts[0]
+      s.tribes[1].totalPayouts = payouts[1]
+      // tribe 1 wins with the probability given by the Fermi function of
+      // the difference in average payouts
+      diff := float64(payouts[1] - payouts[0]) / float64(numAgents)
+      p := 1 / (1 + math.Exp(-beta * diff))
+      wins := 0
+      for i := 0; i < N; i++ {
+        w, l := s.Conflict(0, 1, rnGen)
+        AssertIntEqual(u, w + l, 1)
+        if (w == 1) { wins++ }
+      }
+      testutil.AssertBinomial(u, wins, N, p)
+    }
+  }
+}
+
 func TestShiftAssessMod(u *testing.T) {
   rnGen := NewRandNumGen()
   numTribes := 2
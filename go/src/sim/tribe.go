@@ -42,7 +42,7 @@ func (t *Tribe) String() string {
   str = str + fmt.Sprintf("  \"num-agents\":%d\n", t.numAgents)
   str = str + fmt.Sprintf("  \"assess-mod\":%v\n", t.assessMod)
   str = str + fmt.Sprintf("  \"total-payouts\":%d \n", t.totalPayouts)
-  str = str + fmt.Sprintf("  \"agents\":%d \n", t.agents)
+  str = str + fmt.Sprintf("  \"agents\":%v \n", t.agents)
   str = str + "}"
   return str
 }
@@ -55,8 +55,15 @@ func (self *Tribe) Reset() {
   }
 }
 
-// Play the required rounds of the IR game to complete the current generation.
+// Play the required rounds of the IR donation game to complete the current
+// generation.
 func (self *Tribe) PlayRounds(cost int32, benefit int32, rnGen *rand.Rand) int32 {
+  return self.PlayGameRounds(NewDonationGame(cost, benefit), rnGen)
+}
+
+// Play the required rounds of the specified game to complete the current
+// generation.
+func (self *Tribe) PlayGameRounds(game Game, rnGen *rand.Rand) int32 {
   var donor *Agent
   var recipient *Agent
   // randomize the order of the agents
@@ -67,7 +74,7 @@ func (self *Tribe) PlayRounds(cost int32, benefit int32, rnGen *rand.Rand) int32
       donor, recipient = self.AssignRoles(self.agents[i], self.agents[j], rnGen)
 
       // play the round
-      self.totalPayouts += donor.PlayRound(recipient, cost, benefit, rnGen)
+      self.totalPayouts += donor.PlayGame(recipient, game, rnGen)
     }
   }
 
@@ -91,14 +98,20 @@ func (self *Tribe) AssignRoles(a1 *Agent, a2 *Agent, rnGen *rand.Rand) (donor, r
 }
 
 // Randomly select an agent from the local population.  The chance that an
-// agent is selected is proportional to its fitness.
+// agent is selected is proportional to its fitness.  If the agents earned
+// no payouts, each agent is equally likely to be selected.
 func (self *Tribe) SelectParent(rnGen *rand.Rand) *Agent {
+  if (self.totalPayouts <= 0) {
+    return self.agents[RandInt(rnGen, int64(self.numAgents))]
+  }
   ri := int32(RandInt(rnGen, int64(self.totalPayouts)))
   thresh := int32(0);
   var parent *Agent
   for i := 0; i < self.numAgents; i++ {
     thresh += self.agents[i].payout
-    if (ri <= thresh) {
+    // -- ri is below the total payouts, so agent i is selected for as many
+    //    of its values as the agent's payout
+    if (ri < thresh) {
       parent = self.agents[i]
       break
     }
@@ -1,6 +1,8 @@
 package sim
 
 import "testing"
+import "testutil"
+import "math/rand"
 import "sort"
 
 func TestNewTribe(u *testing.T) {
@@ -216,3 +218,59 @@ func TestSortByPayout(u *testing.T) {
     current = tribes[i].totalPayouts
   }
 }
+
+func TestAssignRolesFairness(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(1))
+  const N = 10000
+  t := NewTribe(2, PASSERR, PACTMUT, PEXEERR, rnGen)
+
+  // each agent is the donor half of the time
+  donations := 0
+  for i := 0; i < N; i++ {
+    donor, recip := t.AssignRoles(t.agents[0], t.agents[1], rnGen)
+    AssertTrue(u, donor != recip)
+    if (donor == t.agents[0]) { donations++ }
+  }
+  testutil.AssertBinomial(u, donations, N, 0.5)
+}
+
+func TestSelectParentProportional(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(1))
+  const N = 20000
+  payouts := []int32{ 0, 10, 20, 30, 5 }
+  t := NewTribe(len(payouts), PASSERR, PACTMUT, PEXEERR, rnGen)
+  for i, po := range payouts {
+    t.agents[i].payout = po
+    t.totalPayouts += po
+  }
+
+  // the chance that an agent is selected is proportional to its payout
+  // -- the agent with no payout is never selected
+  selected := make([]int, len(payouts))
+  probs := make([]float64, len(payouts))
+  for i := 0; i < N; i++ {
+    a := t.SelectParent(rnGen)
+    for j := range t.agents {
+      if (a == t.agents[j]) { selected[j]++ }
+    }
+  }
+  for i, po := range payouts {
+    probs[i] = float64(po) / float64(t.totalPayouts)
+  }
+  AssertIntEqual(u, selected[0], 0)
+  testutil.AssertChiSquare(u, selected, probs)
+
+  // with no payouts every agent is equally likely to be selected
+  t.Reset()
+  for i := range selected {
+    selected[i] = 0
+    probs[i] = 1 / float64(len(payouts))
+  }
+  for i := 0; i < N; i++ {
+    a := t.SelectParent(rnGen)
+    for j := range t.agents {
+      if (a == t.agents[j]) { selected[j]++ }
+    }
+  }
+  testutil.AssertChiSquare(u, selected, probs)
+}
@@ -45,6 +45,16 @@ const (
  FNAME_F = "f"
  NOMP = false
  NOMP_F = "nmp"
+ GAME = GAME_DONATION // default two-player game
+ GAME_F = "game"
+ PAYOFF_R = 3 // default payoffs of the matrix game (prisoner's dilemma)
+ PAYOFF_R_F = "R"
+ PAYOFF_S = 0
+ PAYOFF_S_F = "S"
+ PAYOFF_T = 5
+ PAYOFF_T_F = "T"
+ PAYOFF_P = 1
+ PAYOFF_P_F = "P"
  ALLD = 0
  ALLC = 15
 )
@@ -84,18 +94,36 @@ func FloatAlmostEquals(f1 float64, f2 float64, epsilon float64) bool {
 }
 
 const lowFitMutRate = float64(0.002)
+// Return an error if the min and max tribal payouts can't be used to
+// calculate adaptive mutation rates
+func CheckTribalPayouts(minPO, maxPO int32) error {
+  if (minPO >= maxPO) {
+    return fmt.Errorf("minPO >= maxPO (minPO: %d, maxPO: %d)", minPO, maxPO)
+  }
+  return nil
+}
+
+// Same as TryCalcAdaptTribalMutRate but panics if the payout bounds are bad
+func CalcAdaptTribalMutRate(totalPayouts float64, minPO, maxPO int32) float64 {
+  mutRate, err := TryCalcAdaptTribalMutRate(totalPayouts, minPO, maxPO)
+  if (err != nil) {
+    panic(err.Error())
+  }
+  return mutRate
+}
+
 // Calculate an adaptive mutation rate for a tribe based on the provided total
 // payouts, min payout and max payout
-func CalcAdaptTribalMutRate(totalPayouts float64, minPO, maxPO int32) float64 {
+func TryCalcAdaptTribalMutRate(totalPayouts float64, minPO, maxPO int32) (float64, error) {
   // if the tribe earned the minimum payout then return low fit mutation rate
   if (FloatAlmostEquals(totalPayouts, float64(minPO), epsilon)) {
-    return lowFitMutRate
+    return lowFitMutRate, nil
   }
 
   // error checks
-  if (minPO >= maxPO) {
-    msg := fmt.Sprintf("minPO >= maxPO (minPO: %d, maxPO: %d)", minPO, maxPO)
-    panic(msg)
+  err := CheckTribalPayouts(minPO, maxPO)
+  if (err != nil) {
+    return 0, err
   }
 
   // calculate the percent of possible payout earned by the tribe
@@ -111,23 +139,23 @@ func CalcAdaptTribalMutRate(totalPayouts float64, minPO, maxPO int32) float64 {
   // errRate := float64(1) - earnedPO/maxEarnedPO
   // return PASSMUT + math.Pow(errRate, float64(4))*float64(0.002)
   // -- exponential mutation rate
-  return lowFitMutRate * math.Exp(math.Log(0.5)*5*earnedPO/maxEarnedPO)
+  return lowFitMutRate * math.Exp(math.Log(0.5)*5*earnedPO/maxEarnedPO), nil
   //return lowFitMutRate * math.Exp(-6.2146*5*earnedPO/maxEarnedPO)
 }
 
 // Calculate the minimum and maximum total payouts that can be earned by a tribe
-// in a single generation
+// in a single generation of the donation game
 func CalcMinMaxTribalPayouts(numAgents int, cost int32, benefit int32) (min int32, max int32) {
-  max = 0
-  min = 0
-  for i := 0; i < numAgents; i++ {
-    for j := i+1; j < numAgents; j++ {
-      // add (benefit - cost) + (2*cost)
-      max += (benefit + cost)
-      min += 2*cost
-    }
-  }
-  return min, max
+  return CalcMinMaxGamePayouts(numAgents, NewDonationGame(cost, benefit))
+}
+
+// Calculate the minimum and maximum total payouts that can be earned by a tribe
+// in a single generation of the specified game
+func CalcMinMaxGamePayouts(numAgents int, game Game) (min int32, max int32) {
+  // each pair of agents plays one round
+  pairs := int32(numAgents*(numAgents-1)/2)
+  roundMin, roundMax := MinMaxRoundPayouts(game)
+  return pairs*roundMin, pairs*roundMax
 }
 
 // Return a new random number generator.  This generator is NOT protected
@@ -146,7 +174,8 @@ func RandBool(source *rand.Rand) bool {
   }
 }
 
-// Generate a random integer in the range [0, max] from the provided source
+// Generate a random integer in the range [0, max) from the provided source
+// (0 if max is 0)
 func RandInt(source *rand.Rand, max int64) int64 {
   if (max == 0) { return 0 }
   return source.Int63n(max)
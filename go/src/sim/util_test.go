@@ -0,0 +1,16 @@
+package sim
+
+import "testing"
+
+func TestTryCalcAdaptTribalMutRate(u *testing.T) {
+  // the minimum payout gets the low fitness mutation rate
+  mutRate, err := TryCalcAdaptTribalMutRate(10, 10, 20)
+  AssertTrue(u, err == nil)
+  AssertFloat64Equal(u, mutRate, lowFitMutRate)
+
+  // bad payout bounds are reported instead of panicking
+  _, err = TryCalcAdaptTribalMutRate(15, 20, 20)
+  AssertTrue(u, err != nil)
+  AssertTrue(u, CheckTribalPayouts(20, 10) != nil)
+  AssertTrue(u, CheckTribalPayouts(10, 20) == nil)
+}
@@ -1,5 +1,6 @@
 package simbase
 
+import "math"
 import "math/rand"
 import "fmt"
 
@@ -17,6 +18,20 @@ func (am *ActionModule) Copy() *ActionModule {
   return NewActionModule(am.bits[0], am.bits[1], am.bits[2], am.bits[3], am.pexeerr)
 }
 
+// clone the action module using the specified probability of bit mutation
+func (am *ActionModule) CloneWithMutations(pactmut float64, rnGen *rand.Rand) *ActionModule {
+  // clone
+  clone := am.Copy()
+  // mutate
+  for j := 0; j < 4; j++ {
+    if (RandPercent(rnGen) < pactmut) {
+      // flip bit j
+      clone.bits[j] = !clone.bits[j]
+    }
+  }
+  return clone
+}
+
 func (self *ActionModule) ChooseAction(actionTaker Rep, other Rep, rnGen *rand.Rand) bool {
   var rval bool
   if (actionTaker == GOOD) {
@@ -51,6 +66,15 @@ func (self *ActionModule) SameBits(am *ActionModule) bool {
   return true
 }
 
+// return the bits of the module as an integer in the range [0, 15]
+func (self *ActionModule) GetBits() int {
+  rval := int(0)
+  for i := 0; i < 4; i++ {
+    rval += self.GetBit(i) * int(math.Pow(2,float64(3-i)))
+  }
+  return rval
+}
+
 func (self *ActionModule) GetBit(i int) int {
   if (self.bits[i]) {
     return 1
@@ -0,0 +1,262 @@
+package simbase
+
+import "bufio"
+import "encoding/json"
+import "flag"
+import "fmt"
+import "io"
+import "math"
+import "os"
+import "path"
+import "strconv"
+import "strings"
+
+// flag for the experiment config file
+const CONFIG_F = "config"
+
+// Parameter values read from a config file, keyed by the name of the flag
+// that sets the parameter.  Values are kept in the form accepted by the flag.
+type Config map[string]string
+
+// Read a config file.  Files ending in .toml are read as TOML and all other
+// files as JSON.  Either way the file holds a flat set of parameter names and
+// values (e.g. "beta": 1.2 or beta = 1.2).
+func ReadConfig(fname string) (Config, error) {
+  file, err := os.Open(fname)
+  if (err != nil) {
+    return nil, err
+  }
+  defer file.Close()
+  var cfg Config
+  if (strings.ToLower(path.Ext(fname)) == ".toml") {
+    cfg, err = ParseTOMLConfig(file)
+  } else {
+    cfg, err = ParseJSONConfig(file)
+  }
+  if (err != nil) {
+    return nil, fmt.Errorf("%s: %v", fname, err)
+  }
+  return cfg, nil
+}
+
+// Parse a config held in a JSON object.  The values must be numbers,
+// booleans or strings.
+func ParseJSONConfig(r io.Reader) (Config, error) {
+  var values map[string]interface{}
+  dec := json.NewDecoder(r)
+  dec.UseNumber()
+  err := dec.Decode(&values)
+  if (err != nil) {
+    return nil, err
+  }
+  cfg := make(Config)
+  for name, v := range values {
+    switch v := v.(type) {
+    case json.Number:
+      cfg[name] = v.String()
+    case bool:
+      cfg[name] = strconv.FormatBool(v)
+    case string:
+      cfg[name] = v
+    default:
+      return nil, fmt.Errorf("%s: value must be a number, boolean or string", name)
+    }
+  }
+  return cfg, nil
+}
+
+// Parse a config held in TOML.  Only the subset of TOML needed for
+// parameters is supported: comments and key = value lines whose values are
+// numbers, booleans or strings.  Tables and arrays are rejected.
+func ParseTOMLConfig(r io.Reader) (Config, error) {
+  cfg := make(Config)
+  scanner := bufio.NewScanner(r)
+  for lnum := 1; scanner.Scan(); lnum++ {
+    line := strings.TrimSpace(scanner.Text())
+    if ((line == "") || strings.HasPrefix(line, "#")) {
+      continue
+    }
+    if (strings.HasPrefix(line, "[")) {
+      return nil, fmt.Errorf("line %d: tables are not supported", lnum)
+    }
+    eq := strings.Index(line, "=")
+    if (eq < 0) {
+      return nil, fmt.Errorf("line %d: expected key = value", lnum)
+    }
+    name, err := parseTOMLKey(strings.TrimSpace(line[:eq]))
+    if (err != nil) {
+      return nil, fmt.Errorf("line %d: %v", lnum, err)
+    }
+    value, err := parseTOMLValue(strings.TrimSpace(line[eq+1:]))
+    if (err != nil) {
+      return nil, fmt.Errorf("line %d: %s: %v", lnum, name, err)
+    }
+    if _, ok := cfg[name]; ok {
+      return nil, fmt.Errorf("line %d: %s is defined more than once", lnum, name)
+    }
+    cfg[name] = value
+  }
+  return cfg, scanner.Err()
+}
+
+// parse a bare or quoted TOML key
+func parseTOMLKey(key string) (string, error) {
+  if (strings.HasPrefix(key, "\"")) {
+    return strconv.Unquote(key)
+  }
+  if (strings.HasPrefix(key, "'") && strings.HasSuffix(key, "'") && (len(key) > 1)) {
+    return key[1:len(key)-1], nil
+  }
+  if ((key == "") || strings.ContainsAny(key, " \t.")) {
+    return "", fmt.Errorf("invalid key: %q", key)
+  }
+  return key, nil
+}
+
+// parse a TOML value and any comment that follows it
+func parseTOMLValue(value string) (string, error) {
+  switch {
+  case strings.HasPrefix(value, "\""):
+    // basic string - find the closing quote that isn't escaped
+    for i := 1; i < len(value); i++ {
+      if (value[i] == '\\') {
+        i++
+      } else if (value[i] == '"') {
+        if (!isTOMLComment(value[i+1:])) { break }
+        return strconv.Unquote(value[:i+1])
+      }
+    }
+    return "", fmt.Errorf("invalid string: %s", value)
+  case strings.HasPrefix(value, "'"):
+    // literal string
+    end := strings.Index(value[1:], "'") + 1
+    if ((end < 1) || !isTOMLComment(value[end+1:])) {
+      return "", fmt.Errorf("invalid string: %s", value)
+    }
+    return value[1:end], nil
+  case strings.HasPrefix(value, "["), strings.HasPrefix(value, "{"):
+    return "", fmt.Errorf("arrays and tables are not supported")
+  }
+  // number or boolean
+  if i := strings.Index(value, "#"); (i >= 0) {
+    value = strings.TrimSpace(value[:i])
+  }
+  if ((value == "true") || (value == "false")) {
+    return value, nil
+  }
+  value = strings.ReplaceAll(value, "_", "")
+  if _, err := strconv.ParseFloat(value, 64); (err != nil) {
+    return "", fmt.Errorf("invalid value: %s", value)
+  }
+  return value, nil
+}
+
+// return true if the text after a value is empty or a comment
+func isTOMLComment(rest string) bool {
+  rest = strings.TrimSpace(rest)
+  return (rest == "") || strings.HasPrefix(rest, "#")
+}
+
+// Set the flags to the values in the config.  Flags that were set on the
+// command line keep their values so that they override the config file.  An
+// error is returned for a name that is not a flag or a value that the flag
+// does not accept.
+func ApplyConfig(flags *flag.FlagSet, cfg Config) error {
+  set := make(map[string]bool)
+  flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+  for name, value := range cfg {
+    if ((flags.Lookup(name) == nil) || (name == CONFIG_F)) {
+      return fmt.Errorf("unknown parameter: %s", name)
+    }
+    if (set[name]) {
+      continue
+    }
+    err := flags.Set(name, value)
+    if (err != nil) {
+      return fmt.Errorf("-%s: %v", name, err)
+    }
+  }
+  return nil
+}
+
+// Read the config file named by the config flag, if any, and apply it to the
+// flags.  Call this after the flags have been parsed.
+func LoadConfigFlag(flags *flag.FlagSet) error {
+  f := flags.Lookup(CONFIG_F)
+  if ((f == nil) || (f.Value.String() == "")) {
+    return nil
+  }
+  cfg, err := ReadConfig(f.Value.String())
+  if (err != nil) {
+    return fmt.Errorf("-%s: %v", CONFIG_F, err)
+  }
+  err = ApplyConfig(flags, cfg)
+  if (err != nil) {
+    return fmt.Errorf("-%s: %v", CONFIG_F, err)
+  }
+  return nil
+}
+
+// Write the current value of every flag as a JSON config that can be read
+// by ReadConfig to repeat the run.  The config flag itself is left out.
+func WriteConfig(w io.Writer, flags *flag.FlagSet) error {
+  values := FlagValues(flags)
+  var names []string
+  flags.VisitAll(func(f *flag.Flag) {
+    if (f.Name == CONFIG_F) { return }
+    names = append(names, f.Name)
+  })
+  fmt.Fprintln(w, "{")
+  for i, name := range names {
+    v, err := json.Marshal(values[name])
+    if (err != nil) {
+      return fmt.Errorf("%s: %v", name, err)
+    }
+    sep := ","
+    if (i == len(names)-1) { sep = "" }
+    fmt.Fprintf(w, "  %q: %s%s\n", name, v, sep)
+  }
+  _, err := fmt.Fprintln(w, "}")
+  return err
+}
+
+// Write the effective config of a run to the named file
+func WriteConfigFile(fname string, flags *flag.FlagSet) error {
+  file, err := os.Create(fname)
+  if (err != nil) {
+    return err
+  }
+  err = WriteConfig(file, flags)
+  cerr := file.Close()
+  if (err != nil) {
+    return err
+  }
+  return cerr
+}
+
+// Return the value of each flag, except the config flag, keyed by flag name.
+// Values have the type of the flag where it is known.  Infinite and NaN
+// values, which JSON can't hold, are given as the strings accepted by the
+// flag (e.g. "+Inf").
+func FlagValues(flags *flag.FlagSet) map[string]interface{} {
+  values := make(map[string]interface{})
+  flags.VisitAll(func(f *flag.Flag) {
+    if (f.Name == CONFIG_F) { return }
+    if g, ok := f.Value.(flag.Getter); ok {
+      values[f.Name] = g.Get()
+      if v, ok := values[f.Name].(float64); ok && (math.IsInf(v, 0) || math.IsNaN(v)) {
+        values[f.Name] = f.Value.String()
+      }
+    } else {
+      values[f.Name] = f.Value.String()
+    }
+  })
+  return values
+}
+
+// Return the name of the file that holds the effective config of a run
+// whose results are written to the named file (e.g. stats.csv ->
+// stats.config.json)
+func ConfigFileFor(fname string) string {
+  return strings.TrimSuffix(fname, path.Ext(fname)) + ".config.json"
+}
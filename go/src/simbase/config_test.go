@@ -0,0 +1,99 @@
+package simbase
+
+import "testing"
+import "testutil"
+import "flag"
+import "strings"
+import "bytes"
+import "math"
+
+// create a flag set like the one used by the run commands
+func newTestFlags() (*flag.FlagSet, *int, *float64, *bool, *string) {
+  flags := flag.NewFlagSet("test", flag.ContinueOnError)
+  gens := flags.Int(GENS_F, NUMGENS, "")
+  beta := flags.Float64(BETA_F, BETA, "")
+  mp := flags.Bool(USEMP_F, USEMP, "")
+  fname := flags.String(FNAME_F, FNAME, "")
+  flags.String(CONFIG_F, "", "")
+  return flags, gens, beta, mp, fname
+}
+
+func TestParseConfig(u *testing.T) {
+  jcfg, err := ParseJSONConfig(strings.NewReader(`{"g": 100, "beta": 0.5, "mp": true, "f": "x.csv"}`))
+  testutil.AssertTrue(u, err == nil)
+  tcfg, err := ParseTOMLConfig(strings.NewReader(`
+# experiment
+g = 1_00
+beta = 0.5   # conflict selection strength
+mp = true
+"f" = "x.csv" # output
+`))
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertIntEqual(u, len(jcfg), 4)
+  for name, v := range jcfg {
+    testutil.AssertTrue(u, tcfg[name] == v)
+  }
+
+  // unsupported values
+  _, err = ParseJSONConfig(strings.NewReader(`{"g": [1, 2]}`))
+  testutil.AssertFalse(u, err == nil)
+  _, err = ParseTOMLConfig(strings.NewReader("[sim]\ng = 1\n"))
+  testutil.AssertFalse(u, err == nil)
+  _, err = ParseTOMLConfig(strings.NewReader("g = 1\ng = 2\n"))
+  testutil.AssertFalse(u, err == nil)
+  _, err = ParseTOMLConfig(strings.NewReader("f = \"x.csv\n"))
+  testutil.AssertFalse(u, err == nil)
+}
+
+func TestApplyConfig(u *testing.T) {
+  flags, gens, beta, mp, fname := newTestFlags()
+  err := flags.Parse([]string{"-beta", "2"})
+  testutil.AssertTrue(u, err == nil)
+  err = ApplyConfig(flags, Config{ GENS_F: "100", BETA_F: "0.5", USEMP_F: "true" })
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertIntEqual(u, *gens, 100)
+  // flags override the config
+  testutil.AssertFloat64Equal(u, *beta, 2)
+  testutil.AssertTrue(u, *mp)
+  testutil.AssertTrue(u, *fname == FNAME)
+
+  // unknown parameters and invalid values
+  flags, _, _, _, _ = newTestFlags()
+  testutil.AssertFalse(u, ApplyConfig(flags, Config{ "nosuch": "1" }) == nil)
+  testutil.AssertFalse(u, ApplyConfig(flags, Config{ CONFIG_F: "x.json" }) == nil)
+  testutil.AssertFalse(u, ApplyConfig(flags, Config{ GENS_F: "0.5" }) == nil)
+}
+
+func TestWriteConfig(u *testing.T) {
+  flags, gens, beta, mp, fname := newTestFlags()
+  err := flags.Parse([]string{"-g", "7", "-beta", "0.25", "-mp", "-f", "y.csv", "-config", "c.json"})
+  testutil.AssertTrue(u, err == nil)
+  var buf bytes.Buffer
+  err = WriteConfig(&buf, flags)
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertFalse(u, strings.Contains(buf.String(), CONFIG_F))
+
+  // the written config repeats the run
+  cfg, err := ParseJSONConfig(&buf)
+  testutil.AssertTrue(u, err == nil)
+  flags, gens, beta, mp, fname = newTestFlags()
+  err = ApplyConfig(flags, cfg)
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertIntEqual(u, *gens, 7)
+  testutil.AssertFloat64Equal(u, *beta, 0.25)
+  testutil.AssertTrue(u, *mp)
+  testutil.AssertTrue(u, *fname == "y.csv")
+  testutil.AssertTrue(u, ConfigFileFor("out/stats.csv") == "out/stats.config.json")
+
+  // infinite selection strength is written in a form that can be read back
+  flags, _, beta, _, _ = newTestFlags()
+  testutil.AssertTrue(u, flags.Parse([]string{"-beta", "Inf"}) == nil)
+  buf.Reset()
+  testutil.AssertTrue(u, WriteConfig(&buf, flags) == nil)
+  testutil.AssertTrue(u, strings.Contains(buf.String(), "\"+Inf\""))
+  cfg, err = ParseJSONConfig(&buf)
+  testutil.AssertTrue(u, err == nil)
+  flags, _, beta, _, _ = newTestFlags()
+  testutil.AssertTrue(u, ApplyConfig(flags, cfg) == nil)
+  testutil.AssertTrue(u, math.IsInf(*beta, 1))
+}
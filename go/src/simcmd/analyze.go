@@ -0,0 +1,148 @@
+package simcmd
+
+import "encoding/csv"
+import "flag"
+import "fmt"
+import "io"
+import "os"
+import "path"
+import "strconv"
+import "strings"
+
+// flag for the time before statistics are averaged
+const BURNIN_TIME_F = "burnin"
+
+// The averages of the columns of a statistics file
+type FileStats struct {
+  Dir string        // output directory of the run
+  File string       // statistics file within the directory
+  Rows int          // number of rows averaged
+  Columns []string  // names of the columns
+  Means []float64   // mean of each column
+}
+
+// Return the averages of the columns of a CSV statistics file with a header
+// row.  The first column holds the time (e.g. the generation) and rows with a
+// time before burnin are left out.
+func AverageStats(r io.Reader, burnin float64) (columns []string, means []float64, rows int, err error) {
+  reader := csv.NewReader(r)
+  columns, err = reader.Read()
+  if (err != nil) {
+    return nil, nil, 0, err
+  }
+  sums := make([]float64, len(columns))
+  for {
+    record, err := reader.Read()
+    if (err == io.EOF) {
+      break
+    } else if (err != nil) {
+      return nil, nil, 0, err
+    }
+    values := make([]float64, len(record))
+    for i, field := range record {
+      values[i], err = strconv.ParseFloat(strings.TrimSpace(field), 64)
+      if (err != nil) {
+        return nil, nil, 0, fmt.Errorf("column %s: %v", columns[i], err)
+      }
+    }
+    if (values[0] < burnin) {
+      continue
+    }
+    for i, v := range values {
+      sums[i] += v
+    }
+    rows++
+  }
+  means = make([]float64, len(columns))
+  if (rows > 0) {
+    for i := range sums {
+      means[i] = sums[i] / float64(rows)
+    }
+  }
+  return columns, means, rows, nil
+}
+
+// Return the averages of the statistics files written by a run.  The runs of
+// a sweep are analysed in turn.
+func AnalyzeDir(dname string, burnin float64) ([]FileStats, error) {
+  m, err := ReadManifest(dname)
+  if (err != nil) {
+    return nil, err
+  }
+  var stats []FileStats
+  for _, run := range m.Runs {
+    rstats, err := AnalyzeDir(path.Join(dname, run), burnin)
+    if (err != nil) {
+      return nil, err
+    }
+    stats = append(stats, rstats...)
+  }
+  for _, fname := range m.Files {
+    if (path.Ext(fname) != ".csv") || (path.Base(fname) == "dhist.csv") {
+      // only time series are averaged
+      continue
+    }
+    file, err := os.Open(path.Join(dname, fname))
+    if (err != nil) {
+      return nil, err
+    }
+    columns, means, rows, err := AverageStats(file, burnin)
+    file.Close()
+    if (err != nil) {
+      return nil, fmt.Errorf("%s: %v", path.Join(dname, fname), err)
+    }
+    stats = append(stats, FileStats { Dir: dname, File: fname, Rows: rows, Columns: columns, Means: means })
+  }
+  return stats, nil
+}
+
+// Write the averages of statistics files as a JSON array
+func WriteFileStats(w io.Writer, stats []FileStats) {
+  fmt.Fprintln(w, "[")
+  for i, fs := range stats {
+    fmt.Fprintln(w, "{")
+    fmt.Fprintf(w, "  \"dir\":%q,\n", fs.Dir)
+    fmt.Fprintf(w, "  \"file\":%q,\n", fs.File)
+    fmt.Fprintf(w, "  \"rows\":%d,\n", fs.Rows)
+    fmt.Fprintf(w, "  \"means\":{")
+    for j, c := range fs.Columns {
+      if (j > 0) { fmt.Fprint(w, ",") }
+      fmt.Fprintf(w, "%q:%s", c, strconv.FormatFloat(fs.Means[j], 'g', 6, 64))
+    }
+    fmt.Fprintln(w, "}")
+    if (i+1 < len(stats)) {
+      fmt.Fprintln(w, "},")
+    } else {
+      fmt.Fprintln(w, "}")
+    }
+  }
+  fmt.Fprintln(w, "]")
+}
+
+// The "analyze" subcommand: average the statistics written by the runs in
+// the output directories given as arguments
+func Analyze(args []string) error {
+  flags := flag.NewFlagSet("analyze", flag.ContinueOnError)
+  burnin := flags.Float64(BURNIN_TIME_F, 0, "time (e.g. generation) before which statistics are left out")
+  flags.Usage = func() {
+    fmt.Fprintf(flags.Output(), "usage: simtool analyze [flags] <output directory>...\n")
+    flags.PrintDefaults()
+  }
+  err := flags.Parse(args)
+  if (err != nil) {
+    return &usageError { err: err }
+  }
+  if (flags.NArg() == 0) {
+    return InvalidParams(fmt.Errorf("no output directories to analyze"))
+  }
+  var stats []FileStats
+  for _, dname := range flags.Args() {
+    dstats, err := AnalyzeDir(dname, *burnin)
+    if (err != nil) {
+      return err
+    }
+    stats = append(stats, dstats...)
+  }
+  WriteFileStats(os.Stdout, stats)
+  return nil
+}
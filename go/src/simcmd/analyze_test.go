@@ -0,0 +1,24 @@
+package simcmd
+
+import "testing"
+import "testutil"
+import "strings"
+
+func TestAverageStats(u *testing.T) {
+  data := "gen,Pc,Pd\n0,1.0,0.0\n1,0.5,0.5\n2,0.25,0.75\n3,0.25,0.75\n"
+  columns, means, rows, err := AverageStats(strings.NewReader(data), 0)
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertIntEqual(u, rows, 4)
+  testutil.AssertTrue(u, strings.Join(columns, ",") == "gen,Pc,Pd")
+  testutil.AssertFloat64Equal(u, means[0], 1.5)
+  testutil.AssertFloat64Equal(u, means[1], 0.5)
+
+  // rows before the burn in are left out
+  _, means, rows, err = AverageStats(strings.NewReader(data), 2)
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertIntEqual(u, rows, 2)
+  testutil.AssertFloat64Equal(u, means[2], 0.75)
+
+  _, _, _, err = AverageStats(strings.NewReader("gen,Pc\n0,x\n"), 0)
+  testutil.AssertFalse(u, err == nil)
+}
@@ -0,0 +1,428 @@
+package simcmd
+
+import "bufio"
+import "encoding/csv"
+import "flag"
+import "fmt"
+import "io"
+import "math"
+import "os"
+import "path"
+import "sort"
+import "strconv"
+import "strings"
+
+// flags of the chart subcommand (the number of generations to plot is set
+// by -g, as it is for the run subcommands)
+const (
+ LOG_F = "log" // flag for the axes with a log scale
+ GAP_F = "gap" // flag for the gap between plotted generations
+)
+
+// labels of the units of time in the first column of a statistics file
+var timeLabels = map[string]string {
+  "gen": "generations",
+  "mcs": "Monte Carlo steps",
+  "sync": "synchronous updates",
+  "update": "updates",
+}
+
+// A CSV file with a header row
+type Table struct {
+  Columns []string
+  Records [][]string
+}
+
+// Read a CSV file with a header row
+func ReadTable(r io.Reader) (*Table, error) {
+  reader := csv.NewReader(r)
+  reader.TrimLeadingSpace = true
+  records, err := reader.ReadAll()
+  if (err != nil) {
+    return nil, err
+  }
+  if (len(records) == 0) {
+    return nil, fmt.Errorf("no header row")
+  }
+  return &Table { Columns: records[0], Records: records[1:] }, nil
+}
+
+// Return the index of a column, or -1 if the table doesn't have it
+func (self *Table) Index(name string) int {
+  for i, c := range self.Columns {
+    if (strings.TrimSpace(c) == name) {
+      return i
+    }
+  }
+  return -1
+}
+
+// Return true if the table has all the columns
+func (self *Table) Has(names ...string) bool {
+  for _, name := range names {
+    if (self.Index(name) < 0) {
+      return false
+    }
+  }
+  return true
+}
+
+// Return the values of a column
+func (self *Table) Strings(name string) ([]string, error) {
+  i := self.Index(name)
+  if (i < 0) {
+    return nil, fmt.Errorf("no column %s", name)
+  }
+  values := make([]string, len(self.Records))
+  for r, record := range self.Records {
+    values[r] = strings.TrimSpace(record[i])
+  }
+  return values, nil
+}
+
+// Return the values of a numeric column
+func (self *Table) Floats(name string) ([]float64, error) {
+  s, err := self.Strings(name)
+  if (err != nil) {
+    return nil, err
+  }
+  values := make([]float64, len(s))
+  for r, field := range s {
+    values[r], err = strconv.ParseFloat(field, 64)
+    if (err != nil) {
+      return nil, fmt.Errorf("column %s: %v", name, err)
+    }
+  }
+  return values, nil
+}
+
+// Return a table with the first gens rows (all rows if gens isn't positive),
+// keeping every gap-th row
+func (self *Table) Thin(gens int, gap int) *Table {
+  records := self.Records
+  if ((gens > 0) && (gens < len(records))) {
+    records = records[:gens]
+  }
+  if (gap > 1) {
+    var kept [][]string
+    for r := 0; r < len(records); r += gap {
+      kept = append(kept, records[r])
+    }
+    records = kept
+  }
+  return &Table { Columns: self.Columns, Records: records }
+}
+
+// The options of the chart subcommand
+type ChartOptions struct {
+  LogX bool
+  LogY bool
+  Gens int // number of generations plotted (0 for all)
+  Gap int  // plot every gap-th generation
+}
+
+// Parse the axes given to -log (x, y or xy)
+func (self *ChartOptions) SetLog(axes string) error {
+  self.LogX = strings.Contains(axes, "x")
+  self.LogY = strings.Contains(axes, "y")
+  if (strings.Trim(axes, "xy") != "") {
+    return fmt.Errorf("-%s: expected x, y or xy: %s", LOG_F, axes)
+  }
+  return nil
+}
+
+// A chart and the name it is written under
+type NamedChart struct {
+  Name string
+  Chart *LineChart
+}
+
+// return a chart of columns of a table plotted against the first column.
+// The series are named by the columns unless names are given.  Series that
+// are always zero are left out if skipZero is true.
+func timeSeriesChart(t *Table, opts ChartOptions, title string, ylabel string,
+                     columns []string, names []string, skipZero bool) (*LineChart, error) {
+  t = t.Thin(opts.Gens, opts.Gap)
+  tname := strings.TrimSpace(t.Columns[0])
+  x, err := t.Floats(tname)
+  if (err != nil) {
+    return nil, err
+  }
+  xlabel, ok := timeLabels[tname]
+  if (!ok) {
+    xlabel = tname
+  }
+  c := &LineChart { Title: title, XLabel: xlabel, YLabel: ylabel, LogX: opts.LogX, LogY: opts.LogY }
+  for i, column := range columns {
+    y, err := t.Floats(column)
+    if (err != nil) {
+      return nil, err
+    }
+    if (skipZero && allZero(y)) {
+      continue
+    }
+    name := column
+    if (names != nil) {
+      name = names[i]
+    }
+    c.Series = append(c.Series, Series { Name: name, X: x, Y: y })
+  }
+  return c, nil
+}
+
+// return true if all the values are zero
+func allZero(values []float64) bool {
+  for _, v := range values {
+    if (v != 0) {
+      return false
+    }
+  }
+  return true
+}
+
+// return the names of numbered columns, e.g. n0 to n7
+func numberedColumns(prefix string, n int, format string) []string {
+  names := make([]string, n)
+  for i := range names {
+    names[i] = prefix + fmt.Sprintf(format, i)
+  }
+  return names
+}
+
+// return the chart of the assessment bits of a runsim or pgg statistics file
+func assessChart(t *Table, opts ChartOptions) (*LineChart, error) {
+  return timeSeriesChart(t, opts, "assessment bits", "number of tribes",
+                         numberedColumns("n", 8, "%d"), numberedColumns("b", 8, "%d"), false)
+}
+
+// return the chart of payouts as a percentage of the maximum payout of a
+// runsim statistics file
+func payoutChart(t *Table, opts ChartOptions) (*LineChart, error) {
+  c, err := timeSeriesChart(t, opts, "payouts", "% of maximum payout", []string{ "po", "maxpo" }, nil, false)
+  if (err != nil) {
+    return nil, err
+  }
+  po, maxpo := c.Series[0].Y, c.Series[1].Y
+  percent := make([]float64, len(po))
+  for i := range po {
+    percent[i] = math.NaN()
+    if (maxpo[i] > 0) {
+      percent[i] = 100 * po[i] / maxpo[i]
+    }
+  }
+  c.Series = []Series { { Name: "%max", X: c.Series[0].X, Y: percent } }
+  c.YMin, c.YMax = 0, 100
+  return c, nil
+}
+
+// return the chart of the degrees of the vertices of a gpgg degree histogram
+// file, with a series for each strategy
+func degreeChart(t *Table, opts ChartOptions) (*LineChart, error) {
+  strats, err := t.Strings("S")
+  if (err != nil) {
+    return nil, err
+  }
+  degrees, err := t.Floats("K")
+  if (err != nil) {
+    return nil, err
+  }
+  counts := map[string]map[float64]int { "C": {}, "D": {} }
+  var kvalues []float64
+  seen := make(map[float64]bool)
+  for i, k := range degrees {
+    if (counts[strats[i]] == nil) {
+      return nil, fmt.Errorf("column S: unknown strategy %s", strats[i])
+    }
+    counts[strats[i]][k]++
+    if (!seen[k]) {
+      seen[k] = true
+      kvalues = append(kvalues, k)
+    }
+  }
+  sort.Float64s(kvalues)
+  c := &LineChart { Title: "degree histogram", XLabel: "degree", YLabel: "number of vertices",
+                    LogX: opts.LogX, LogY: opts.LogY }
+  for _, s := range []struct { strat, name string } { { "C", "cooperators" }, { "D", "defectors" } } {
+    y := make([]float64, len(kvalues))
+    for i, k := range kvalues {
+      y[i] = float64(counts[s.strat][k])
+    }
+    c.Series = append(c.Series, Series { Name: s.name, X: kvalues, Y: y, Markers: true })
+  }
+  return c, nil
+}
+
+// Return the charts of a statistics file.  The kind of file is identified by
+// its columns:
+//   runsim (ir run) stats: assessment bits, action modules and payouts
+//   pgg stats: assessment bits, action bits and decisions
+//   gpgg pstat.csv: cooperator fraction
+//   gpgg dhist.csv: degree histogram split by strategy
+func TableCharts(t *Table, opts ChartOptions) ([]NamedChart, error) {
+  var charts []NamedChart
+  add := func(name string, c *LineChart, err error) error {
+    if (err == nil) {
+      charts = append(charts, NamedChart { Name: name, Chart: c })
+    }
+    return err
+  }
+  var err error
+  switch {
+  case t.Has("a00", "po", "maxpo"):
+    c, cerr := assessChart(t, opts)
+    err = add("assess", c, cerr)
+    if (err == nil) {
+      // -- action modules that are never used are left out
+      c, cerr = timeSeriesChart(t, opts, "action modules", "number of agents",
+                                numberedColumns("a", 16, "%02d"), nil, true)
+      err = add("actions", c, cerr)
+    }
+    if (err == nil) {
+      c, cerr = payoutChart(t, opts)
+      err = add("payout", c, cerr)
+    }
+  case t.Has("c0", "fc"):
+    c, cerr := assessChart(t, opts)
+    err = add("assess", c, cerr)
+    if (err == nil) {
+      var columns []string
+      for _, prefix := range []string{ "c", "p", "r", "s" } {
+        columns = append(columns, numberedColumns(prefix, 4, "%d")...)
+      }
+      c, cerr = timeSeriesChart(t, opts, "action bits", "number of agents", columns, nil, false)
+      err = add("actions", c, cerr)
+    }
+    if (err == nil) {
+      c, cerr = timeSeriesChart(t, opts, "decisions", "fraction of decisions",
+                                []string{ "fc", "fp", "fr", "fs", "fg" },
+                                []string{ "contribute", "punish", "reward", "punish non-punishers", "good reputation" }, false)
+      if (cerr == nil) {
+        c.YMin, c.YMax = 0, 1
+      }
+      err = add("coop", c, cerr)
+    }
+  case t.Has("Pc", "Pd"):
+    c, cerr := timeSeriesChart(t, opts, "cooperators", "fraction of agents",
+                               []string{ "Pc", "Pd" }, []string{ "cooperators", "defectors" }, false)
+    if (cerr == nil) {
+      c.YMin, c.YMax = 0, 1
+    }
+    err = add("coop", c, cerr)
+  case t.Has("id", "S", "K"):
+    c, cerr := degreeChart(t, opts)
+    err = add("degree", c, cerr)
+  default:
+    err = fmt.Errorf("unknown statistics file with columns %s", strings.Join(t.Columns, ","))
+  }
+  if (err != nil) {
+    return nil, err
+  }
+  return charts, nil
+}
+
+// Write the charts of a statistics file as SVG files next to it, named after
+// the file and the chart (e.g. stats-assess.svg), and return their names
+func ChartFile(fname string, opts ChartOptions) ([]string, error) {
+  file, err := os.Open(fname)
+  if (err != nil) {
+    return nil, err
+  }
+  t, err := ReadTable(file)
+  file.Close()
+  if (err != nil) {
+    return nil, fmt.Errorf("%s: %v", fname, err)
+  }
+  charts, err := TableCharts(t, opts)
+  if (err != nil) {
+    return nil, fmt.Errorf("%s: %v", fname, err)
+  }
+  var written []string
+  prefix := strings.TrimSuffix(fname, path.Ext(fname))
+  for _, nc := range charts {
+    svgname := prefix + "-" + nc.Name + ".svg"
+    err = WriteFileAtomic(svgname, func(w *bufio.Writer) error { return nc.Chart.WriteSVG(w) })
+    if (err != nil) {
+      return written, err
+    }
+    written = append(written, svgname)
+  }
+  return written, nil
+}
+
+// Write the charts of the statistics files of a run, and of the runs of a
+// sweep, and return their names
+func ChartDir(dname string, opts ChartOptions) ([]string, error) {
+  m, err := ReadManifest(dname)
+  if (err != nil) {
+    return nil, err
+  }
+  var written []string
+  for _, run := range m.Runs {
+    rwritten, err := ChartDir(path.Join(dname, run), opts)
+    written = append(written, rwritten...)
+    if (err != nil) {
+      return written, err
+    }
+  }
+  for _, fname := range m.Files {
+    if (path.Ext(fname) != ".csv") {
+      continue
+    }
+    fwritten, err := ChartFile(path.Join(dname, fname), opts)
+    written = append(written, fwritten...)
+    if (err != nil) {
+      return written, err
+    }
+  }
+  return written, nil
+}
+
+// The "chart" subcommand: write SVG charts of the statistics files in the
+// output directories, or of the statistics files, given as arguments.  The
+// names of the charts are written to stdout.
+func Chart(args []string) error {
+  flags := flag.NewFlagSet("chart", flag.ContinueOnError)
+  logAxes := flags.String(LOG_F, "", "axes with a log scale: x, y or xy")
+  gens := flags.Int(GENS_F, 0, "number of generations to plot (0 = all)")
+  gap := flags.Int(GAP_F, 1, "plot every gap-th generation")
+  flags.Usage = func() {
+    fmt.Fprintf(flags.Output(), "usage: simtool chart [flags] <output directory or CSV file>...\n")
+    flags.PrintDefaults()
+  }
+  err := flags.Parse(args)
+  if (err != nil) {
+    return &usageError { err: err }
+  }
+  opts := ChartOptions { Gens: *gens, Gap: *gap }
+  err = opts.SetLog(*logAxes)
+  if (err != nil) {
+    return InvalidParams(err)
+  }
+  switch {
+  case (flags.NArg() == 0):
+    return InvalidParams(fmt.Errorf("no output directories or files to chart"))
+  case (opts.Gens < 0):
+    return InvalidParams(fmt.Errorf("-%s: must not be negative", GENS_F))
+  case (opts.Gap < 1):
+    return InvalidParams(fmt.Errorf("-%s: must be at least 1", GAP_F))
+  }
+  for _, name := range flags.Args() {
+    info, err := os.Stat(name)
+    if (err != nil) {
+      return err
+    }
+    var written []string
+    if (info.IsDir()) {
+      written, err = ChartDir(name, opts)
+    } else {
+      written, err = ChartFile(name, opts)
+    }
+    for _, svgname := range written {
+      fmt.Println(svgname)
+    }
+    if (err != nil) {
+      return err
+    }
+  }
+  return nil
+}
@@ -0,0 +1,164 @@
+package simcmd
+
+import "encoding/xml"
+import "io"
+import "math"
+import "os"
+import "path"
+import "strings"
+import "testing"
+import "testutil"
+
+func TestChartAxis(u *testing.T) {
+  a := newChartAxis(3, 97, false)
+  testutil.AssertFloat64Equal(u, a.min, 0)
+  testutil.AssertFloat64Equal(u, a.max, 100)
+  testutil.AssertIntEqual(u, len(a.ticks), 6)
+  testutil.AssertFloat64Equal(u, a.ticks[3], 60)
+  x, ok := a.scale(25)
+  testutil.AssertTrue(u, ok)
+  testutil.AssertFloat64Equal(u, x, 0.25)
+
+  a = newChartAxis(0.1, 0.3, false)
+  testutil.AssertFloat64Equal(u, a.ticks[len(a.ticks)-1], 0.3)
+
+  // a constant non-negative series
+  a = newChartAxis(0, 0, false)
+  testutil.AssertFloat64Equal(u, a.min, 0)
+  testutil.AssertTrue(u, a.max > 0)
+
+  a = newChartAxis(3, 250, true)
+  testutil.AssertFloat64Equal(u, a.min, 0)
+  testutil.AssertFloat64Equal(u, a.max, 3)
+  testutil.AssertIntEqual(u, len(a.ticks), 4)
+  testutil.AssertFloat64Equal(u, a.ticks[2], 100)
+  x, ok = a.scale(10)
+  testutil.AssertTrue(u, ok)
+  testutil.AssertFloat64Equal(u, x, 1.0/3)
+  _, ok = a.scale(0)
+  testutil.AssertFalse(u, ok)
+  _, ok = a.scale(math.NaN())
+  testutil.AssertFalse(u, ok)
+}
+
+func TestChartOptions(u *testing.T) {
+  var opts ChartOptions
+  testutil.AssertTrue(u, opts.SetLog("xy") == nil)
+  testutil.AssertTrue(u, opts.LogX && opts.LogY)
+  testutil.AssertTrue(u, opts.SetLog("y") == nil)
+  testutil.AssertTrue(u, !opts.LogX && opts.LogY)
+  testutil.AssertTrue(u, opts.SetLog("") == nil)
+  testutil.AssertTrue(u, !opts.LogX && !opts.LogY)
+  testutil.AssertFalse(u, opts.SetLog("z") == nil)
+}
+
+// return a runsim statistics file with the action module counts in a00 and
+// a05
+func irStatsData(gens int) string {
+  var b strings.Builder
+  WriteIRHeader(&b)
+  for g := 0; g < gens; g++ {
+    WriteIRStats(&b, g, 4, 8, [8]int{ 4, 3, 2, 1, 0, 1, 2, 3 }, map[int]int{ 0: 20, 5: 12 },
+                 int32(100 + g), 50, 200)
+  }
+  return b.String()
+}
+
+func TestTableCharts(u *testing.T) {
+  t, err := ReadTable(strings.NewReader(irStatsData(10)))
+  testutil.AssertTrue(u, err == nil)
+  charts, err := TableCharts(t, ChartOptions { Gens: 6, Gap: 2 })
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertIntEqual(u, len(charts), 3)
+  testutil.AssertTrue(u, charts[0].Name == "assess")
+  testutil.AssertIntEqual(u, len(charts[0].Chart.Series), 8)
+  testutil.AssertTrue(u, charts[0].Chart.XLabel == "generations")
+  // -- unused action modules are left out
+  testutil.AssertTrue(u, charts[1].Name == "actions")
+  testutil.AssertIntEqual(u, len(charts[1].Chart.Series), 2)
+  testutil.AssertTrue(u, charts[1].Chart.Series[1].Name == "a05")
+  // -- generations 0, 2 and 4 are plotted
+  po := charts[2].Chart.Series[0]
+  testutil.AssertIntEqual(u, len(po.Y), 3)
+  testutil.AssertFloat64Equal(u, po.X[2], 4)
+  testutil.AssertFloat64Equal(u, po.Y[2], 52)
+
+  t, err = ReadTable(strings.NewReader("update,Pc,Pd\n0,0.500,0.500\n1,0.600,0.400\n"))
+  testutil.AssertTrue(u, err == nil)
+  charts, err = TableCharts(t, ChartOptions{})
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertIntEqual(u, len(charts), 1)
+  testutil.AssertTrue(u, charts[0].Name == "coop")
+  testutil.AssertTrue(u, charts[0].Chart.XLabel == "updates")
+  testutil.AssertFloat64Equal(u, charts[0].Chart.Series[0].Y[1], 0.6)
+
+  t, err = ReadTable(strings.NewReader("id,S,K\n0,C,2\n1,D,2\n2,C,2\n3,D,5\n"))
+  testutil.AssertTrue(u, err == nil)
+  charts, err = TableCharts(t, ChartOptions{})
+  testutil.AssertTrue(u, err == nil)
+  c := charts[0].Chart
+  testutil.AssertTrue(u, charts[0].Name == "degree")
+  testutil.AssertIntEqual(u, len(c.Series[0].X), 2)
+  testutil.AssertFloat64Equal(u, c.Series[0].Y[0], 2) // cooperators with degree 2
+  testutil.AssertFloat64Equal(u, c.Series[0].Y[1], 0)
+  testutil.AssertFloat64Equal(u, c.Series[1].Y[1], 1) // defectors with degree 5
+
+  t, err = ReadTable(strings.NewReader("id,S,K\n0,X,2\n"))
+  testutil.AssertTrue(u, err == nil)
+  _, err = TableCharts(t, ChartOptions{})
+  testutil.AssertFalse(u, err == nil)
+  t, err = ReadTable(strings.NewReader("a,b\n1,2\n"))
+  testutil.AssertTrue(u, err == nil)
+  _, err = TableCharts(t, ChartOptions{})
+  testutil.AssertFalse(u, err == nil)
+}
+
+// return the number of elements with a name in an XML document, or -1 if
+// the document isn't well formed
+func countElements(r io.Reader, name string) int {
+  decoder := xml.NewDecoder(r)
+  n := 0
+  for {
+    token, err := decoder.Token()
+    if (err == io.EOF) {
+      return n
+    } else if (err != nil) {
+      return -1
+    }
+    if start, ok := token.(xml.StartElement); ok && (start.Name.Local == name) {
+      n++
+    }
+  }
+}
+
+func TestChartFile(u *testing.T) {
+  dname := u.TempDir()
+  fname := path.Join(dname, "stats.csv")
+  err := os.WriteFile(fname, []byte(irStatsData(20)), 0644)
+  testutil.AssertTrue(u, err == nil)
+  written, err := ChartFile(fname, ChartOptions { LogY: true })
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertIntEqual(u, len(written), 3)
+  testutil.AssertTrue(u, written[0] == path.Join(dname, "stats-assess.svg"))
+
+  file, err := os.Open(written[0])
+  testutil.AssertTrue(u, err == nil)
+  defer file.Close()
+  // one path for each series, with the assessment bit that is always zero
+  // left out on the log scale
+  testutil.AssertIntEqual(u, countElements(file, "path"), 7)
+}
+
+func TestChartCommand(u *testing.T) {
+  dname := u.TempDir()
+  err := runQuietly([]string{ "gpgg", "run", "-s", "2", "-g", "5", "-a", "20", "-seed", "1", "-o", dname })
+  testutil.AssertTrue(u, err == nil)
+  err = runQuietly([]string{ "chart", "-log", "x", dname })
+  testutil.AssertTrue(u, err == nil)
+  for _, fname := range []string{ "sim0/pstat-coop.svg", "sim1/dhist-degree.svg" } {
+    _, err = os.Stat(path.Join(dname, fname))
+    testutil.AssertTrue(u, err == nil)
+  }
+  testutil.AssertFalse(u, runQuietly([]string{ "chart", "-log", "q", dname }) == nil)
+  testutil.AssertFalse(u, runQuietly([]string{ "chart" }) == nil)
+}
@@ -0,0 +1,126 @@
+package simcmd
+
+import "errors"
+import "flag"
+import "fmt"
+import "os"
+import "strings"
+
+// a subcommand of the simulation tool
+type command struct {
+  name string                   // words that select the command, e.g. "ir run"
+  run func(args []string) error // run the command with the remaining arguments
+  isRun bool                    // true if the command runs a simulation (and can be swept)
+  summary string
+}
+
+// the subcommands in the order they are listed by the usage message
+var commands []command
+
+func init() {
+  commands = []command {
+    { "ir run", IRRun, true, "run an indirect reciprocity simulation with tribes" },
+    { "gpgg run", GPGGRun, true, "run an experiment of public goods games played on graphs" },
+    { "pgg run", PGGRun, true, "run a public goods game simulation with tribes" },
+    { "sweep", Sweep, false, "run a command for every combination of parameter values" },
+    { "analyze", Analyze, false, "average the statistics written by runs" },
+    { "chart", Chart, false, "write SVG charts of the statistics written by runs" },
+    { "selftest", SelfTest, false, "run each command briefly and check its output" },
+  }
+}
+
+// An error caused by invalid parameters
+type paramError struct {
+  err error
+}
+
+// Return an error that reports invalid parameters
+func InvalidParams(err error) error {
+  return &paramError { err: err }
+}
+
+func (self *paramError) Error() string {
+  return "invalid parameters: " + self.err.Error()
+}
+
+func (self *paramError) Unwrap() error {
+  return self.err
+}
+
+// An error in the command line that has already been reported by the flag
+// package
+type usageError struct {
+  err error
+}
+
+func (self *usageError) Error() string {
+  return self.err.Error()
+}
+
+func (self *usageError) Unwrap() error {
+  return self.err
+}
+
+// find the command selected by the first arguments and return it with the
+// remaining arguments
+func findCommand(args []string) (command, []string, bool) {
+  for _, c := range commands {
+    words := strings.Fields(c.name)
+    if ((len(args) >= len(words)) && (strings.Join(args[:len(words)], " ") == c.name)) {
+      return c, args[len(words):], true
+    }
+  }
+  return command{}, nil, false
+}
+
+// Run the command selected by the arguments
+func Run(args []string) error {
+  c, cargs, ok := findCommand(args)
+  if (!ok) {
+    return &usageError { err: fmt.Errorf("unknown command: %s", strings.Join(args, " ")) }
+  }
+  return c.run(cargs)
+}
+
+// Write the usage message of the tool
+func Usage() {
+  fmt.Fprintf(os.Stderr, "usage: simtool <command> [flags]\n\ncommands:\n")
+  for _, c := range commands {
+    fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.summary)
+  }
+  fmt.Fprintf(os.Stderr, "\nUse simtool <command> -h for the flags of a command.\n")
+}
+
+// Run the tool with the command line arguments (without the program name)
+// and return the exit status
+func Main(args []string) int {
+  if ((len(args) == 0) || (args[0] == "-h") || (args[0] == "help")) {
+    Usage()
+    if (len(args) == 0) { return 2 }
+    return 0
+  }
+  if _, _, ok := findCommand(args); !ok {
+    fmt.Fprintf(os.Stderr, "ERROR: unknown command: %s\n\n", strings.Join(args, " "))
+    Usage()
+    return 2
+  }
+  err := Run(args)
+  var perr *paramError
+  var uerr *usageError
+  switch {
+  case (err == nil):
+    return 0
+  case errors.Is(err, flag.ErrHelp):
+    return 0
+  case errors.As(err, &uerr):
+    return 2
+  case errors.As(err, &perr):
+    fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+    return 2
+  case errors.Is(err, ErrInterrupted):
+    fmt.Fprintf(os.Stderr, "%v: the results of the completed generations were kept\n", err)
+    return EXIT_INTERRUPTED
+  }
+  fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+  return 1
+}
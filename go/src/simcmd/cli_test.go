@@ -0,0 +1,41 @@
+package simcmd
+
+import "testing"
+import "testutil"
+
+func TestFindCommand(u *testing.T) {
+  c, args, ok := findCommand([]string{ "gpgg", "run", "-s", "2" })
+  testutil.AssertTrue(u, ok)
+  testutil.AssertTrue(u, c.name == "gpgg run")
+  testutil.AssertIntEqual(u, len(args), 2)
+  c, args, ok = findCommand([]string{ "selftest" })
+  testutil.AssertTrue(u, ok && !c.isRun)
+  testutil.AssertIntEqual(u, len(args), 0)
+  _, _, ok = findCommand([]string{ "gpgg" })
+  testutil.AssertFalse(u, ok)
+}
+
+func TestMainExitStatus(u *testing.T) {
+  dname := u.TempDir()
+  testutil.AssertIntEqual(u, Main([]string{ "nosuch", "run" }), 2)
+  testutil.AssertIntEqual(u, Main([]string{ "ir", "run", "-a", "1", "-o", dname }), 2)
+  testutil.AssertIntEqual(u, Main([]string{ "pgg", "run", "-nosuch" }), 2)
+  testutil.AssertIntEqual(u, Main([]string{ "analyze", dname }), 1)
+}
+
+func TestRunCommands(u *testing.T) {
+  for _, args := range [][]string {
+    { "ir", "run", "-g", "3", "-t", "2", "-a", "4" },
+    { "pgg", "run", "-g", "3", "-t", "2", "-a", "5" },
+    { "gpgg", "run", "-ng", "1", "-spg", "2", "-g", "3", "-a", "20" },
+    { "gpgg", "run", "-s", "1", "-g", "3", "-a", "20", "-w", "3" },
+    { "gpgg", "run", "-s", "1", "-g", "3", "-a", "20", "-gtype", "13" },
+  } {
+    dname := u.TempDir()
+    err := runQuietly(withOutDir(args, dname))
+    testutil.AssertTrue(u, err == nil)
+    testutil.AssertTrue(u, CheckRun(dname) == nil)
+    // the output directory isn't overwritten without -overwrite
+    testutil.AssertFalse(u, runQuietly(withOutDir(args, dname)) == nil)
+  }
+}
@@ -0,0 +1,199 @@
+package simcmd
+
+import "bufio"
+import "encoding/json"
+import "errors"
+import "flag"
+import "fmt"
+import "io"
+import "os"
+import "path"
+import "simbase"
+import "strings"
+import "time"
+
+// flags shared by the run subcommands
+const (
+ OUTDIR_F = "o"            // flag for the output directory
+ OVERWRITE_F = "overwrite" // flag to overwrite data in an existing output directory
+ SEED_F = "seed"           // flag for the seed (0 = seed from the current time)
+)
+
+// files written to every output directory
+const (
+ MANIFEST_FNAME = "manifest.json" // what was run and the files it wrote
+ CONFIG_FNAME = "config.json"     // effective parameters, readable with -config
+)
+
+// status of a run recorded in its manifest
+const (
+ STATUS_RUNNING = "running"
+ STATUS_COMPLETE = "complete"
+ STATUS_FAILED = "failed"
+ STATUS_INTERRUPTED = "interrupted"
+)
+
+// The flags shared by the run subcommands
+type RunFlags struct {
+  OutDir *string
+  Overwrite *bool
+  Seed *int64
+  HTTP *string
+}
+
+// Create the flag set for a subcommand with the flags shared by the run
+// subcommands.  Output is written to outDir unless -o is used.
+func NewRunFlagSet(name string, outDir string) (*flag.FlagSet, *RunFlags) {
+  flags := flag.NewFlagSet(name, flag.ContinueOnError)
+  rf := &RunFlags {
+    OutDir: flags.String(OUTDIR_F, outDir, "directory to write results"),
+    Overwrite: flags.Bool(OVERWRITE_F, false, "overwrite data if the output directory isn't empty"),
+    Seed: flags.Int64(SEED_F, 0, "seed for the run (0 = use time)"),
+    HTTP: flags.String(HTTP_F, "", "address (e.g. :8080) to serve the progress of the run as JSON at /status and Server-Sent Events at /events"),
+  }
+  flags.String(simbase.CONFIG_F, "", "JSON or TOML file of parameter values keyed by flag name (flags override the file)")
+  return flags, rf
+}
+
+// Parse the arguments of a subcommand and apply the config file, if any.
+// Positional arguments are not accepted.
+func ParseFlags(flags *flag.FlagSet, args []string) error {
+  err := flags.Parse(args)
+  if (err != nil) {
+    // the flag package has reported the error
+    return &usageError { err: err }
+  }
+  if (flags.NArg() > 0) {
+    return InvalidParams(fmt.Errorf("unexpected argument: %s", flags.Arg(0)))
+  }
+  err = simbase.LoadConfigFlag(flags)
+  if (err != nil) {
+    return InvalidParams(err)
+  }
+  return nil
+}
+
+// Create the output directory.  Returns an error if the directory holds
+// data and overwrite is false.
+func PrepareOutputDir(dname string, overwrite bool) error {
+  entries, err := os.ReadDir(dname)
+  if ((err == nil) && (len(entries) > 0) && !overwrite) {
+    return fmt.Errorf("directory exists: %s (use -%s to overwrite)", dname, OVERWRITE_F)
+  }
+  return os.MkdirAll(dname, os.ModePerm)
+}
+
+// A record of a run that is kept in its output directory
+type Manifest struct {
+  Command string `json:"command"`
+  Args []string `json:"args"`
+  Status string `json:"status"`
+  Start string `json:"start"`
+  End string `json:"end,omitempty"`
+  Runtime string `json:"runtime,omitempty"`
+  Seed int64 `json:"seed"`
+  Config string `json:"config"`
+  Files []string `json:"files"`
+  Runs []string `json:"runs,omitempty"` // output directories of the runs of a sweep
+  Progress string `json:"progress,omitempty"` // how much of the run was completed
+  Error string `json:"error,omitempty"`
+  fname string // path of the manifest
+  dname string // directory of the files of the run
+  start time.Time
+}
+
+// Create the manifest of a run that has started.  The manifest is written
+// to the file fname and the files of the run are in the same directory.
+func NewManifest(command string, args []string, fname string, seed int64) *Manifest {
+  start := time.Now()
+  return &Manifest { Command: command, Args: args, Status: STATUS_RUNNING,
+                     Start: start.Format(time.RFC3339), Seed: seed, Files: []string{},
+                     fname: fname, dname: path.Dir(fname), start: start }
+}
+
+// Return the name of the manifest of a run whose results are written to the
+// named file (e.g. stats.csv -> stats.manifest.json)
+func ManifestFileFor(fname string) string {
+  return strings.TrimSuffix(fname, path.Ext(fname)) + ".manifest.json"
+}
+
+// Start a run: create the output directory, record the effective
+// parameters and write a manifest with status "running".  A seed of zero is
+// replaced by one taken from the current time before the parameters are
+// recorded so that the run can be repeated.
+func StartRun(command string, args []string, flags *flag.FlagSet, rf *RunFlags) (*Manifest, error) {
+  err := PrepareOutputDir(*rf.OutDir, *rf.Overwrite)
+  if (err != nil) {
+    return nil, err
+  }
+  if (*rf.Seed == 0) {
+    *rf.Seed = time.Now().UnixNano()
+  }
+  err = simbase.WriteConfigFile(path.Join(*rf.OutDir, CONFIG_FNAME), flags)
+  if (err != nil) {
+    return nil, err
+  }
+  m := NewManifest(command, args, path.Join(*rf.OutDir, MANIFEST_FNAME), *rf.Seed)
+  m.Config = CONFIG_FNAME
+  return m, m.Write()
+}
+
+// Return the path of a file in the output directory of the run and record
+// it in the manifest
+func (self *Manifest) AddFile(fname string) string {
+  self.Files = append(self.Files, fname)
+  return path.Join(self.dname, fname)
+}
+
+// Record the end of the run.  The status is "complete" if err is nil,
+// "interrupted" if err is ErrInterrupted and "failed" otherwise.  The error
+// of the run is returned unless writing the manifest fails.
+func (self *Manifest) Finish(err error) error {
+  end := time.Now()
+  self.End = end.Format(time.RFC3339)
+  self.Runtime = end.Sub(self.start).String()
+  self.Status = STATUS_COMPLETE
+  if (errors.Is(err, ErrInterrupted)) {
+    self.Status = STATUS_INTERRUPTED
+  } else if (err != nil) {
+    self.Status = STATUS_FAILED
+    self.Error = err.Error()
+  }
+  werr := self.Write()
+  if (err != nil) {
+    return err
+  }
+  return werr
+}
+
+// Write the manifest.  The file is replaced atomically so that it is never
+// seen partly written.
+func (self *Manifest) Write() error {
+  return WriteFileAtomic(self.fname, func(w *bufio.Writer) error {
+    return self.WriteJSON(w)
+  })
+}
+
+// Write the manifest as JSON
+func (self *Manifest) WriteJSON(w io.Writer) error {
+  b, err := json.MarshalIndent(self, "", "  ")
+  if (err != nil) {
+    return err
+  }
+  _, err = fmt.Fprintf(w, "%s\n", b)
+  return err
+}
+
+// Read the manifest in an output directory
+func ReadManifest(dname string) (*Manifest, error) {
+  b, err := os.ReadFile(path.Join(dname, MANIFEST_FNAME))
+  if (err != nil) {
+    return nil, err
+  }
+  m := &Manifest { fname: path.Join(dname, MANIFEST_FNAME), dname: dname }
+  err = json.Unmarshal(b, m)
+  if (err != nil) {
+    return nil, fmt.Errorf("%s: %v", path.Join(dname, MANIFEST_FNAME), err)
+  }
+  return m, nil
+}
@@ -0,0 +1,58 @@
+package simcmd
+
+import "testing"
+import "testutil"
+import "os"
+import "path"
+
+func TestPrepareOutputDir(u *testing.T) {
+  dname := path.Join(u.TempDir(), "out")
+  testutil.AssertTrue(u, PrepareOutputDir(dname, false) == nil)
+  // an empty directory can be reused
+  testutil.AssertTrue(u, PrepareOutputDir(dname, false) == nil)
+  err := os.WriteFile(path.Join(dname, "stats.csv"), []byte("gen\n"), 0666)
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertFalse(u, PrepareOutputDir(dname, false) == nil)
+  testutil.AssertTrue(u, PrepareOutputDir(dname, true) == nil)
+}
+
+func TestManifest(u *testing.T) {
+  dname := path.Join(u.TempDir(), "out")
+  flags, rf := NewRunFlagSet("test run", dname)
+  gens := flags.Int(GENS_F, GENS, "")
+  testutil.AssertTrue(u, ParseFlags(flags, []string{ "-g", "5" }) == nil)
+  testutil.AssertIntEqual(u, *gens, 5)
+
+  m, err := StartRun("test run", []string{ "-g", "5" }, flags, rf)
+  testutil.AssertTrue(u, err == nil)
+  // the seed is chosen before the config is written
+  testutil.AssertFalse(u, *rf.Seed == 0)
+  fname := m.AddFile("stats.csv")
+  testutil.AssertTrue(u, fname == path.Join(dname, "stats.csv"))
+
+  r, err := ReadManifest(dname)
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertTrue(u, r.Status == STATUS_RUNNING)
+  testutil.AssertTrue(u, r.Seed == *rf.Seed)
+
+  testutil.AssertTrue(u, m.Finish(nil) == nil)
+  r, err = ReadManifest(dname)
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertTrue(u, r.Status == STATUS_COMPLETE)
+  testutil.AssertIntEqual(u, len(r.Files), 1)
+  testutil.AssertTrue(u, r.Files[0] == "stats.csv")
+
+  // the run is repeated from the recorded config
+  flags, rf = NewRunFlagSet("test run", dname)
+  gens = flags.Int(GENS_F, GENS, "")
+  err = ParseFlags(flags, []string{ "-config", path.Join(dname, CONFIG_FNAME) })
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertIntEqual(u, *gens, 5)
+  testutil.AssertTrue(u, *rf.Seed == r.Seed)
+
+  // unexpected arguments are invalid parameters
+  flags, _ = NewRunFlagSet("test run", dname)
+  err = ParseFlags(flags, []string{ "extra" })
+  _, ok := err.(*paramError)
+  testutil.AssertTrue(u, ok)
+}
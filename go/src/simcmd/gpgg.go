@@ -0,0 +1,520 @@
+package simcmd
+
+import "bufio"
+import "bytes"
+import "flag"
+import "fmt"
+import "goraph"
+import "io"
+import "math/rand"
+import "os"
+import "path"
+import "runtime"
+import "simgpgg"
+import "time"
+
+// default parameter values of the graph public goods game simulations
+const (
+ SIMS = 10      // default number of simulations to conduct
+ SIMS_F = "s"   // flag for SIMS parameter
+ NGRAPHS = 0    // default number of shared graphs (0 = a new graph for each simulation)
+ NGRAPHS_F = "ng" // flag for NGRAPHS parameter
+ SPG = 1        // default number of simulations per shared graph
+ SPG_F = "spg"  // flag for SPG parameter
+ GENS = 10      // default number of generations per simulation
+ GENS_F = "g"   // flag for GENS parameter
+ AGENTS = 10    // default number of agents per tribe
+ AGENTS_F = "a" // flag for AGENTS parameter
+ Z = 4          // default average degree of the graph (z)
+ Z_F = "z"      // flag for Z parameter
+ GTYPE = 0      // default graph type (0 = regular ring)
+ GTYPE_F = "gtype" // flag for GTYPE parameter
+ GIMPL = 0      // default graph implementation (0 = adjacency list)
+ GIMPL_F = "gimpl" // flag for GIMPL parameter
+ MULT = 3       // default contribution multiplier (r)
+ MULT_F = "r"   // flag for MULT parameter
+ COST = 1       // default contribution made by cooperators
+ COST_F = "c"   // flag for COST parameter
+ BETAE = 10     // selection strength for strategy updates
+ BETAE_F = "betae"   // flag for BETAE parameter
+ BETAA = 10     // selection strength for structure updates
+ BETAA_F = "betaa"   // flag for BETAA parameter
+ W = 0          // ratio of time scales for strategy and structure updates
+ W_F = "w"      // flag for W parameter
+ STEP = 0       // default time step (0 = async update, 1 = MC step, 2 = sync)
+ STEP_F = "step" // flag for STEP parameter
+ SRULE = 0      // default structure update rule (0 = rewire to neighbor of y)
+ SRULE_F = "srule" // flag for SRULE parameter
+ URULE = 0      // default strategy update rule (0 = pairwise Fermi)
+ URULE_F = "urule" // flag for URULE parameter
+ CSCHEME = 0    // default contribution scheme (0 = cost per game)
+ CSCHEME_F = "cs" // flag for CSCHEME parameter
+ REP = false    // default to fixed cooperator and defector strategies
+ REP_F = "rep"  // flag for REP parameter
+ FINE = 3       // fine paid by a non-contributor to each punisher
+ FINE_F = "fine" // flag for FINE parameter
+ PCOST = 1      // cost paid by a punisher for each non-contributor punished
+ PCOST_F = "pcost" // flag for PCOST parameter
+ PEXEERR = 0.001 // probability of execution error for action modules
+ PEXEERR_F = "pexeerr" // flag for PEXEERR parameter
+ MU = 0         // probability that an update is a random exploration
+ MU_F = "mu"    // flag for MU parameter
+ BURNIN = -1    // generations before stationary statistics are measured (-1: never)
+ BURNIN_F = "burnin" // flag for BURNIN parameter
+ JOBS_F = "j"   // flag for number of concurrent simulations (default: num CPUs)
+)
+
+// parameters shared by all of the simulations in an experiment
+type SimParams struct {
+  NumGens, NumAgents, Avgdeg, Gtype, Mult, Cost, Step, Srule, Urule, Cscheme, Burnin, Gimpl int32
+  Betae, Betaa, W, Mu float64
+  UseRep bool
+  Fine, Pcost float64
+  Pexeerr float32
+}
+
+// An experiment made up of simulations that share their parameters
+type GPGGExperiment struct {
+  NumSims int       // number of simulations
+  NumGraphs int     // number of shared graphs (0 = a new graph for each simulation)
+  SimsPerGraph int  // number of simulations that start from each shared graph
+  NumJobs int       // number of simulations to run concurrently
+  Params SimParams
+}
+
+// The flags that set the parameters of a graph public goods game experiment
+type GPGGFlags struct {
+  NumSims, NumGraphs, SimsPerGraph, NumGens, NumAgents, Avgdeg, Gtype, Gimpl *int
+  Mult, Cost, Step, Srule, Urule, Cscheme, Burnin, NumJobs *int
+  Betae, Betaa, W, Fine, Pcost, Pexeerr, Mu *float64
+  UseRep *bool
+}
+
+// Add the flags for the parameters of a graph public goods game experiment
+// to a flag set
+func RegisterGPGGFlags(flags *flag.FlagSet) *GPGGFlags {
+  return &GPGGFlags {
+    NumSims:   flags.Int(SIMS_F, SIMS, "number of simulations to conduct (ignored if -ng > 0)"),
+    NumGraphs: flags.Int(NGRAPHS_F, NGRAPHS, "number of graphs shared by the simulations (0 = a new graph for each simulation)"),
+    SimsPerGraph: flags.Int(SPG_F, SPG, "number of simulations that start from a copy of each shared graph"),
+    NumGens:   flags.Int(GENS_F, GENS, "number of generations to simulate"),
+    NumAgents: flags.Int(AGENTS_F, AGENTS, "number of agents"),
+    Avgdeg:    flags.Int(Z_F, Z, "average degree of the graph (z)"),
+    Gtype:     flags.Int(GTYPE_F, GTYPE, "type of graph to use (0 = ring, 1 = homogeneous random, 2-6 = small world and scale free, 7 = Erdos-Renyi G(N,M), 8 = von Neumann lattice, 9 = Moore lattice, 10 = random regular, 11 = Erdos-Renyi G(N,p), 12 = power law configuration model, 13 = Holme-Kim, 14 = stochastic block model)"),
+    Gimpl:     flags.Int(GIMPL_F, GIMPL, "graph implementation (0 = adjacency list, 1 = dense indexed graph for large networks)"),
+    Mult:      flags.Int(MULT_F, MULT, "contribution multiplier (r)"),
+    Cost:      flags.Int(COST_F, COST, "cost to contribute"),
+    Betae:     flags.Float64(BETAE_F, BETAE, "selection strength for strategy updates"),
+    Betaa:     flags.Float64(BETAA_F, BETAA, "selection strength for structure updates"),
+    W:         flags.Float64(W_F, W, "ratio of time scales for strategy and structure updates"),
+    Step:      flags.Int(STEP_F, STEP, "time step (0 = one async update, 1 = MC step of N async updates, 2 = sync update)"),
+    Srule:     flags.Int(SRULE_F, SRULE, "structure update rule (0 = neighbor of y, 1 = random, 2 = high payout, 3 = high degree, 4 = either end, 5 = drift)"),
+    Urule:     flags.Int(URULE_F, URULE, "strategy update rule using betae (0 = pairwise Fermi, 1 = death-birth, 2 = birth-death, 3 = imitate best, 4 = replicator)"),
+    Cscheme:   flags.Int(CSCHEME_F, CSCHEME, "contribution scheme (0 = cost per game, 1 = cost per individual, 2 = cost scaled by degree)"),
+    UseRep:    flags.Bool(REP_F, REP, "use reputation-driven action modules to contribute and punish"),
+    Fine:      flags.Float64(FINE_F, FINE, "fine paid by a non-contributor to each punisher"),
+    Pcost:     flags.Float64(PCOST_F, PCOST, "cost paid by a punisher for each non-contributor punished"),
+    Pexeerr:   flags.Float64(PEXEERR_F, PEXEERR, "execution error probability for action modules"),
+    Mu:        flags.Float64(MU_F, MU, "probability that an update is a random exploration (mu > 0 runs to the generation limit)"),
+    Burnin:    flags.Int(BURNIN_F, BURNIN, "generations to complete before stationary statistics are measured (negative: never)"),
+    NumJobs:   flags.Int(JOBS_F, runtime.NumCPU(), "number of simulations to run concurrently"),
+  }
+}
+
+// Return the experiment set by the flags or an error describing the first
+// invalid parameter.  When the simulations share graphs, the number of
+// simulations is the number of graphs times the simulations per graph.
+func (self *GPGGFlags) Check() (GPGGExperiment, error) {
+  p := SimParams { NumGens: int32(*self.NumGens), NumAgents: int32(*self.NumAgents),
+                   Avgdeg: int32(*self.Avgdeg), Gtype: int32(*self.Gtype), Mult: int32(*self.Mult),
+                   Cost: int32(*self.Cost), Step: int32(*self.Step), Srule: int32(*self.Srule),
+                   Urule: int32(*self.Urule), Gimpl: int32(*self.Gimpl),
+                   Cscheme: int32(*self.Cscheme), Burnin: int32(*self.Burnin),
+                   Betae: *self.Betae, Betaa: *self.Betaa, Mu: *self.Mu,
+                   W: *self.W, UseRep: *self.UseRep, Fine: *self.Fine, Pcost: *self.Pcost,
+                   Pexeerr: float32(*self.Pexeerr) }
+  e := GPGGExperiment { NumSims: *self.NumSims, NumGraphs: *self.NumGraphs,
+                        SimsPerGraph: *self.SimsPerGraph, NumJobs: *self.NumJobs, Params: p }
+  err := e.Check()
+  // -- each shared graph is used by the same number of simulations
+  if (e.NumGraphs > 0) {
+    e.NumSims = e.NumGraphs * e.SimsPerGraph
+  }
+  return e, err
+}
+
+// Return an error describing the first invalid parameter
+func (self GPGGExperiment) Check() error {
+  p := self.Params
+  switch {
+  case (self.NumSims < 1) && (self.NumGraphs == 0):
+    return fmt.Errorf("-%s: number of simulations must be at least 1", SIMS_F)
+  case (self.NumGraphs < 0):
+    return fmt.Errorf("-%s: number of graphs must not be negative", NGRAPHS_F)
+  case (self.SimsPerGraph < 1):
+    return fmt.Errorf("-%s: number of simulations per graph must be at least 1", SPG_F)
+  case (self.NumJobs < 1):
+    return fmt.Errorf("-%s: number of concurrent simulations must be at least 1", JOBS_F)
+  case (p.NumAgents < 2):
+    return fmt.Errorf("-%s: number of agents must be at least 2", AGENTS_F)
+  case (p.NumGens < 0):
+    return fmt.Errorf("-%s: number of generations must not be negative", GENS_F)
+  case (p.Betae < 0) || (p.Betaa < 0):
+    return fmt.Errorf("-%s and -%s: selection strengths must not be negative", BETAE_F, BETAA_F)
+  case (p.W < 0):
+    return fmt.Errorf("-%s: ratio of time scales must not be negative", W_F)
+  case (p.Step < 0) || (p.Step > simgpgg.STEP_SYNC):
+    return fmt.Errorf("-%s: unknown time step: %d", STEP_F, p.Step)
+  case (p.Srule < 0) || (p.Srule > simgpgg.SRULE_DRIFT):
+    return fmt.Errorf("-%s: unknown structure update rule: %d", SRULE_F, p.Srule)
+  case (p.Urule < 0) || (p.Urule > simgpgg.URULE_REPLICATOR):
+    return fmt.Errorf("-%s: unknown strategy update rule: %d", URULE_F, p.Urule)
+  case (p.Cscheme < 0) || (p.Cscheme > simgpgg.CONTRIB_DEGREE):
+    return fmt.Errorf("-%s: unknown contribution scheme: %d", CSCHEME_F, p.Cscheme)
+  case (p.Gimpl < 0) || (p.Gimpl > simgpgg.GIMPL_DENSE):
+    return fmt.Errorf("-%s: unknown graph implementation: %d", GIMPL_F, p.Gimpl)
+  case (p.Mu < 0) || (p.Mu > 1):
+    return fmt.Errorf("-%s: exploration probability must be between 0 and 1", MU_F)
+  case (p.Pexeerr < 0) || (p.Pexeerr > 1):
+    return fmt.Errorf("-%s: execution error probability must be between 0 and 1", PEXEERR_F)
+  }
+  err := simgpgg.CheckGraphParams(p.Gtype, p.NumAgents, p.Avgdeg)
+  if (err != nil) {
+    return fmt.Errorf("-%s, -%s and -%s: %v", GTYPE_F, AGENTS_F, Z_F, err)
+  }
+  return nil
+}
+
+// Return the name of the output directory of simulation s
+func SimDirName(dname string, s int) string {
+  return path.Join(dname, fmt.Sprintf("%s%d", "sim", s))
+}
+
+// Create the output directories for the simulations of an experiment.  An
+// error is returned if a directory exists and overwrite is false.
+// -- this is done before any simulation starts so that a failure
+//    doesn't leave the experiment partially complete
+func MakeSimDirs(dname string, numSims int, overwrite bool) ([]string, error) {
+  simdnames := make([]string, numSims)
+  for s := 0; s < numSims; s++ {
+    simdname := SimDirName(dname, s)
+    err := os.Mkdir(simdname, os.ModePerm)
+    if (err != nil) {
+      if (!os.IsExist(err) || !overwrite) {
+        return nil, err
+      }
+    }
+    simdnames[s] = simdname
+  }
+  return simdnames, nil
+}
+
+// Run the simulations of an experiment in the directories made by
+// MakeSimDirs.  The seed is used to generate a seed for each simulation and
+// each shared graph.  The parameters and results of the simulations are
+// written to out as a JSON array in simulation order and the ensemble
+// statistics are written to summary.json in the experiment directory.  Once
+// stop returns true, running simulations finish their current generation
+// and simulations that have not started are skipped.  Returns the number of
+// simulations that were run, and ErrInterrupted if any were stopped early
+// or skipped.  The progress of each simulation is published to the monitor
+// unless it is nil.
+func RunGPGGExperiment(dname string, simdnames []string, seed int64, e GPGGExperiment,
+                       out io.Writer, stop func() bool, mon *Monitor) (int, error) {
+  // give each simulation its own seed
+  // -- seeds are assigned in order so an experiment can be repeated
+  seedGen := rand.New(rand.NewSource(seed))
+  seeds := make([]int64, e.NumSims)
+  for s := 0; s < e.NumSims; s++ {
+    seeds[s] = seedGen.Int63()
+  }
+
+  // generate the shared graphs and record them in the output directory
+  // -- simulation s starts from a copy of graph s / simsPerGraph
+  graphs := make([]*SharedGraph, e.NumGraphs)
+  for g := 0; g < e.NumGraphs; g++ {
+    var err error
+    graphs[g], err = NewSharedGraph(dname, g, seedGen.Int63(), e.Params)
+    if (err != nil) {
+      return 0, fmt.Errorf("cannot create graph %d: %v", g, err)
+    }
+  }
+
+  // run the simulations on a bounded pool of workers
+  numJobs := max(e.NumJobs, 1)
+  jobs := make(chan int, e.NumSims)
+  results := make(chan SimResult, e.NumSims)
+  for j := 0; j < numJobs; j++ {
+    go func() {
+      for s := range jobs {
+        if ((stop != nil) && stop()) {
+          results <- SimResult { idx: s, skipped: true }
+          continue
+        }
+        var graph *SharedGraph
+        if (e.NumGraphs > 0) {
+          graph = graphs[s / e.SimsPerGraph]
+        }
+        var observers []simgpgg.Observer
+        if (mon != nil) {
+          observers = append(observers, &GPGGMonitor { mon: mon, sim: s })
+        }
+        json, summary, err := RunGPGGSimulation(simdnames[s], seeds[s], e.Params, graph, stop, observers...)
+        results <- SimResult { idx: s, json: json, summary: summary, err: err }
+      }
+    }()
+  }
+  for s := 0; s < e.NumSims; s++ {
+    jobs <- s
+  }
+  close(jobs)
+
+  // make the output a valid JSON array
+  fmt.Fprintln(out, "[")
+
+  // write the results in simulation order as they become available
+  // -- each result is held until the next one is written so that the last
+  //    one written is not followed by a comma, whichever simulations ran
+  ensemble := simgpgg.NewEnsemble()
+  pending := make(map[int]SimResult)
+  held := ""
+  next := 0
+  numRun := 0
+  var err error
+  for i := 0; i < e.NumSims; i++ {
+    result := <-results
+    pending[result.idx] = result
+    for result, ok := pending[next]; ok; result, ok = pending[next] {
+      delete(pending, next)
+      next++
+      if (result.skipped) {
+        continue
+      }
+      if ((result.err != nil) && (err == nil)) {
+        err = result.err
+      }
+      // -- a simulation whose output files couldn't be created didn't run
+      if (result.json == "") {
+        continue
+      }
+      numRun++
+      ensemble.Add(result.summary)
+      if (held != "") {
+        fmt.Fprint(out, held)
+        fmt.Fprintln(out, "},")
+      }
+      held = result.json
+    }
+  }
+  if (held != "") {
+    fmt.Fprint(out, held)
+    fmt.Fprintln(out, "}")
+  }
+
+  // make the output a valid JSON array
+  fmt.Fprintln(out, "]")
+
+  // write the ensemble statistics for the experiment
+  serr := WriteFileAtomic(path.Join(dname, "summary.json"), func(w *bufio.Writer) error {
+    ensemble.WriteSummary(w)
+    return nil
+  })
+  if (err == nil) {
+    err = serr
+  }
+  if ((err == nil) && (stop != nil) && stop()) {
+    err = ErrInterrupted
+  }
+  return numRun, err
+}
+
+// the output of a simulation and its position in the experiment
+type SimResult struct {
+  idx int
+  json string
+  summary simgpgg.SimSummary
+  err error           // error writing the output files of the simulation
+  skipped bool        // the simulation was not run because of a stop
+}
+
+// a graph that several simulations start from
+type SharedGraph struct {
+  idx int             // position of the graph in the experiment
+  fname string        // file that holds the graph as an edge list
+  seed int64          // seed used to generate the graph
+  graph goraph.Graph  // the graph - simulations use a clone of it
+}
+
+// Generate a graph for simulations to share and write it to the experiment
+// directory
+func NewSharedGraph(dname string, idx int, seed int64, p SimParams) (*SharedGraph, error) {
+  graph, err := simgpgg.TryNewGraph(p.Gtype, p.NumAgents, p.Avgdeg, rand.New(rand.NewSource(seed)))
+  if (err != nil) {
+    return nil, err
+  }
+  fname := path.Join(dname, fmt.Sprintf("%s%d.txt", "graph", idx))
+  gfile, err := os.Create(fname)
+  if (err != nil) {
+    return nil, err
+  }
+  defer gfile.Close()
+  err = goraph.WriteEdgeList(gfile, graph)
+  if (err != nil) {
+    return nil, err
+  }
+  return &SharedGraph { idx: idx, fname: fname, seed: seed, graph: graph }, nil
+}
+
+// Run a single simulation that writes its data to the specified directory.
+// The simulation starts from a clone of the shared graph unless it is nil, in
+// which case it generates its own graph.  The simulation stops after the
+// current generation once stop returns true.  The observers are notified of
+// the events of the simulation.  The simulation parameters and results are
+// returned as a JSON object without its closing brace along with a summary of
+// the final state of the simulation and any error writing the output files.
+// The simulation doesn't run if its output files can't be created.
+func RunGPGGSimulation(simdname string, seed int64, p SimParams, shared *SharedGraph,
+                       stop func() bool, observers ...simgpgg.Observer) (string, simgpgg.SimSummary, error) {
+  // set up the output files for the simulation
+  // -- file for population statistics (strategy percentages)
+  psfname := path.Join(simdname, "pstat.csv")
+  psfile, err := CreateOutputFile(psfname)
+  if (err != nil) {
+    return "", simgpgg.SimSummary{}, err
+  }
+  // -- file for degree histogram
+  dhfname := path.Join(simdname, "dhist.csv")
+  dhfile, err := CreateOutputFile(dhfname)
+  if (err != nil) {
+    psfile.Close()
+    return "", simgpgg.SimSummary{}, err
+  }
+
+  start := time.Now()
+
+  // create the sim engine with its own random number generator
+  rnGen := rand.New(rand.NewSource(seed))
+  var simeng *simgpgg.SimEngine
+  if (p.UseRep) {
+    simeng = simgpgg.NewRepSimEngineWithRNG(p.NumAgents, p.NumGens, p.Gtype, p.Avgdeg,
+                                            p.Mult, p.Cost, p.W, p.Betae, p.Betaa,
+                                            p.Fine, p.Pcost, p.Pexeerr, rnGen)
+  } else {
+    simeng = simgpgg.NewSimEngineWithRNG(p.NumAgents, p.NumGens, p.Gtype, p.Avgdeg,
+                                         p.Mult, p.Cost, p.W, p.Betae, p.Betaa, rnGen)
+  }
+
+  if (shared != nil) {
+    simeng.SetGraph(goraph.Clone(shared.graph))
+  }
+  simeng.SetGraphImpl(p.Gimpl)
+  simeng.SetStepMode(p.Step)
+  simeng.SetStructureRule(simgpgg.NewStructureRule(p.Srule, p.Betaa))
+  simeng.SetStrategyRule(simgpgg.NewStrategyRule(p.Urule, p.Betae))
+  simeng.SetContribScheme(p.Cscheme)
+  simeng.SetExploration(p.Mu)
+  simeng.SetBurnIn(p.Burnin)
+  simeng.SetStop(stop)
+
+  // run the simulation
+  observers = append([]simgpgg.Observer{ simgpgg.NewPStatsWriter(psfile), simgpgg.NewDegreeHistWriter(dhfile) },
+                     observers...)
+  gens := simeng.Run(observers...)
+
+  end := time.Now()
+
+  // give the output files their final names
+  err = psfile.Commit()
+  derr := dhfile.Commit()
+  if (err == nil) {
+    err = derr
+  }
+
+  // write simulation parameters and results
+  var out bytes.Buffer
+  fmt.Fprintln(&out, "{")
+  fmt.Fprintf(&out, "  \"params\":\n")
+  fmt.Fprintf(&out, "%v", simeng)
+  fmt.Fprint(&out, ",\n")
+  fmt.Fprintf(&out, "  \"results\":\n")
+  fmt.Fprintf(&out, "  {\n")
+  fmt.Fprintf(&out, "  \"psfile\":\"%s\",\n", psfname)
+  fmt.Fprintf(&out, "  \"dhfile\":\"%s\",\n", dhfname)
+  fmt.Fprintf(&out, "  \"seed\":%d,\n", seed)
+  if (shared != nil) {
+    fmt.Fprintf(&out, "  \"graph\":%d,\n", shared.idx)
+    fmt.Fprintf(&out, "  \"graphfile\":\"%s\",\n", shared.fname)
+    fmt.Fprintf(&out, "  \"graphseed\":%d,\n", shared.seed)
+  }
+  fmt.Fprintf(&out, "  \"ngens-completed\":%d,\n", gens)
+  if ((stop != nil) && stop()) {
+    fmt.Fprintf(&out, "  \"interrupted\":true,\n")
+  }
+  fmt.Fprintf(&out, "  \"runtime\":\"%v\"\n",end.Sub(start))
+  fmt.Fprintf(&out, "  }\n")
+  return out.String(), simeng.Summary(gens), err
+}
+
+// An observer that publishes the fraction of cooperators in each generation
+// of a simulation to a monitor
+type GPGGMonitor struct {
+  simgpgg.NopObserver
+  mon *Monitor
+  sim int // position of the simulation in the experiment
+}
+
+func (self *GPGGMonitor) GenEnd(simeng *simgpgg.SimEngine, gen int32) {
+  coop := float64(simeng.Nc)/float64(simeng.Nc + simeng.Nd)
+  self.mon.Publish(GenUpdate { Sim: self.sim, Gen: int(gen), Coop: &coop })
+}
+
+func (self *GPGGMonitor) SimEnd(simeng *simgpgg.SimEngine, gens int32) {
+  self.mon.PublishFinal(self.sim)
+}
+
+// Record the files written by an experiment in its manifest
+func AddGPGGFiles(m *Manifest, simdnames []string, e GPGGExperiment) {
+  for s := 0; s < e.NumSims; s++ {
+    m.AddFile(path.Join(path.Base(simdnames[s]), "pstat.csv"))
+    m.AddFile(path.Join(path.Base(simdnames[s]), "dhist.csv"))
+  }
+  for g := 0; g < e.NumGraphs; g++ {
+    m.AddFile(fmt.Sprintf("%s%d.txt", "graph", g))
+  }
+  m.AddFile("summary.json")
+}
+
+// The "gpgg run" subcommand: run an experiment of public goods games played
+// on graphs
+func GPGGRun(args []string) error {
+  flags, rf := NewRunFlagSet("gpgg run", "gpggdata")
+  gf := RegisterGPGGFlags(flags)
+  err := ParseFlags(flags, args)
+  if (err != nil) {
+    return err
+  }
+  e, err := gf.Check()
+  if (err != nil) {
+    return InvalidParams(err)
+  }
+  m, err := StartRun("gpgg run", args, flags, rf)
+  if (err != nil) {
+    return err
+  }
+  simdnames, err := MakeSimDirs(*rf.OutDir, e.NumSims, true)
+  if (err == nil) {
+    AddGPGGFiles(m, simdnames, e)
+    var mon *Monitor
+    mon, err = StartMonitor(*rf.HTTP, "gpgg run", flags)
+    if (err != nil) {
+      return m.Finish(err)
+    }
+    defer mon.Close()
+    stop := NotifyInterrupt()
+    defer stop.Stop()
+    var numRun int
+    numRun, err = RunGPGGExperiment(*rf.OutDir, simdnames, *rf.Seed, e, os.Stdout, stop.Requested, mon)
+    m.Progress = fmt.Sprintf("%d of %d simulations", numRun, e.NumSims)
+  }
+  return m.Finish(err)
+}
@@ -0,0 +1,37 @@
+package simcmd
+
+import "flag"
+import "testing"
+import "testutil"
+
+// return the experiment set by the gpgg flags in args and the error of its
+// check
+func checkGPGGArgs(args []string) (GPGGExperiment, error) {
+  flags := flag.NewFlagSet("test", flag.ContinueOnError)
+  gf := RegisterGPGGFlags(flags)
+  err := flags.Parse(args)
+  if (err != nil) {
+    return GPGGExperiment{}, err
+  }
+  return gf.Check()
+}
+
+func TestGPGGCheckW(u *testing.T) {
+  // W is a ratio of time scales, so values above 1 are valid
+  for _, w := range []string{ "0", "0.5", "1", "3", "9" } {
+    _, err := checkGPGGArgs([]string{ "-w", w })
+    testutil.AssertTrue(u, err == nil)
+  }
+  _, err := checkGPGGArgs([]string{ "-w", "-0.5" })
+  testutil.AssertFalse(u, err == nil)
+}
+
+func TestGPGGCheckBurnin(u *testing.T) {
+  // the stationary statistics aren't measured unless a burn in is given
+  e, err := checkGPGGArgs([]string{})
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertTrue(u, e.Params.Burnin < 0)
+  e, err = checkGPGGArgs([]string{ "-burnin", "10" })
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertInt32Equal(u, e.Params.Burnin, 10)
+}
@@ -0,0 +1,75 @@
+package simcmd
+
+import "errors"
+import "fmt"
+import "os"
+import "os/signal"
+import "sync/atomic"
+import "syscall"
+import "time"
+
+// exit status of a run that was interrupted (128 + SIGINT)
+const EXIT_INTERRUPTED = 130
+
+// The error returned by a run that stopped early because it was interrupted
+var ErrInterrupted = errors.New("interrupted")
+
+// A request to stop a run early, made by SIGINT or SIGTERM.  The run checks
+// for the request after each generation so that its output ends with a
+// complete generation.  A second signal exits the program at once.
+type Interrupt struct {
+  requested atomic.Bool
+  signals chan os.Signal
+}
+
+// Start catching SIGINT and SIGTERM.  Call Stop when the run is over to
+// restore the default handling of the signals.
+func NotifyInterrupt() *Interrupt {
+  self := &Interrupt { signals: make(chan os.Signal, 2) }
+  signal.Notify(self.signals, os.Interrupt, syscall.SIGTERM)
+  go func() {
+    for sig := range self.signals {
+      if (self.requested.Swap(true)) {
+        fmt.Fprintf(os.Stderr, "%v again: exiting without finishing the run\n", sig)
+        os.Exit(EXIT_INTERRUPTED)
+      }
+      fmt.Fprintf(os.Stderr, "%v: finishing the current generation (repeat to exit at once)\n", sig)
+    }
+  }()
+  return self
+}
+
+// Return true if the run should stop.  A nil interrupt never requests a
+// stop.
+func (self *Interrupt) Requested() bool {
+  return (self != nil) && self.requested.Load()
+}
+
+// Request a stop as if a signal had been received
+func (self *Interrupt) Request() {
+  self.requested.Store(true)
+}
+
+// Stop catching signals
+func (self *Interrupt) Stop() {
+  signal.Stop(self.signals)
+  close(self.signals)
+}
+
+// Write the last object of the JSON array written to stdout by a run,
+// noting whether the run was interrupted
+func WriteRuntime(runtime time.Duration, interrupted bool) {
+  if (interrupted) {
+    fmt.Println("{\n  \"interrupted\":true,\n  \"runtime\":", runtime, "\n}")
+  } else {
+    fmt.Println("{\n  \"runtime\":", runtime, "\n}")
+  }
+}
+
+// Return ErrInterrupted if a stop was requested
+func (self *Interrupt) Err() error {
+  if (self.Requested()) {
+    return ErrInterrupted
+  }
+  return nil
+}
@@ -0,0 +1,85 @@
+package simcmd
+
+import "testing"
+import "testutil"
+import "bytes"
+import "encoding/json"
+import "flag"
+import "fmt"
+import "path"
+import "sim"
+import "strings"
+
+func TestInterrupt(u *testing.T) {
+  var none *Interrupt
+  testutil.AssertFalse(u, none.Requested())
+  stop := NotifyInterrupt()
+  defer stop.Stop()
+  testutil.AssertFalse(u, stop.Requested())
+  testutil.AssertTrue(u, stop.Err() == nil)
+  stop.Request()
+  testutil.AssertTrue(u, stop.Requested())
+  testutil.AssertTrue(u, stop.Err() == ErrInterrupted)
+}
+
+func TestInterruptedManifest(u *testing.T) {
+  dname := u.TempDir()
+  m := NewManifest("test run", nil, path.Join(dname, MANIFEST_FNAME), 1)
+  m.Progress = "2 of 5 generations"
+  err := fmt.Errorf("r3: %w", ErrInterrupted)
+  testutil.AssertTrue(u, m.Finish(err) == err)
+  r, err := ReadManifest(dname)
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertTrue(u, r.Status == STATUS_INTERRUPTED)
+  testutil.AssertTrue(u, r.Progress == "2 of 5 generations")
+  testutil.AssertTrue(u, r.Error == "")
+  testutil.AssertTrue(u, ManifestFileFor("data/stats.csv") == "data/stats.manifest.json")
+}
+
+func TestRunIRStop(u *testing.T) {
+  cfg := sim.DefaultConfig(2, 4)
+  cfg.Seed = 1
+  game, err := sim.NewGame(sim.GAME, sim.COST, sim.BENEFIT, 0, 0, 0, 0)
+  testutil.AssertTrue(u, err == nil)
+  s := sim.MustNewSimEngine(cfg)
+  // the run stops after the generation in which the stop is requested
+  calls := 0
+  stop := func() bool {
+    calls++
+    return calls >= 2
+  }
+  var out bytes.Buffer
+  testutil.AssertIntEqual(u, RunIR(s, 5, game, 2, 4, &out, stop, nil), 2)
+  testutil.AssertIntEqual(u, strings.Count(out.String(), "\n"), 2)
+  testutil.AssertIntEqual(u, RunIR(s, 5, game, 2, 4, &out, nil, nil), 5)
+}
+
+func TestRunGPGGExperimentStop(u *testing.T) {
+  flags := flag.NewFlagSet("test", flag.ContinueOnError)
+  gf := RegisterGPGGFlags(flags)
+  testutil.AssertTrue(u, flags.Parse([]string{ "-s", "3", "-g", "3", "-a", "20" }) == nil)
+  e, err := gf.Check()
+  testutil.AssertTrue(u, err == nil)
+
+  // simulations that haven't started when a stop is requested are skipped
+  for _, stopped := range []bool{ true, false } {
+    dname := u.TempDir()
+    simdnames, err := MakeSimDirs(dname, e.NumSims, false)
+    testutil.AssertTrue(u, err == nil)
+    var out bytes.Buffer
+    numRun, err := RunGPGGExperiment(dname, simdnames, 1, e, &out, func() bool { return stopped }, nil)
+    var results []interface{}
+    testutil.AssertTrue(u, json.Unmarshal(out.Bytes(), &results) == nil)
+    testutil.AssertTrue(u, fileExists(path.Join(dname, "summary.json")))
+    if (stopped) {
+      testutil.AssertTrue(u, err == ErrInterrupted)
+      testutil.AssertIntEqual(u, numRun, 0)
+      testutil.AssertIntEqual(u, len(results), 0)
+    } else {
+      testutil.AssertTrue(u, err == nil)
+      testutil.AssertIntEqual(u, numRun, 3)
+      testutil.AssertIntEqual(u, len(results), 3)
+      testutil.AssertTrue(u, fileExists(path.Join(simdnames[2], "pstat.csv")))
+    }
+  }
+}
@@ -0,0 +1,218 @@
+package simcmd
+
+import "flag"
+import "fmt"
+import "io"
+import "sim"
+import "time"
+
+// The flags that set the parameters of an indirect reciprocity simulation
+type IRFlags struct {
+  Gens, Cost, Benefit, Game, R, S, T, P, NumTribes, NumAgents *int
+  Beta, Eta, Pcon, Pmig, Passmut, Pactmut, Passerr, Pexeerr *float64
+  SingleDef, PassmutAll, NoMP, UseAM *bool
+}
+
+// Add the flags for the parameters of an indirect reciprocity simulation to
+// a flag set
+func RegisterIRFlags(flags *flag.FlagSet) *IRFlags {
+  return &IRFlags {
+    Gens:      flags.Int(sim.GENS_F, sim.NUMGENS, "number of generations to simulate"),
+    Cost:      flags.Int(sim.COST_F, sim.COST, "cost c to donate"),
+    Benefit:   flags.Int(sim.BEN_F,  sim.BENEFIT, "benefit b received from donation"),
+    Game:      flags.Int(sim.GAME_F, sim.GAME, "two-player game (0 = donation game, 1 = prisoner's dilemma using c and b, 2 = payoff matrix R, S, T, P e.g. snowdrift or stag hunt)"),
+    R:         flags.Int(sim.PAYOFF_R_F, sim.PAYOFF_R, "payoff R when both players cooperate (payoff matrix only)"),
+    S:         flags.Int(sim.PAYOFF_S_F, sim.PAYOFF_S, "payoff S to a cooperator that meets a defector (payoff matrix only)"),
+    T:         flags.Int(sim.PAYOFF_T_F, sim.PAYOFF_T, "payoff T to a defector that meets a cooperator (payoff matrix only)"),
+    P:         flags.Int(sim.PAYOFF_P_F, sim.PAYOFF_P, "payoff P when both players defect (payoff matrix only)"),
+    NumTribes: flags.Int(sim.TRIBES_F, sim.NUMTRIBES, "number of tribes"),
+    NumAgents: flags.Int(sim.AGENTS_F, sim.NUMAGENTS, "number of agents"),
+    Beta:      flags.Float64(sim.BETA_F, sim.BETA, "conflict selection strength"),
+    Eta:       flags.Float64(sim.ETA_F, sim.ETA, "bit switch selection strength"),
+    Pcon:      flags.Float64(sim.PCON_F, sim.PCON, "conflict probability"),
+    Pmig:      flags.Float64(sim.PMIG_F, sim.PMIG, "migration probability"),
+    Passmut:   flags.Float64(sim.PASSM_F, sim.PASSMUT, "assess module bit mutation probability"),
+    Pactmut:   flags.Float64(sim.PACTM_F, sim.PACTMUT, "action module bit mutation probability"),
+    Passerr:   flags.Float64(sim.PASSE_F, sim.PASSERR, "assessment error probability"),
+    Pexeerr:   flags.Float64(sim.PEXEE_F, sim.PEXEERR, "execution error probability"),
+    SingleDef: flags.Bool(sim.SINGLE_DEF_F, sim.SINGLE_DEF, "each tribe can only be defeated once per generation"),
+    PassmutAll: flags.Bool(sim.PASSMUT_ALL_F, sim.PASSMUT_ALL, "attempt mutation on all assess mod bits"),
+    NoMP:      flags.Bool(sim.NOMP_F, sim.NOMP, "turn off multiprocessing"),
+    UseAM:     flags.Bool(sim.USEAM_F, sim.USEAM, "use adaptive mutation"),
+  }
+}
+
+// Return the game and engine configuration set by the flags or an error
+// describing the first invalid parameter
+func (self *IRFlags) Check() (sim.Game, sim.Config, error) {
+  cfg := sim.Config { NumTribes: *self.NumTribes, NumAgents: *self.NumAgents,
+                      Beta: *self.Beta, Eta: *self.Eta, Pcon: *self.Pcon, Pmig: *self.Pmig,
+                      Passmut: *self.Passmut, Pactmut: *self.Pactmut,
+                      Passerr: *self.Passerr, Pexeerr: *self.Pexeerr,
+                      SingleDef: *self.SingleDef, PassmutAll: *self.PassmutAll,
+                      UseAM: *self.UseAM, UseMP: !*self.NoMP }
+  game, err := sim.NewGame(*self.Game, int32(*self.Cost), int32(*self.Benefit),
+                           int32(*self.R), int32(*self.S), int32(*self.T), int32(*self.P))
+  if (err != nil) {
+    return nil, cfg, fmt.Errorf("-%s: %v", sim.GAME_F, err)
+  }
+  return game, cfg, CheckIRParams(*self.Gens, game, cfg)
+}
+
+// Return an error describing the first invalid parameter
+func CheckIRParams(gens int, game sim.Game, cfg sim.Config) error {
+  if (gens < 0) {
+    return fmt.Errorf("-%s: number of generations must not be negative", sim.GENS_F)
+  }
+  err := cfg.Validate()
+  if (err != nil) {
+    return err
+  }
+  // adaptive mutation needs a range of possible payouts
+  if (cfg.UseAM) {
+    minPO, maxPO := sim.CalcMinMaxGamePayouts(cfg.NumAgents, game)
+    err := sim.CheckTribalPayouts(minPO, maxPO)
+    if (err != nil) {
+      return fmt.Errorf("-%s: adaptive mutation needs a game with a range of payouts and at least 2 agents: %v", sim.USEAM_F, err)
+    }
+  }
+  return nil
+}
+
+// Simulate the specified number of generations and write the statistics of
+// each generation.  The simulation stops early if stop is not nil and
+// returns true after a generation.  Returns the number of generations
+// completed.  The statistics are also published to the monitor unless it is
+// nil.
+func RunIR(s *sim.SimEngine, gens int, game sim.Game, numTribes int, numAgents int,
+           w io.Writer, stop func() bool, mon *Monitor) int {
+  s.SetStop(stop)
+  observers := []sim.Observer{ NewIRStatsWriter(w, s, game, numTribes, numAgents) }
+  if (mon != nil) {
+    observers = append(observers, &IRMonitor { mon: mon })
+  }
+  gens = s.Run(game, gens, observers...)
+  mon.PublishFinal(0)
+  return gens
+}
+
+// An observer that writes the statistics of each generation of an indirect
+// reciprocity simulation as a line of CSV (see WriteIRHeader)
+type IRStatsWriter struct {
+  sim.NopObserver
+  w io.Writer
+  numTribes, numAgents int
+  minPO, maxPO int32 // min and max possible payouts of all tribes per generation
+}
+
+func NewIRStatsWriter(w io.Writer, s *sim.SimEngine, game sim.Game, numTribes int, numAgents int) *IRStatsWriter {
+  // calculate max and min possible payouts per generation
+  minPO, maxPO := s.MinMaxGamePayouts(game)
+  return &IRStatsWriter { w: w, numTribes: numTribes, numAgents: numAgents,
+                          minPO: minPO * int32(numTribes), maxPO: maxPO * int32(numTribes) }
+}
+
+func (self *IRStatsWriter) GenEnd(s *sim.SimEngine, gen int, payouts int32) {
+  n, a := s.GetStats()
+  WriteIRStats(self.w, gen, self.numTribes, self.numAgents, n, a, payouts, self.minPO, self.maxPO)
+}
+
+// An observer that publishes the statistics of each generation of an
+// indirect reciprocity simulation to a monitor
+type IRMonitor struct {
+  sim.NopObserver
+  mon *Monitor
+}
+
+func (self *IRMonitor) GenEnd(s *sim.SimEngine, gen int, payouts int32) {
+  n, a := s.GetStats()
+  // -- action modules are numbered by their bits (0 to 15)
+  actions := make([]int, 16)
+  for bits, count := range a {
+    actions[bits] = count
+  }
+  p := float64(payouts)
+  self.mon.Publish(GenUpdate { Gen: gen, Payouts: &p, Assess: n[:], Actions: actions })
+}
+
+func WriteIRHeader(w io.Writer) {
+  fmt.Fprintf(w, "gen,t,a,n0,n1,n2,n3,n4,n5,n6,n7,a00,a01,a02,a03,a04,a05,a06,a07,a08,a09,a10,a11,a12,a13,a14,a15,po,minpo,maxpo\n")
+}
+
+func WriteIRStats(w io.Writer, gen int, numTribes int, numAgents int,
+                  n [8]int, a map[int]int,
+                  p int32, min int32, max int32) {
+  fmt.Fprintf(w, "%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d\n",
+                 gen, numTribes, numAgents,
+                 n[0], n[1], n[2], n[3], n[4], n[5], n[6], n[7],
+                 a[0], a[1], a[2], a[3], a[4], a[5], a[6], a[7],
+                 a[8], a[9], a[10], a[11], a[12], a[13], a[14], a[15],
+                 p, min, max)
+}
+
+func WriteIRSimParams(s *sim.SimEngine, gens int, cost int, benefit int, game sim.Game, fname string) {
+  // output simulation parameters
+  fmt.Println("{")
+  fmt.Printf("  \"simtype\":\"IR\",\n")
+  fmt.Printf("  \"ngens\":%d,\n", gens)
+  fmt.Printf("  \"cost\":%d,\n", cost)
+  fmt.Printf("  \"benefit\":%d,\n", benefit)
+  fmt.Printf("  \"game\":\"%v\",\n", game)
+  fmt.Printf("  \"ofile\":\"%s\",\n", fname)
+  s.WriteSimParams()
+  fmt.Println("}")
+}
+
+// The "ir run" subcommand: run an indirect reciprocity simulation
+func IRRun(args []string) error {
+  flags, rf := NewRunFlagSet("ir run", "irdata")
+  ir := RegisterIRFlags(flags)
+  err := ParseFlags(flags, args)
+  if (err != nil) {
+    return err
+  }
+  game, cfg, err := ir.Check()
+  if (err != nil) {
+    return InvalidParams(err)
+  }
+  m, err := StartRun("ir run", args, flags, rf)
+  if (err != nil) {
+    return err
+  }
+  cfg.Seed = *rf.Seed
+  mon, err := StartMonitor(*rf.HTTP, "ir run", flags)
+  if (err != nil) {
+    return m.Finish(err)
+  }
+  defer mon.Close()
+  return m.Finish(runIR(m, cfg, game, *ir.Gens, *ir.Cost, *ir.Benefit, mon))
+}
+
+// run the simulation of the "ir run" subcommand
+func runIR(m *Manifest, cfg sim.Config, game sim.Game, gens int, cost int, benefit int, mon *Monitor) error {
+  ofile, err := CreateOutputFile(m.AddFile("stats.csv"))
+  if (err != nil) {
+    return err
+  }
+  defer ofile.Close()
+  WriteIRHeader(ofile)
+
+  stop := NotifyInterrupt()
+  defer stop.Stop()
+  start := time.Now()
+  s := sim.MustNewSimEngine(cfg)
+  fmt.Println("[")
+  WriteIRSimParams(s, gens, cost, benefit, game, ofile.Name())
+  fmt.Println(",")
+  done := RunIR(s, gens, game, cfg.NumTribes, cfg.NumAgents, ofile, stop.Requested, mon)
+  end := time.Now()
+  WriteRuntime(end.Sub(start), stop.Requested())
+  fmt.Println("]")
+
+  m.Progress = fmt.Sprintf("%d of %d generations", done, gens)
+  err = ofile.Commit()
+  if (err != nil) {
+    return err
+  }
+  return stop.Err()
+}
@@ -0,0 +1,221 @@
+package simcmd
+
+import "encoding/json"
+import "flag"
+import "fmt"
+import "net"
+import "net/http"
+import "os"
+import "simbase"
+import "sort"
+import "sync"
+import "time"
+
+// flag for the address of the monitoring endpoint
+const HTTP_F = "http"
+
+// number of recent updates kept by a monitor
+const MONITOR_HISTORY = 100
+
+// minimum time between the updates a monitor records for a simulation
+// -- a gpgg generation can be a single update, so recording every generation
+//    would flood the event stream and the recent updates
+const MONITOR_INTERVAL = 100 * time.Millisecond
+
+// The statistics of a generation reported by a monitor.  Fields that a
+// simulation doesn't measure are left out.
+type GenUpdate struct {
+  Sim int `json:"sim"`                    // simulation in an experiment (0 for a single run)
+  Gen int `json:"gen"`                    // generation, counting from 0
+  Payouts *float64 `json:"payouts,omitempty"` // total payouts earned in the generation
+  Assess []int `json:"assess,omitempty"`  // number of tribes with each assessment bit GOOD
+  Actions []int `json:"actions,omitempty"` // agents using each action module (ir) or action bit (pgg)
+  Coop *float64 `json:"coop,omitempty"`   // fraction of cooperators (or contributions)
+  Final bool `json:"final,omitempty"`     // the last update of the simulation
+  Time string `json:"time"`
+}
+
+// The state of a run served by a monitor
+type MonitorStatus struct {
+  Command string `json:"command"`
+  Params map[string]interface{} `json:"params"`
+  Start string `json:"start"`
+  Current []GenUpdate `json:"current"` // latest update of each simulation
+  Recent []GenUpdate `json:"recent"`   // recent updates, oldest first
+}
+
+// A local HTTP endpoint for watching a run while it is in progress.  The
+// status of the run is served as JSON at /status and the updates of each
+// generation are streamed as Server-Sent Events at /events.  The updates of
+// a simulation are sampled: the event stream and the recent updates only get
+// an update once MONITOR_INTERVAL has passed since the previous one, while the
+// current update of each simulation is always the latest.  The final update
+// of each simulation is always sent.
+type Monitor struct {
+  mutex sync.Mutex
+  status MonitorStatus
+  current map[int]GenUpdate
+  lastRecorded map[int]time.Time
+  subscribers map[chan []byte]bool
+  listener net.Listener
+  server *http.Server
+}
+
+// Start serving the status of a run at the specified address (e.g. :8080).
+// The run parameters are the values of the flags.  Returns nil if the
+// address is empty.  A nil monitor ignores every update.
+func StartMonitor(addr string, command string, flags *flag.FlagSet) (*Monitor, error) {
+  if (addr == "") {
+    return nil, nil
+  }
+  listener, err := net.Listen("tcp", addr)
+  if (err != nil) {
+    return nil, fmt.Errorf("-%s: %v", HTTP_F, err)
+  }
+  self := &Monitor { current: make(map[int]GenUpdate), lastRecorded: make(map[int]time.Time),
+                     subscribers: make(map[chan []byte]bool), listener: listener }
+  self.status = MonitorStatus { Command: command, Params: simbase.FlagValues(flags),
+                                Start: time.Now().Format(time.RFC3339), Recent: []GenUpdate{} }
+  mux := http.NewServeMux()
+  mux.HandleFunc("/", self.serveStatus)
+  mux.HandleFunc("/status", self.serveStatus)
+  mux.HandleFunc("/events", self.serveEvents)
+  self.server = &http.Server { Handler: mux }
+  go self.server.Serve(listener)
+  fmt.Fprintf(os.Stderr, "monitoring at http://%s/status and http://%s/events\n", self.Addr(), self.Addr())
+  return self, nil
+}
+
+// Return the address the monitor is listening on
+func (self *Monitor) Addr() string {
+  return self.listener.Addr().String()
+}
+
+// Record the statistics of a generation and send them to the clients of the
+// event stream.  Updates that follow the previous one of the same
+// simulation within MONITOR_INTERVAL only become the current update.
+func (self *Monitor) Publish(u GenUpdate) {
+  if (self == nil) {
+    return
+  }
+  now := time.Now()
+  u.Time = now.Format(time.RFC3339Nano)
+  self.mutex.Lock()
+  defer self.mutex.Unlock()
+  self.current[u.Sim] = u
+  if (now.Sub(self.lastRecorded[u.Sim]) < MONITOR_INTERVAL) {
+    return
+  }
+  self.record(u)
+}
+
+// Record the current update of a simulation as its final update and send it
+// to the clients of the event stream, whether or not the update was sent
+// before.  Does nothing if the simulation hasn't published an update.
+func (self *Monitor) PublishFinal(sim int) {
+  if (self == nil) {
+    return
+  }
+  self.mutex.Lock()
+  defer self.mutex.Unlock()
+  u, ok := self.current[sim]
+  if (!ok) {
+    return
+  }
+  u.Final = true
+  self.current[sim] = u
+  self.record(u)
+}
+
+// add an update to the recent updates and send it to the clients of the
+// event stream
+// -- the mutex must be held
+func (self *Monitor) record(u GenUpdate) {
+  self.lastRecorded[u.Sim] = time.Now()
+  self.status.Recent = append(self.status.Recent, u)
+  if (len(self.status.Recent) > MONITOR_HISTORY) {
+    self.status.Recent = self.status.Recent[len(self.status.Recent) - MONITOR_HISTORY:]
+  }
+  b, err := json.Marshal(u)
+  if (err != nil) {
+    return
+  }
+  for events := range self.subscribers {
+    // a client that can't keep up misses the update
+    select {
+    case events <- b:
+    default:
+    }
+  }
+}
+
+// Stop serving and end the event streams
+func (self *Monitor) Close() error {
+  if (self == nil) {
+    return nil
+  }
+  self.mutex.Lock()
+  for events := range self.subscribers {
+    close(events)
+    delete(self.subscribers, events)
+  }
+  self.mutex.Unlock()
+  return self.server.Close()
+}
+
+// serve the status of the run as JSON
+func (self *Monitor) serveStatus(w http.ResponseWriter, r *http.Request) {
+  self.mutex.Lock()
+  status := self.status
+  status.Recent = append([]GenUpdate{}, self.status.Recent...)
+  status.Current = make([]GenUpdate, 0, len(self.current))
+  for _, u := range self.current {
+    status.Current = append(status.Current, u)
+  }
+  self.mutex.Unlock()
+  sort.Slice(status.Current, func(i, j int) bool { return status.Current[i].Sim < status.Current[j].Sim })
+
+  b, err := json.MarshalIndent(status, "", "  ")
+  if (err != nil) {
+    http.Error(w, err.Error(), http.StatusInternalServerError)
+    return
+  }
+  w.Header().Set("Content-Type", "application/json")
+  fmt.Fprintf(w, "%s\n", b)
+}
+
+// stream the updates of each generation as Server-Sent Events
+func (self *Monitor) serveEvents(w http.ResponseWriter, r *http.Request) {
+  flusher, ok := w.(http.Flusher)
+  if (!ok) {
+    http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+    return
+  }
+  events := make(chan []byte, 64)
+  self.mutex.Lock()
+  self.subscribers[events] = true
+  self.mutex.Unlock()
+  defer func() {
+    self.mutex.Lock()
+    delete(self.subscribers, events)
+    self.mutex.Unlock()
+  }()
+
+  w.Header().Set("Content-Type", "text/event-stream")
+  w.Header().Set("Cache-Control", "no-cache")
+  w.WriteHeader(http.StatusOK)
+  flusher.Flush()
+  for {
+    select {
+    case <-r.Context().Done():
+      return
+    case b, ok := <-events:
+      if (!ok) {
+        // the run is over
+        return
+      }
+      fmt.Fprintf(w, "data: %s\n\n", b)
+      flusher.Flush()
+    }
+  }
+}
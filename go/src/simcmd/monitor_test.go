@@ -0,0 +1,87 @@
+package simcmd
+
+import "testing"
+import "testutil"
+import "bufio"
+import "encoding/json"
+import "flag"
+import "math"
+import "net/http"
+import "strings"
+import "time"
+
+func TestMonitor(u *testing.T) {
+  var none *Monitor
+  none.Publish(GenUpdate { Gen: 1 })
+  testutil.AssertTrue(u, none.Close() == nil)
+  mon, err := StartMonitor("", "ir run", flag.NewFlagSet("ir run", flag.ContinueOnError))
+  testutil.AssertTrue(u, (mon == nil) && (err == nil))
+
+  flags, _ := NewRunFlagSet("ir run", "irdata")
+  flags.Float64("beta", math.Inf(1), "")
+  mon, err = StartMonitor("127.0.0.1:0", "ir run", flags)
+  testutil.AssertTrue(u, err == nil)
+  url := "http://" + mon.Addr()
+
+  // updates are streamed to the clients of the event stream
+  resp, err := http.Get(url + "/events")
+  testutil.AssertTrue(u, err == nil)
+  defer resp.Body.Close()
+  events := bufio.NewReader(resp.Body)
+  testutil.AssertTrue(u, resp.Header.Get("Content-Type") == "text/event-stream")
+  // -- wait for the client to be subscribed
+  for i := 0; (i < 100) && (numSubscribers(mon) == 0); i++ {
+    time.Sleep(10 * time.Millisecond)
+  }
+  p := float64(12)
+  mon.Publish(GenUpdate { Gen: 0, Payouts: &p, Assess: []int{ 1, 2 } })
+  // -- an update that follows too soon only becomes the current update
+  mon.Publish(GenUpdate { Gen: 1, Payouts: &p })
+  line, err := events.ReadString('\n')
+  testutil.AssertTrue(u, err == nil)
+  var update GenUpdate
+  testutil.AssertTrue(u, strings.HasPrefix(line, "data: "))
+  testutil.AssertTrue(u, json.Unmarshal([]byte(line[len("data: "):]), &update) == nil)
+  testutil.AssertIntEqual(u, update.Gen, 0)
+  testutil.AssertTrue(u, *update.Payouts == p)
+  testutil.AssertTrue(u, update.Coop == nil)
+
+  // the status holds the parameters, the current generation and the recent
+  // updates
+  sresp, err := http.Get(url + "/status")
+  testutil.AssertTrue(u, err == nil)
+  defer sresp.Body.Close()
+  var status MonitorStatus
+  testutil.AssertTrue(u, json.NewDecoder(sresp.Body).Decode(&status) == nil)
+  testutil.AssertTrue(u, status.Command == "ir run")
+  testutil.AssertTrue(u, status.Params[OUTDIR_F] == "irdata")
+  testutil.AssertTrue(u, status.Params["beta"] == "+Inf")
+  testutil.AssertIntEqual(u, len(status.Current), 1)
+  testutil.AssertIntEqual(u, status.Current[0].Gen, 1)
+  testutil.AssertIntEqual(u, len(status.Recent), 1)
+  testutil.AssertIntEqual(u, len(status.Recent[0].Assess), 2)
+
+  // the final update is sent even if it follows too soon
+  mon.PublishFinal(0)
+  events.ReadString('\n')
+  line, err = events.ReadString('\n')
+  testutil.AssertTrue(u, err == nil)
+  update = GenUpdate{}
+  testutil.AssertTrue(u, json.Unmarshal([]byte(line[len("data: "):]), &update) == nil)
+  testutil.AssertIntEqual(u, update.Gen, 1)
+  testutil.AssertTrue(u, update.Final)
+  testutil.AssertIntEqual(u, len(mon.status.Recent), 2)
+
+  // the event stream ends when the monitor is closed
+  testutil.AssertTrue(u, mon.Close() == nil)
+  events.ReadString('\n')
+  _, err = events.ReadString('\n')
+  testutil.AssertFalse(u, err == nil)
+}
+
+// return the number of clients of the event stream
+func numSubscribers(mon *Monitor) int {
+  mon.mutex.Lock()
+  defer mon.mutex.Unlock()
+  return len(mon.subscribers)
+}
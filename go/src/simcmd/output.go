@@ -0,0 +1,123 @@
+package simcmd
+
+import "bufio"
+import "os"
+import "time"
+
+// how often output files are flushed to disk while a run writes them
+const FLUSH_INTERVAL = 10 * time.Second
+
+// suffix of an output file until it is committed
+const PARTIAL_SUFFIX = ".partial"
+
+// A buffered output file that is flushed to disk periodically so that a
+// crash loses at most a few seconds of output.  The file is written under a
+// temporary name (the final name followed by .partial) and only takes its
+// final name when it is committed, so a file with the final name is always
+// complete.
+type OutputFile struct {
+  fname string          // final name of the file
+  file *os.File
+  writer *bufio.Writer
+  interval time.Duration
+  lastFlush time.Time
+  closed bool
+}
+
+// Create an output file that will be given the specified name when it is
+// committed
+func CreateOutputFile(fname string) (*OutputFile, error) {
+  file, err := os.Create(fname + PARTIAL_SUFFIX)
+  if (err != nil) {
+    return nil, err
+  }
+  return &OutputFile { fname: fname, file: file, writer: bufio.NewWriter(file),
+                       interval: FLUSH_INTERVAL, lastFlush: time.Now() }, nil
+}
+
+// Set how often the file is flushed.  An interval of zero flushes every
+// write.
+func (self *OutputFile) SetFlushInterval(interval time.Duration) {
+  self.interval = interval
+}
+
+// Write to the file, flushing it if the flush interval has passed.  Output
+// is only flushed after a complete write so each line written with a single
+// call reaches the disk whole.
+func (self *OutputFile) Write(p []byte) (int, error) {
+  n, err := self.writer.Write(p)
+  if ((err == nil) && (time.Since(self.lastFlush) >= self.interval)) {
+    err = self.Flush()
+  }
+  return n, err
+}
+
+// Write the buffered output to disk
+func (self *OutputFile) Flush() error {
+  self.lastFlush = time.Now()
+  err := self.writer.Flush()
+  if (err != nil) {
+    return err
+  }
+  return self.file.Sync()
+}
+
+// Return the name the file takes when it is committed
+func (self *OutputFile) Name() string {
+  return self.fname
+}
+
+// Flush and close the file and give it its final name
+func (self *OutputFile) Commit() error {
+  if (self.closed) {
+    return os.ErrClosed
+  }
+  err := self.Close()
+  if (err != nil) {
+    return err
+  }
+  return os.Rename(self.fname + PARTIAL_SUFFIX, self.fname)
+}
+
+// Flush and close the file without committing it, leaving the output under
+// its temporary name.  Closing a file that has been committed or closed does
+// nothing, so Close can be deferred.
+func (self *OutputFile) Close() error {
+  if (self.closed) {
+    return nil
+  }
+  self.closed = true
+  err := self.Flush()
+  cerr := self.file.Close()
+  if (err != nil) {
+    return err
+  }
+  return cerr
+}
+
+// Write a file under a temporary name and then rename it so that readers
+// never see a partly written file
+func WriteFileAtomic(fname string, write func(w *bufio.Writer) error) error {
+  tmpname := fname + PARTIAL_SUFFIX
+  file, err := os.Create(tmpname)
+  if (err != nil) {
+    return err
+  }
+  w := bufio.NewWriter(file)
+  err = write(w)
+  if (err == nil) {
+    err = w.Flush()
+  }
+  if (err == nil) {
+    err = file.Sync()
+  }
+  cerr := file.Close()
+  if (err == nil) {
+    err = cerr
+  }
+  if (err != nil) {
+    os.Remove(tmpname)
+    return err
+  }
+  return os.Rename(tmpname, fname)
+}
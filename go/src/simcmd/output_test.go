@@ -0,0 +1,109 @@
+package simcmd
+
+import "testing"
+import "testutil"
+import "bufio"
+import "bytes"
+import "encoding/json"
+import "flag"
+import "fmt"
+import "os"
+import "path"
+
+// return true if the named file exists
+func fileExists(fname string) bool {
+  _, err := os.Stat(fname)
+  return (err == nil)
+}
+
+func TestOutputFile(u *testing.T) {
+  fname := path.Join(u.TempDir(), "stats.csv")
+  ofile, err := CreateOutputFile(fname)
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertTrue(u, ofile.Name() == fname)
+  // the file only takes its final name when it is committed
+  testutil.AssertTrue(u, fileExists(fname + PARTIAL_SUFFIX))
+  testutil.AssertFalse(u, fileExists(fname))
+
+  // output reaches the disk once the flush interval has passed
+  fmt.Fprintf(ofile, "gen\n")
+  b, _ := os.ReadFile(fname + PARTIAL_SUFFIX)
+  testutil.AssertIntEqual(u, len(b), 0)
+  ofile.SetFlushInterval(0)
+  fmt.Fprintf(ofile, "0\n")
+  b, _ = os.ReadFile(fname + PARTIAL_SUFFIX)
+  testutil.AssertTrue(u, string(b) == "gen\n0\n")
+
+  testutil.AssertTrue(u, ofile.Commit() == nil)
+  testutil.AssertTrue(u, fileExists(fname))
+  testutil.AssertFalse(u, fileExists(fname + PARTIAL_SUFFIX))
+  // closing a committed file does nothing
+  testutil.AssertTrue(u, ofile.Close() == nil)
+  testutil.AssertFalse(u, ofile.Commit() == nil)
+}
+
+func TestOutputFileClose(u *testing.T) {
+  fname := path.Join(u.TempDir(), "stats.csv")
+  ofile, err := CreateOutputFile(fname)
+  testutil.AssertTrue(u, err == nil)
+  fmt.Fprintf(ofile, "gen\n")
+  // a file that isn't committed keeps its output under the temporary name
+  testutil.AssertTrue(u, ofile.Close() == nil)
+  testutil.AssertFalse(u, fileExists(fname))
+  b, _ := os.ReadFile(fname + PARTIAL_SUFFIX)
+  testutil.AssertTrue(u, string(b) == "gen\n")
+}
+
+func TestWriteFileAtomic(u *testing.T) {
+  fname := path.Join(u.TempDir(), "summary.json")
+  err := WriteFileAtomic(fname, func(w *bufio.Writer) error {
+    _, err := fmt.Fprintf(w, "{}\n")
+    return err
+  })
+  testutil.AssertTrue(u, err == nil)
+  b, _ := os.ReadFile(fname)
+  testutil.AssertTrue(u, string(b) == "{}\n")
+
+  // a failed write leaves the existing file alone
+  err = WriteFileAtomic(fname, func(w *bufio.Writer) error {
+    fmt.Fprintf(w, "{")
+    return os.ErrInvalid
+  })
+  testutil.AssertTrue(u, err == os.ErrInvalid)
+  b, _ = os.ReadFile(fname)
+  testutil.AssertTrue(u, string(b) == "{}\n")
+  testutil.AssertFalse(u, fileExists(fname + PARTIAL_SUFFIX))
+}
+
+func TestRunGPGGOutputError(u *testing.T) {
+  flags := flag.NewFlagSet("test", flag.ContinueOnError)
+  gf := RegisterGPGGFlags(flags)
+  testutil.AssertTrue(u, flags.Parse([]string{ "-s", "2", "-g", "3", "-a", "20" }) == nil)
+  e, err := gf.Check()
+  testutil.AssertTrue(u, err == nil)
+
+  // the simulation directory doesn't exist
+  out, _, err := RunGPGGSimulation(path.Join(u.TempDir(), "missing"), 1, e.Params, nil, nil)
+  testutil.AssertFalse(u, err == nil)
+  testutil.AssertTrue(u, out == "")
+
+  // the degree histogram can't be created
+  dname := u.TempDir()
+  testutil.AssertTrue(u, os.Mkdir(path.Join(dname, "dhist.csv" + PARTIAL_SUFFIX), 0755) == nil)
+  out, _, err = RunGPGGSimulation(dname, 1, e.Params, nil, nil)
+  testutil.AssertFalse(u, err == nil)
+  testutil.AssertTrue(u, out == "")
+  testutil.AssertFalse(u, fileExists(path.Join(dname, "pstat.csv")))
+
+  // the experiment reports the error and leaves the simulation out of its
+  // results
+  dname = u.TempDir()
+  simdnames := []string{ path.Join(dname, "missing"), dname }
+  var buf bytes.Buffer
+  numRun, err := RunGPGGExperiment(dname, simdnames, 1, e, &buf, nil, nil)
+  testutil.AssertFalse(u, err == nil)
+  testutil.AssertIntEqual(u, numRun, 1)
+  var results []interface{}
+  testutil.AssertTrue(u, json.Unmarshal(buf.Bytes(), &results) == nil)
+  testutil.AssertIntEqual(u, len(results), 1)
+}
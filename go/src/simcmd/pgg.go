@@ -0,0 +1,182 @@
+package simcmd
+
+import "flag"
+import "fmt"
+import "io"
+import "math/rand"
+import "simbase"
+import "simpgg"
+import "time"
+
+// The flags that set the parameters of a tribal public goods game simulation
+type PGGFlags struct {
+  Gens, Gsize, Rounds, NumTribes, NumAgents *int
+  Cost, Mult, Fine, Pcost, Reward, Rcost, Spfine, Spcost *float64
+  Beta, Eta, Pcon, Pmig, Passmut, Pactmut, Passerr, Pexeerr *float64
+}
+
+// Add the flags for the parameters of a tribal public goods game simulation
+// to a flag set
+func RegisterPGGFlags(flags *flag.FlagSet) *PGGFlags {
+  return &PGGFlags {
+    Gens:      flags.Int(simbase.GENS_F, simbase.NUMGENS, "number of generations to simulate"),
+    Cost:      flags.Float64(simbase.COST_F, simbase.COST, "contribution c made by a contributor"),
+    Mult:      flags.Float64(simpgg.MULT_F, simpgg.MULT, "contribution multiplier (r)"),
+    Fine:      flags.Float64(simpgg.FINE_F, simpgg.FINE, "fine paid by a non-contributor to each punisher"),
+    Pcost:     flags.Float64(simpgg.PCOST_F, simpgg.PCOST, "cost paid by a punisher for each non-contributor punished"),
+    Reward:    flags.Float64(simpgg.REWARD_F, simpgg.REWARD, "reward received by a contributor from each rewarder"),
+    Rcost:     flags.Float64(simpgg.RCOST_F, simpgg.RCOST, "cost paid by a rewarder for each contributor rewarded"),
+    Spfine:    flags.Float64(simpgg.SPFINE_F, simpgg.SPFINE, "fine paid by a non-punisher to each second-order punisher"),
+    Spcost:    flags.Float64(simpgg.SPCOST_F, simpgg.SPCOST, "cost paid by a second-order punisher for each non-punisher punished"),
+    Gsize:     flags.Int(simpgg.GSIZE_F, simpgg.GSIZE, "number of agents in each group"),
+    Rounds:    flags.Int(simpgg.ROUNDS_F, simpgg.ROUNDS, "number of rounds played by each tribe per generation"),
+    NumTribes: flags.Int(simbase.TRIBES_F, simbase.NUMTRIBES, "number of tribes"),
+    NumAgents: flags.Int(simbase.AGENTS_F, simbase.NUMAGENTS, "number of agents"),
+    Beta:      flags.Float64(simbase.BETA_F, simbase.BETA, "conflict selection strength"),
+    Eta:       flags.Float64(simbase.ETA_F, simbase.ETA, "bit switch selection strength"),
+    Pcon:      flags.Float64(simbase.PCON_F, simbase.PCON, "conflict probability"),
+    Pmig:      flags.Float64(simbase.PMIG_F, simbase.PMIG, "migration probability"),
+    Passmut:   flags.Float64(simbase.PASSM_F, simbase.PASSMUT, "assess module bit mutation probability"),
+    Pactmut:   flags.Float64(simbase.PACTM_F, simbase.PACTMUT, "action module bit mutation probability"),
+    Passerr:   flags.Float64(simbase.PASSE_F, simbase.PASSERR, "assessment error probability"),
+    Pexeerr:   flags.Float64(simbase.PEXEE_F, simbase.PEXEERR, "execution error probability"),
+  }
+}
+
+// Return the parameter map set by the flags or an error describing the
+// first invalid parameter
+func (self *PGGFlags) Check() (map[string]float64, error) {
+  params := make(map[string]float64)
+  params[simbase.COST_F]  = *self.Cost
+  params[simpgg.MULT_F]   = *self.Mult
+  params[simpgg.FINE_F]   = *self.Fine
+  params[simpgg.PCOST_F]  = *self.Pcost
+  params[simpgg.REWARD_F] = *self.Reward
+  params[simpgg.RCOST_F]  = *self.Rcost
+  params[simpgg.SPFINE_F] = *self.Spfine
+  params[simpgg.SPCOST_F] = *self.Spcost
+  params[simpgg.GSIZE_F]  = float64(*self.Gsize)
+  params[simpgg.ROUNDS_F] = float64(*self.Rounds)
+  params[simbase.BETA_F]  = *self.Beta
+  params[simbase.ETA_F]   = *self.Eta
+  params[simbase.PCON_F]  = *self.Pcon
+  params[simbase.PMIG_F]  = *self.Pmig
+  params[simbase.PASSM_F] = *self.Passmut
+  params[simbase.PACTM_F] = *self.Pactmut
+  params[simbase.PASSE_F] = *self.Passerr
+  params[simbase.PEXEE_F] = *self.Pexeerr
+
+  err := simpgg.CheckParams(*self.NumTribes, *self.NumAgents, params)
+  if ((err == nil) && (*self.Gens < 0)) {
+    err = fmt.Errorf("-%s: number of generations must not be negative", simbase.GENS_F)
+  }
+  return params, err
+}
+
+// Simulate the specified number of generations and write the statistics of
+// each generation.  The simulation stops early if stop is not nil and
+// returns true after a generation.  Returns the number of generations
+// completed.  The statistics are also published to the monitor unless it is
+// nil.
+func RunPGG(s *simpgg.SimEngine, gens int, numTribes int, numAgents int,
+            w io.Writer, stop func() bool, mon *Monitor) int {
+  for g := 0; g < gens; g++ {
+    nextGen := s.PlayRounds()
+    stats := s.GetStats()
+    p := s.GetTotalPayouts()
+    WritePGGStats(w, g, numTribes, numAgents, stats, p)
+    if (mon != nil) {
+      mon.Publish(GenUpdate { Gen: g, Payouts: &p, Assess: stats.Assess[:],
+                              Actions: stats.Action[:], Coop: &stats.Contrib })
+    }
+    s.EvolveTribes(nextGen)
+    s.Reset()
+    if ((stop != nil) && stop()) {
+      mon.PublishFinal(0)
+      return g + 1
+    }
+  }
+  mon.PublishFinal(0)
+  return gens
+}
+
+func WritePGGHeader(w io.Writer) {
+  fmt.Fprintf(w, "gen,t,a,n0,n1,n2,n3,n4,n5,n6,n7,c0,c1,c2,c3,p0,p1,p2,p3,r0,r1,r2,r3,s0,s1,s2,s3,fc,fp,fr,fs,fg,po\n")
+}
+
+func WritePGGStats(w io.Writer, gen int, numTribes int, numAgents int,
+                   stats simpgg.Stats, p float64) {
+  n := stats.Assess
+  a := stats.Action
+  fmt.Fprintf(w, "%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%.4f,%.4f,%.4f,%.4f,%.4f,%.3f\n",
+                 gen, numTribes, numAgents,
+                 n[0], n[1], n[2], n[3], n[4], n[5], n[6], n[7],
+                 a[0], a[1], a[2], a[3], a[4], a[5], a[6], a[7],
+                 a[8], a[9], a[10], a[11], a[12], a[13], a[14], a[15],
+                 stats.Contrib, stats.Punish, stats.Reward, stats.SPunish, stats.GoodRep, p)
+}
+
+func WritePGGSimParams(s *simpgg.SimEngine, gens int, seed int64, fname string) {
+  // output simulation parameters
+  fmt.Println("{")
+  fmt.Printf("  \"simtype\":\"PGG\",\n")
+  fmt.Printf("  \"ngens\":%d,\n", gens)
+  fmt.Printf("  \"seed\":%d,\n", seed)
+  fmt.Printf("  \"ofile\":\"%s\",\n", fname)
+  s.WriteSimParams()
+  fmt.Println("}")
+}
+
+// The "pgg run" subcommand: run a tribal public goods game simulation
+func PGGRun(args []string) error {
+  flags, rf := NewRunFlagSet("pgg run", "pggdata")
+  pf := RegisterPGGFlags(flags)
+  err := ParseFlags(flags, args)
+  if (err != nil) {
+    return err
+  }
+  params, err := pf.Check()
+  if (err != nil) {
+    return InvalidParams(err)
+  }
+  m, err := StartRun("pgg run", args, flags, rf)
+  if (err != nil) {
+    return err
+  }
+  mon, err := StartMonitor(*rf.HTTP, "pgg run", flags)
+  if (err != nil) {
+    return m.Finish(err)
+  }
+  defer mon.Close()
+  return m.Finish(runPGG(m, params, *rf.Seed, *pf.Gens, *pf.NumTribes, *pf.NumAgents, mon))
+}
+
+// run the simulation of the "pgg run" subcommand
+func runPGG(m *Manifest, params map[string]float64, seed int64, gens int, numTribes int, numAgents int,
+            mon *Monitor) error {
+  ofile, err := CreateOutputFile(m.AddFile("stats.csv"))
+  if (err != nil) {
+    return err
+  }
+  defer ofile.Close()
+  WritePGGHeader(ofile)
+
+  stop := NotifyInterrupt()
+  defer stop.Stop()
+  start := time.Now()
+  s := simpgg.NewSimEngineWithRNG(numTribes, numAgents, params, rand.New(rand.NewSource(seed)))
+  fmt.Println("[")
+  WritePGGSimParams(s, gens, seed, ofile.Name())
+  fmt.Println(",")
+  done := RunPGG(s, gens, numTribes, numAgents, ofile, stop.Requested, mon)
+  end := time.Now()
+  WriteRuntime(end.Sub(start), stop.Requested())
+  fmt.Println("]")
+
+  m.Progress = fmt.Sprintf("%d of %d generations", done, gens)
+  err = ofile.Commit()
+  if (err != nil) {
+    return err
+  }
+  return stop.Err()
+}
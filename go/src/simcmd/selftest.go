@@ -0,0 +1,122 @@
+package simcmd
+
+import "flag"
+import "fmt"
+import "os"
+import "path"
+
+// a short run of a command that selftest checks
+type selfTestRun struct {
+  name string    // name of the output directory
+  args []string  // command line without -o
+}
+
+// the runs made by selftest
+var selfTestRuns = []selfTestRun {
+  { "ir", []string{ "ir", "run", "-g", "5", "-t", "4", "-a", "4", "-seed", "1" } },
+  { "irpd", []string{ "ir", "run", "-g", "5", "-t", "4", "-a", "4", "-game", "1", "-am", "-seed", "1" } },
+  { "pgg", []string{ "pgg", "run", "-g", "5", "-t", "4", "-a", "5", "-seed", "1" } },
+  { "gpgg", []string{ "gpgg", "run", "-s", "2", "-g", "5", "-a", "20", "-j", "1", "-seed", "1" } },
+  { "gpggng", []string{ "gpgg", "run", "-ng", "1", "-spg", "2", "-g", "5", "-a", "20", "-rep", "-seed", "1" } },
+  { "sweep", []string{ "sweep", "-p", "beta=0.5,1", "-seed", "1", "ir", "run", "-g", "2", "-t", "2", "-a", "4" } },
+}
+
+// Check that a run completed and that the files in its manifest exist
+func CheckRun(dname string) error {
+  m, err := ReadManifest(dname)
+  if (err != nil) {
+    return err
+  }
+  if (m.Status != STATUS_COMPLETE) {
+    return fmt.Errorf("%s: status is %s", dname, m.Status)
+  }
+  for _, fname := range append([]string{ m.Config }, m.Files...) {
+    info, err := os.Stat(path.Join(dname, fname))
+    if (err != nil) {
+      return err
+    }
+    if (info.Size() == 0) {
+      return fmt.Errorf("%s: file is empty", path.Join(dname, fname))
+    }
+  }
+  for _, run := range m.Runs {
+    err = CheckRun(path.Join(dname, run))
+    if (err != nil) {
+      return err
+    }
+  }
+  return nil
+}
+
+// return the command line with -o inserted after the words of the command
+// (a sweep passes the flags that follow the swept command to it)
+func withOutDir(args []string, dname string) []string {
+  _, cargs, _ := findCommand(args)
+  rval := append([]string(nil), args[:len(args)-len(cargs)]...)
+  rval = append(rval, "-" + OUTDIR_F, dname)
+  return append(rval, cargs...)
+}
+
+// run a command with its standard output discarded
+func runQuietly(args []string) error {
+  stdout := os.Stdout
+  devnull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+  if (err != nil) {
+    return err
+  }
+  defer devnull.Close()
+  os.Stdout = devnull
+  defer func() { os.Stdout = stdout }()
+  return Run(args)
+}
+
+// The "selftest" subcommand: run each command briefly in a temporary
+// directory and check its output
+func SelfTest(args []string) error {
+  flags := flag.NewFlagSet("selftest", flag.ContinueOnError)
+  keep := flags.Bool("keep", false, "keep the output of the runs")
+  err := flags.Parse(args)
+  if (err != nil) {
+    return &usageError { err: err }
+  }
+  if (flags.NArg() > 0) {
+    return InvalidParams(fmt.Errorf("unexpected argument: %s", flags.Arg(0)))
+  }
+
+  dname, err := os.MkdirTemp("", "simtool-selftest")
+  if (err != nil) {
+    return err
+  }
+  if (*keep) {
+    fmt.Printf("output in %s\n", dname)
+  } else {
+    defer os.RemoveAll(dname)
+  }
+
+  failed := 0
+  report := func(name string, err error) {
+    if (err != nil) {
+      fmt.Printf("FAIL %-8s %v\n", name, err)
+      failed++
+    } else {
+      fmt.Printf("ok   %-8s\n", name)
+    }
+  }
+  var dnames []string
+  for _, r := range selfTestRuns {
+    rdname := path.Join(dname, r.name)
+    err := runQuietly(withOutDir(r.args, rdname))
+    if (err == nil) {
+      err = CheckRun(rdname)
+      dnames = append(dnames, rdname)
+    }
+    report(r.name, err)
+  }
+  report("analyze", runQuietly(append([]string{ "analyze" }, dnames...)))
+  report("chart", runQuietly(append([]string{ "chart" }, dnames...)))
+
+  if (failed > 0) {
+    return fmt.Errorf("%d of %d checks failed", failed, len(selfTestRuns)+2)
+  }
+  return nil
+}
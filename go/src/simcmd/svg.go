@@ -0,0 +1,268 @@
+package simcmd
+
+import "fmt"
+import "html"
+import "io"
+import "math"
+import "strconv"
+import "strings"
+
+// size of a chart in pixels
+const (
+ CHART_WIDTH = 800
+ CHART_HEIGHT = 400
+)
+
+// margins around the plot area of a chart
+const (
+ chartMarginLeft = 70
+ chartMarginRight = 20
+ chartMarginTop = 40
+ chartMarginBottom = 50
+ chartLegendWidth = 110 // width of the legend to the right of the plot
+ chartLegendLine = 16   // height of a legend entry
+)
+
+// approximate number of ticks on a linear axis
+const chartTicks = 5
+
+// colors of the series in the order they are used (the first eight match
+// the colors of the assessment bits in python/lineplot.py)
+var chartColors = []string {
+  "#0000ff", "#008000", "#ff0000", "#00bfbf", "#bf00bf", "#bfbf00", "#000000", "#bfbfbf",
+  "#ff7f0e", "#8c564b", "#e377c2", "#17becf", "#9467bd", "#2ca02c", "#1f77b4", "#7f7f7f",
+}
+
+// A line of a chart.  Points with a NaN coordinate, or with a coordinate
+// that isn't positive on a log axis, are left out and break the line.
+type Series struct {
+  Name string
+  X []float64
+  Y []float64
+  Markers bool // draw a marker at each point
+}
+
+// A line chart written as SVG
+type LineChart struct {
+  Title string
+  XLabel string
+  YLabel string
+  LogX bool
+  LogY bool
+  YMin float64 // fixed range of the y axis, used if YMin < YMax
+  YMax float64 // -- the range is taken from the data otherwise
+  Series []Series
+}
+
+// An axis of a chart.  The range and ticks of a log axis are powers of 10.
+type chartAxis struct {
+  log bool
+  min float64     // range of the axis (log10 of the range for a log axis)
+  max float64
+  ticks []float64 // values at the ticks (not log10)
+}
+
+// return the value a point is plotted at on an axis, and false if the value
+// can't be plotted on it
+func (self *chartAxis) scale(v float64) (float64, bool) {
+  if (math.IsNaN(v) || math.IsInf(v, 0)) {
+    return 0, false
+  }
+  if (self.log) {
+    if (v <= 0) {
+      return 0, false
+    }
+    v = math.Log10(v)
+  }
+  return (v - self.min) / (self.max - self.min), true
+}
+
+// return a step of 1, 2 or 5 times a power of 10 that divides the range
+// into about n intervals
+func niceStep(span float64, n int) float64 {
+  raw := span / float64(n)
+  mag := math.Pow(10, math.Floor(math.Log10(raw)))
+  for _, f := range []float64{ 1, 2, 5 } {
+    if (raw <= f * mag) {
+      return f * mag
+    }
+  }
+  return 10 * mag
+}
+
+// Return an axis that covers the values from lo to hi.  A linear axis is
+// extended to the nearest ticks.  A log axis is extended to the nearest
+// powers of 10 and lo must be positive.
+func newChartAxis(lo float64, hi float64, log bool) chartAxis {
+  self := chartAxis { log: log }
+  if (log) {
+    self.min = math.Floor(math.Log10(lo))
+    self.max = math.Ceil(math.Log10(hi))
+    if (self.max <= self.min) {
+      self.max = self.min + 1
+    }
+    // -- a long range has a tick every few powers of 10
+    step := math.Ceil((self.max - self.min) / 10)
+    for e := self.min; e <= self.max; e += step {
+      self.ticks = append(self.ticks, math.Pow(10, e))
+    }
+    return self
+  }
+  if (hi <= lo) {
+    // -- a constant series is plotted in the middle, but a non-negative
+    //    one stays on a non-negative axis
+    if ((lo >= 0) && (lo < 1)) {
+      lo, hi = 0, hi + 1
+    } else {
+      lo, hi = lo - 1, hi + 1
+    }
+  }
+  step := niceStep(hi - lo, chartTicks)
+  self.min = math.Floor(lo / step) * step
+  self.max = math.Ceil(hi / step) * step
+  for i := 0; self.min + float64(i) * step <= self.max + step / 2; i++ {
+    t := self.min + float64(i) * step
+    // -- avoid labels such as 0.30000000000000004
+    t, _ = strconv.ParseFloat(strconv.FormatFloat(t, 'g', 12, 64), 64)
+    self.ticks = append(self.ticks, t)
+  }
+  return self
+}
+
+// return the range of the values that can be plotted on an axis, and false
+// if there are none
+func dataRange(values [][]float64, log bool) (float64, float64, bool) {
+  lo, hi := math.Inf(1), math.Inf(-1)
+  for _, vs := range values {
+    for _, v := range vs {
+      if (math.IsNaN(v) || math.IsInf(v, 0) || (log && (v <= 0))) {
+        continue
+      }
+      lo = math.Min(lo, v)
+      hi = math.Max(hi, v)
+    }
+  }
+  return lo, hi, (lo <= hi)
+}
+
+// return the axes of a chart
+func (self *LineChart) axes() (chartAxis, chartAxis) {
+  var xs, ys [][]float64
+  for _, s := range self.Series {
+    xs = append(xs, s.X)
+    ys = append(ys, s.Y)
+  }
+  xlo, xhi, ok := dataRange(xs, self.LogX)
+  if (!ok) {
+    xlo, xhi = 1, 10
+  }
+  ylo, yhi, ok := dataRange(ys, self.LogY)
+  if (!ok) {
+    ylo, yhi = 1, 10
+  }
+  if ((self.YMin < self.YMax) && (!self.LogY || (self.YMin > 0))) {
+    ylo, yhi = self.YMin, self.YMax
+  }
+  return newChartAxis(xlo, xhi, self.LogX), newChartAxis(ylo, yhi, self.LogY)
+}
+
+// format the value at a tick
+func tickLabel(v float64) string {
+  return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+// Write the chart as an SVG document
+func (self *LineChart) WriteSVG(w io.Writer) error {
+  var b strings.Builder
+  width, height := CHART_WIDTH, CHART_HEIGHT
+  left, top := float64(chartMarginLeft), float64(chartMarginTop)
+  pw := float64(width - chartMarginLeft - chartMarginRight - chartLegendWidth)
+  ph := float64(height - chartMarginTop - chartMarginBottom)
+  xaxis, yaxis := self.axes()
+  px := func(x float64) float64 { return left + x * pw }
+  py := func(y float64) float64 { return top + (1 - y) * ph }
+
+  fmt.Fprintf(&b, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+  fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"sans-serif\" font-size=\"11\">\n",
+              width, height, width, height)
+  fmt.Fprintf(&b, "<rect width=\"%d\" height=\"%d\" fill=\"white\"/>\n", width, height)
+  fmt.Fprintf(&b, "<text x=\"%.1f\" y=\"%d\" text-anchor=\"middle\" font-size=\"14\">%s</text>\n",
+              left + pw / 2, chartMarginTop / 2 + 5, html.EscapeString(self.Title))
+
+  // axes, ticks and grid lines
+  fmt.Fprintf(&b, "<g stroke=\"#e0e0e0\">\n")
+  for _, t := range xaxis.ticks {
+    x, _ := xaxis.scale(t)
+    fmt.Fprintf(&b, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\"/>\n", px(x), top, px(x), top + ph)
+  }
+  for _, t := range yaxis.ticks {
+    y, _ := yaxis.scale(t)
+    fmt.Fprintf(&b, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\"/>\n", left, py(y), left + pw, py(y))
+  }
+  fmt.Fprintf(&b, "</g>\n")
+  fmt.Fprintf(&b, "<rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"none\" stroke=\"black\"/>\n",
+              left, top, pw, ph)
+  fmt.Fprintf(&b, "<g text-anchor=\"middle\">\n")
+  for _, t := range xaxis.ticks {
+    x, _ := xaxis.scale(t)
+    fmt.Fprintf(&b, "<text x=\"%.1f\" y=\"%.1f\">%s</text>\n", px(x), top + ph + 15, tickLabel(t))
+  }
+  fmt.Fprintf(&b, "<text x=\"%.1f\" y=\"%.1f\">%s</text>\n", left + pw / 2, top + ph + 35, html.EscapeString(self.XLabel))
+  fmt.Fprintf(&b, "</g>\n")
+  fmt.Fprintf(&b, "<g text-anchor=\"end\">\n")
+  for _, t := range yaxis.ticks {
+    y, _ := yaxis.scale(t)
+    fmt.Fprintf(&b, "<text x=\"%.1f\" y=\"%.1f\">%s</text>\n", left - 5, py(y) + 4, tickLabel(t))
+  }
+  fmt.Fprintf(&b, "</g>\n")
+  fmt.Fprintf(&b, "<text transform=\"translate(%d,%.1f) rotate(-90)\" text-anchor=\"middle\">%s</text>\n",
+              chartMarginLeft / 4, top + ph / 2, html.EscapeString(self.YLabel))
+
+  // series, clipped to the plot area
+  fmt.Fprintf(&b, "<clipPath id=\"plot\"><rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\"/></clipPath>\n",
+              left, top, pw, ph)
+  fmt.Fprintf(&b, "<g clip-path=\"url(#plot)\" fill=\"none\" stroke-width=\"1\">\n")
+  for i, s := range self.Series {
+    color := chartColors[i % len(chartColors)]
+    var path strings.Builder
+    var markers strings.Builder
+    move := true
+    for j := 0; (j < len(s.X)) && (j < len(s.Y)); j++ {
+      x, xok := xaxis.scale(s.X[j])
+      y, yok := yaxis.scale(s.Y[j])
+      if (!xok || !yok) {
+        move = true
+        continue
+      }
+      if (move) {
+        fmt.Fprintf(&path, "M%.1f %.1f", px(x), py(y))
+        move = false
+      } else {
+        fmt.Fprintf(&path, "L%.1f %.1f", px(x), py(y))
+      }
+      if (s.Markers) {
+        fmt.Fprintf(&markers, "<circle cx=\"%.1f\" cy=\"%.1f\" r=\"2.5\"/>", px(x), py(y))
+      }
+    }
+    if (path.Len() > 0) {
+      fmt.Fprintf(&b, "<path stroke=\"%s\" d=\"%s\"/>\n", color, path.String())
+    }
+    if (markers.Len() > 0) {
+      fmt.Fprintf(&b, "<g fill=\"%s\" stroke=\"none\">%s</g>\n", color, markers.String())
+    }
+  }
+  fmt.Fprintf(&b, "</g>\n")
+
+  // legend
+  lx := left + pw + 15
+  for i, s := range self.Series {
+    y := top + 5 + float64(i * chartLegendLine)
+    fmt.Fprintf(&b, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"%s\" stroke-width=\"2\"/>\n",
+                lx, y, lx + 20, y, chartColors[i % len(chartColors)])
+    fmt.Fprintf(&b, "<text x=\"%.1f\" y=\"%.1f\">%s</text>\n", lx + 25, y + 4, html.EscapeString(s.Name))
+  }
+  fmt.Fprintf(&b, "</svg>\n")
+
+  _, err := io.WriteString(w, b.String())
+  return err
+}
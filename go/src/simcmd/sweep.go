@@ -0,0 +1,166 @@
+package simcmd
+
+import "fmt"
+import "math/rand"
+import "path"
+import "simbase"
+import "strconv"
+import "strings"
+
+// flag for a swept parameter
+const PARAM_F = "p"
+
+// A parameter and the values it takes in a sweep
+type SweepParam struct {
+  Name string
+  Values []string
+}
+
+// The swept parameters set by repeated -p flags
+type SweepParams []SweepParam
+
+func (self *SweepParams) String() string {
+  s := make([]string, len(*self))
+  for i, p := range *self {
+    s[i] = p.Name + "=" + strings.Join(p.Values, ",")
+  }
+  return strings.Join(s, " ")
+}
+
+// Add a parameter given as name=v1,v2,...  Several parameters can be
+// separated by spaces, as they are by String.
+func (self *SweepParams) Set(value string) error {
+  for _, spec := range strings.Fields(value) {
+    eq := strings.Index(spec, "=")
+    if (eq < 1) {
+      return fmt.Errorf("expected name=v1,v2,...: %s", spec)
+    }
+    p := SweepParam { Name: strings.TrimPrefix(spec[:eq], "-"), Values: strings.Split(spec[eq+1:], ",") }
+    for _, v := range p.Values {
+      if (v == "") {
+        return fmt.Errorf("empty value for %s", p.Name)
+      }
+    }
+    for _, q := range *self {
+      if (q.Name == p.Name) {
+        return fmt.Errorf("%s is swept more than once", p.Name)
+      }
+    }
+    *self = append(*self, p)
+  }
+  return nil
+}
+
+// Return every combination of the parameter values as flag arguments.  The
+// last parameter varies fastest.
+func (self SweepParams) Combinations() [][]string {
+  combos := [][]string{ nil }
+  for _, p := range self {
+    var next [][]string
+    for _, c := range combos {
+      for _, v := range p.Values {
+        combo := append(append([]string(nil), c...), "-" + p.Name, v)
+        next = append(next, combo)
+      }
+    }
+    combos = next
+  }
+  return combos
+}
+
+// Return the name of the output directory of a run from its swept flag
+// arguments (e.g. -r 3 -z 4 -> r3_z4)
+func SweepDirName(combo []string) string {
+  var parts []string
+  for i := 0; i+1 < len(combo); i += 2 {
+    parts = append(parts, strings.TrimPrefix(combo[i], "-") + strings.ReplaceAll(combo[i+1], "/", "_"))
+  }
+  if (len(parts) == 0) {
+    return "run"
+  }
+  return strings.Join(parts, "_")
+}
+
+// return true if the flag is set in the arguments
+func hasFlag(args []string, name string) bool {
+  for _, arg := range args {
+    if (arg == "--") {
+      return false
+    }
+    arg = strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+    if ((arg == name) || strings.HasPrefix(arg, name + "=")) {
+      return true
+    }
+  }
+  return false
+}
+
+// The "sweep" subcommand: run a simulation command once for every
+// combination of the swept parameter values.  Each run writes to its own
+// directory within the output directory of the sweep.  Unless the command
+// sets -seed, each run is given a seed generated from the seed of the sweep
+// so that the sweep can be repeated.  The address set by -http is passed on
+// to each run.  The sweep stops if a run is interrupted.
+func Sweep(args []string) error {
+  flags, rf := NewRunFlagSet("sweep", "sweepdata")
+  var params SweepParams
+  flags.Var(&params, PARAM_F, "swept parameter as name=v1,v2,... (repeat for each parameter)")
+  flags.Usage = func() {
+    fmt.Fprintf(flags.Output(), "usage: simtool sweep [flags] <command> [command flags]\n")
+    flags.PrintDefaults()
+  }
+  err := flags.Parse(args)
+  if (err != nil) {
+    return &usageError { err: err }
+  }
+  err = simbase.LoadConfigFlag(flags)
+  if (err != nil) {
+    return InvalidParams(err)
+  }
+  c, runArgs, ok := findCommand(flags.Args())
+  switch {
+  case (!ok):
+    return InvalidParams(fmt.Errorf("expected a command to sweep, e.g. ir run"))
+  case (!c.isRun):
+    return InvalidParams(fmt.Errorf("cannot sweep %s", c.name))
+  case (len(params) == 0):
+    return InvalidParams(fmt.Errorf("-%s: no parameters to sweep", PARAM_F))
+  case hasFlag(runArgs, OUTDIR_F):
+    return InvalidParams(fmt.Errorf("-%s: the output directories of the runs are set by the sweep", OUTDIR_F))
+  }
+
+  m, err := StartRun("sweep", args, flags, rf)
+  if (err != nil) {
+    return err
+  }
+  seedGen := rand.New(rand.NewSource(*rf.Seed))
+  combos := params.Combinations()
+  for i, combo := range combos {
+    dname := SweepDirName(combo)
+    m.Runs = append(m.Runs, dname)
+    cargs := append(strings.Fields(c.name), runArgs...)
+    cargs = append(cargs, combo...)
+    cargs = append(cargs, "-" + OUTDIR_F, path.Join(*rf.OutDir, dname))
+    seed := seedGen.Int63()
+    if (!hasFlag(runArgs, SEED_F)) {
+      cargs = append(cargs, "-" + SEED_F, strconv.FormatInt(seed, 10))
+    }
+    if (*rf.Overwrite) {
+      cargs = append(cargs, "-" + OVERWRITE_F)
+    }
+    if ((*rf.HTTP != "") && !hasFlag(runArgs, HTTP_F)) {
+      cargs = append(cargs, "-" + HTTP_F, *rf.HTTP)
+    }
+    err = Run(cargs)
+    if (err != nil) {
+      return m.Finish(fmt.Errorf("%s: %w", dname, err))
+    }
+    // record the progress of the sweep
+    m.Progress = fmt.Sprintf("%d of %d runs", i + 1, len(combos))
+    err = m.Write()
+    if (err != nil) {
+      return err
+    }
+  }
+  return m.Finish(nil)
+}
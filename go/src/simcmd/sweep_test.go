@@ -0,0 +1,55 @@
+package simcmd
+
+import "testing"
+import "testutil"
+import "strings"
+
+func TestSweepParams(u *testing.T) {
+  var params SweepParams
+  testutil.AssertTrue(u, params.Set("r=2,3") == nil)
+  testutil.AssertTrue(u, params.Set("-z=4,8,16") == nil)
+  testutil.AssertFalse(u, params.Set("r=5") == nil)
+  testutil.AssertFalse(u, params.Set("beta=") == nil)
+  testutil.AssertFalse(u, params.Set("=1") == nil)
+
+  combos := params.Combinations()
+  testutil.AssertIntEqual(u, len(combos), 6)
+  testutil.AssertTrue(u, strings.Join(combos[0], " ") == "-r 2 -z 4")
+  testutil.AssertTrue(u, strings.Join(combos[1], " ") == "-r 2 -z 8")
+  testutil.AssertTrue(u, strings.Join(combos[5], " ") == "-r 3 -z 16")
+  testutil.AssertTrue(u, SweepDirName(combos[1]) == "r2_z8")
+
+  // the string form sets the same parameters
+  var parsed SweepParams
+  testutil.AssertTrue(u, parsed.Set(params.String()) == nil)
+  testutil.AssertTrue(u, parsed.String() == params.String())
+}
+
+func TestHasFlag(u *testing.T) {
+  testutil.AssertTrue(u, hasFlag([]string{ "-g", "5", "-seed", "3" }, SEED_F))
+  testutil.AssertTrue(u, hasFlag([]string{ "--seed=3" }, SEED_F))
+  testutil.AssertFalse(u, hasFlag([]string{ "-seedx", "3" }, SEED_F))
+  testutil.AssertFalse(u, hasFlag([]string{ "--", "-seed" }, SEED_F))
+}
+
+func TestSweep(u *testing.T) {
+  dname := u.TempDir()
+  err := runQuietly([]string{ "sweep", "-o", dname, "-overwrite", "-p", "beta=0.5,1", "-p", "a=4,6",
+                              "ir", "run", "-g", "2", "-t", "2" })
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertTrue(u, CheckRun(dname) == nil)
+  m, err := ReadManifest(dname)
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertIntEqual(u, len(m.Runs), 4)
+  testutil.AssertTrue(u, m.Runs[3] == "beta1_a6")
+  stats, err := AnalyzeDir(dname, 0)
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertIntEqual(u, len(stats), 4)
+  // -- the swept parameters were used
+  testutil.AssertFloat64Equal(u, stats[3].Means[2], 6)
+
+  // commands that can't be swept
+  testutil.AssertFalse(u, Run([]string{ "sweep", "-p", "g=1,2", "analyze" }) == nil)
+  testutil.AssertFalse(u, Run([]string{ "sweep", "ir", "run" }) == nil)
+  testutil.AssertFalse(u, Run([]string{ "sweep", "-p", "g=1", "ir", "run", "-o", dname }) == nil)
+}
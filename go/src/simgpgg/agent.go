@@ -1,10 +1,28 @@
 package simgpgg
 
+import "math/rand"
+import "simbase"
+import "simpgg"
+
 type Agent struct {
   payouts float64
+  contrib float64 // contributions made in the games played since payouts were reset
   cooperate bool // true for cooperators and false for defectors
+  rep simbase.Rep // reputation of the agent (reputation-driven games only)
+  actMod *simpgg.ActionModule // nil unless the agent plays reputation-driven games
 }
 
 func NewAgent(cooperate bool) *Agent {
   return &Agent { cooperate: cooperate, payouts: 0 }
 }
+
+// Create an agent that uses a randomly generated action module to decide
+// whether to contribute and punish.  By default the agent has a GOOD
+// reputation and is a cooperator if it contributes when it and its group
+// are both GOOD.
+func NewRepAgent(pexeerr float32, rnGen *rand.Rand) *Agent {
+  actMod := simpgg.NewActionModule(RandBool(rnGen), RandBool(rnGen), RandBool(rnGen), RandBool(rnGen),
+                                   RandBool(rnGen), RandBool(rnGen), RandBool(rnGen), RandBool(rnGen),
+                                   pexeerr)
+  return &Agent { cooperate: actMod.GetBit(0) == 1, payouts: 0, rep: simbase.GOOD, actMod: actMod }
+}
@@ -0,0 +1,267 @@
+package simgpgg
+
+import "fmt"
+import "io"
+import "math"
+
+// Outcomes of a simulation
+const (
+  FIXED_COOP int32 = iota   // cooperators took over the population
+  FIXED_DEFECT int32 = iota // defectors took over the population
+  GEN_LIMIT int32 = iota    // the generation limit was reached first
+)
+
+// Summary of the final state of a single simulation
+type SimSummary struct {
+  outcome int32  // how the simulation ended
+  gens int32     // number of generations completed
+  pc float64     // final fraction of cooperators
+  kavg float64   // final average degree
+  ksd float64    // final standard deviation of the degree
+  kmax int       // final maximum degree
+  kavgc float64  // final average degree of cooperators (NaN if none)
+  kavgd float64  // final average degree of defectors (NaN if none)
+  statgens int32     // number of generations measured after the burn in
+  statpc float64     // time averaged fraction of cooperators (NaN if not measured)
+  statkavg float64   // time averaged average degree
+  statksd float64    // time averaged standard deviation of the degree
+  statkavgc float64  // time averaged average degree of cooperators
+  statkavgd float64  // time averaged average degree of defectors
+}
+
+// Summarize the final state of a simulation that completed the specified
+// number of generations along with its stationary statistics
+func (self *SimEngine) Summary(gens int32) SimSummary {
+  s := SimSummary { gens: gens, pc: float64(self.Nc)/float64(self.numAgents) }
+
+  // determine the outcome
+  // -- agents that use action modules have fixed once they all share the
+  //    same module - it is a cooperator module if it contributes when the
+  //    agent and its group are GOOD
+  // -- nothing is fixed for good while agents explore other strategies
+  // -- the strategies of agents that use action modules follow their most
+  //    recent actions, so they can all cooperate before the modules fix
+  switch {
+  case self.mu > 0:
+    s.outcome = GEN_LIMIT
+  case self.useRep && (len(self.amCounts) <= 1):
+    if (self.agent(self.vertices[0]).actMod.GetBit(0) == 1) {
+      s.outcome = FIXED_COOP
+    } else {
+      s.outcome = FIXED_DEFECT
+    }
+  case self.useRep:
+    s.outcome = GEN_LIMIT
+  case self.Nc >= self.numAgents:
+    s.outcome = FIXED_COOP
+  case self.Nd >= self.numAgents:
+    s.outcome = FIXED_DEFECT
+  default:
+    s.outcome = GEN_LIMIT
+  }
+
+  s.kavg, s.ksd, s.kmax, s.kavgc, s.kavgd = self.degreeStats()
+
+  // time averages over the measurement window
+  s.statgens = self.stat.n
+  s.statpc = self.stat.average(self.stat.pc, self.stat.n)
+  s.statkavg = self.stat.average(self.stat.kavg, self.stat.n)
+  s.statksd = self.stat.average(self.stat.ksd, self.stat.n)
+  s.statkavgc = self.stat.average(self.stat.kavgc, self.stat.nc)
+  s.statkavgd = self.stat.average(self.stat.kavgd, self.stat.nd)
+  return s
+}
+
+// return the average degree, the standard deviation of the degree, the
+// maximum degree and the average degrees of cooperators and defectors
+// -- the average degree of a strategy is NaN if no agent uses it
+func (self *SimEngine) degreeStats() (kavg float64, ksd float64, kmax int, kavgc float64, kavgd float64) {
+  var sum, sumsq, sumc, sumd float64
+  var nc, nd int
+  for _, v := range self.graph.Vertices() {
+    k := self.graph.Degree(v)
+    sum += float64(k)
+    sumsq += float64(k*k)
+    if (k > kmax) {
+      kmax = k
+    }
+    if (self.agent(v).cooperate) {
+      sumc += float64(k)
+      nc += 1
+    } else {
+      sumd += float64(k)
+      nd += 1
+    }
+  }
+  n := float64(nc + nd)
+  kavg = sum/n
+  ksd = math.Sqrt(math.Max(sumsq/n - kavg*kavg, 0))
+  kavgc = sumc/float64(nc)
+  kavgd = sumd/float64(nd)
+  return kavg, ksd, kmax, kavgc, kavgd
+}
+
+// Sums of the population statistics measured once per generation after the
+// burn in period
+type stationaryStats struct {
+  n int32      // number of generations measured
+  nc int32     // number of generations with cooperators
+  nd int32     // number of generations with defectors
+  pc float64
+  kavg float64
+  ksd float64
+  kavgc float64
+  kavgd float64
+}
+
+// add the current state of the simulation to the sums
+func (self *stationaryStats) add(simeng *SimEngine) {
+  kavg, ksd, _, kavgc, kavgd := simeng.degreeStats()
+  self.n += 1
+  self.pc += float64(simeng.Nc)/float64(simeng.numAgents)
+  self.kavg += kavg
+  self.ksd += ksd
+  if (!math.IsNaN(kavgc)) {
+    self.kavgc += kavgc
+    self.nc += 1
+  }
+  if (!math.IsNaN(kavgd)) {
+    self.kavgd += kavgd
+    self.nd += 1
+  }
+}
+
+// return the time average of a sum - NaN if nothing was measured
+func (self *stationaryStats) average(sum float64, n int32) float64 {
+  if (n <= 0) {
+    return math.NaN()
+  }
+  return sum/float64(n)
+}
+
+// Ensemble statistics for a set of simulations run with the same parameters
+type Ensemble struct {
+  sims []SimSummary
+}
+
+func NewEnsemble() *Ensemble {
+  return &Ensemble {}
+}
+
+// Add the summary of a completed simulation to the ensemble
+func (self *Ensemble) Add(s SimSummary) {
+  self.sims = append(self.sims, s)
+}
+
+// return the fraction of simulations with the specified outcome
+func (self *Ensemble) OutcomeFraction(outcome int32) float64 {
+  count := 0
+  for _, s := range self.sims {
+    if (s.outcome == outcome) {
+      count += 1
+    }
+  }
+  return float64(count)/float64(len(self.sims))
+}
+
+// return the mean time to fixation and the half width of its 95% confidence
+// interval - only simulations that fixed on a strategy are included
+func (self *Ensemble) FixationTime() (mean float64, ci float64) {
+  var times []float64
+  for _, s := range self.sims {
+    if (s.outcome != GEN_LIMIT) {
+      times = append(times, float64(s.gens))
+    }
+  }
+  return meanCI95(times)
+}
+
+// return the mean of the selected value over all of the simulations
+// -- undefined (NaN) values are skipped
+func (self *Ensemble) mean(value func(s SimSummary) float64) float64 {
+  var values []float64
+  for _, s := range self.sims {
+    v := value(s)
+    if (!math.IsNaN(v)) {
+      values = append(values, v)
+    }
+  }
+  mean, _ := meanCI95(values)
+  return mean
+}
+
+// write the ensemble statistics as a JSON object
+func (self *Ensemble) WriteSummary(w io.Writer) {
+  fixtime, fixci := self.FixationTime()
+  fmt.Fprintln(w, "{")
+  fmt.Fprintf(w, "  \"nsims\":%d,\n", len(self.sims))
+  fmt.Fprintf(w, "  \"frac-fixed-c\":%s,\n", jsonFloat(self.OutcomeFraction(FIXED_COOP)))
+  fmt.Fprintf(w, "  \"frac-fixed-d\":%s,\n", jsonFloat(self.OutcomeFraction(FIXED_DEFECT)))
+  fmt.Fprintf(w, "  \"frac-gen-limit\":%s,\n", jsonFloat(self.OutcomeFraction(GEN_LIMIT)))
+  fmt.Fprintf(w, "  \"mean-fix-time\":%s,\n", jsonFloat(fixtime))
+  fmt.Fprintf(w, "  \"fix-time-ci95\":[%s,%s],\n", jsonFloat(fixtime-fixci), jsonFloat(fixtime+fixci))
+  fmt.Fprintf(w, "  \"mean-final-pc\":%s,\n",
+              jsonFloat(self.mean(func(s SimSummary) float64 { return s.pc })))
+  fmt.Fprintf(w, "  \"mean-final-kavg\":%s,\n",
+              jsonFloat(self.mean(func(s SimSummary) float64 { return s.kavg })))
+  fmt.Fprintf(w, "  \"mean-final-ksd\":%s,\n",
+              jsonFloat(self.mean(func(s SimSummary) float64 { return s.ksd })))
+  fmt.Fprintf(w, "  \"mean-final-kmax\":%s,\n",
+              jsonFloat(self.mean(func(s SimSummary) float64 { return float64(s.kmax) })))
+  fmt.Fprintf(w, "  \"mean-final-kavg-c\":%s,\n",
+              jsonFloat(self.mean(func(s SimSummary) float64 { return s.kavgc })))
+  fmt.Fprintf(w, "  \"mean-final-kavg-d\":%s,\n",
+              jsonFloat(self.mean(func(s SimSummary) float64 { return s.kavgd })))
+  fmt.Fprintf(w, "  \"mean-stat-pc\":%s,\n",
+              jsonFloat(self.mean(func(s SimSummary) float64 { return s.statpc })))
+  fmt.Fprintf(w, "  \"mean-stat-kavg\":%s,\n",
+              jsonFloat(self.mean(func(s SimSummary) float64 { return s.statkavg })))
+  fmt.Fprintf(w, "  \"mean-stat-ksd\":%s,\n",
+              jsonFloat(self.mean(func(s SimSummary) float64 { return s.statksd })))
+  fmt.Fprintf(w, "  \"mean-stat-kavg-c\":%s,\n",
+              jsonFloat(self.mean(func(s SimSummary) float64 { return s.statkavgc })))
+  fmt.Fprintf(w, "  \"mean-stat-kavg-d\":%s\n",
+              jsonFloat(self.mean(func(s SimSummary) float64 { return s.statkavgd })))
+  fmt.Fprintln(w, "}")
+}
+
+// format a float for JSON - undefined values are written as null
+func jsonFloat(f float64) string {
+  if (math.IsNaN(f) || math.IsInf(f, 0)) {
+    return "null"
+  }
+  return fmt.Sprintf("%.5f", f)
+}
+
+// two-sided 95% critical values of Student's t distribution for 1 to 30
+// degrees of freedom
+var tcrit95 = [...]float64 { 12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+                             2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+                             2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042 }
+
+// return the mean of the values and the half width of its 95% confidence
+// interval - the mean is NaN if there are no values and the half width is
+// NaN if there are fewer than two values
+func meanCI95(values []float64) (mean float64, ci float64) {
+  n := len(values)
+  if (n == 0) {
+    return math.NaN(), math.NaN()
+  }
+  for _, v := range values {
+    mean += v
+  }
+  mean = mean/float64(n)
+  if (n < 2) {
+    return mean, math.NaN()
+  }
+  var ss float64
+  for _, v := range values {
+    ss += (v - mean)*(v - mean)
+  }
+  sd := math.Sqrt(ss/float64(n-1))
+  t := 1.960
+  if (n-1 <= len(tcrit95)) {
+    t = tcrit95[n-2]
+  }
+  return mean, t*sd/math.Sqrt(float64(n))
+}
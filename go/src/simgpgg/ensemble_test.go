@@ -0,0 +1,103 @@
+package simgpgg
+
+import "testing"
+import "testutil"
+import "math"
+import "bytes"
+import "strings"
+import "simpgg"
+
+func TestSummary(u *testing.T) {
+  simeng := NewTestSimEngine()
+
+  // make all agents cooperators
+  for _, v := range simeng.vertices {
+    agent := simeng.agent(v)
+    agent.cooperate = true
+  }
+  simeng.Nc = simeng.numAgents
+  simeng.Nd = 0
+
+  s := simeng.Summary(3)
+  testutil.AssertInt32Equal(u, s.outcome, FIXED_COOP)
+  testutil.AssertInt32Equal(u, s.gens, 3)
+  testutil.AssertFloat64Equal(u, s.pc, 1)
+  // the regular ring has the same degree everywhere
+  testutil.AssertFloat64Equal(u, s.kavg, 4)
+  testutil.AssertFloat64Equal(u, s.ksd, 0)
+  testutil.AssertIntEqual(u, s.kmax, 4)
+  testutil.AssertFloat64Equal(u, s.kavgc, 4)
+  testutil.AssertTrue(u, math.IsNaN(s.kavgd))
+
+  // a mixed population has not fixed
+  simeng.agent(0).cooperate = false
+  simeng.Nc -= 1
+  simeng.Nd += 1
+  s = simeng.Summary(5)
+  testutil.AssertInt32Equal(u, s.outcome, GEN_LIMIT)
+}
+
+func TestSummaryRep(u *testing.T) {
+  simeng := NewTestRepSimEngine()
+
+  // every agent contributes, but with one of two action modules, so the
+  // run only ends at the generation limit
+  for i, v := range simeng.vertices {
+    agent := simeng.agent(v)
+    agent.actMod = simpgg.NewActionModule(true, true, true, true,
+                                          i%2 == 0, false, false, false, 0)
+    agent.cooperate = true
+  }
+  simeng.Nc = simeng.numAgents
+  simeng.Nd = 0
+  simeng.amCounts = map[int]int32 { simeng.agent(0).actMod.GetBits(): 4,
+                                    simeng.agent(1).actMod.GetBits(): 3 }
+  testutil.AssertFalse(u, simeng.SimComplete(simeng.numGens - 1))
+  s := simeng.Summary(simeng.numGens)
+  testutil.AssertInt32Equal(u, s.outcome, GEN_LIMIT)
+
+  // the population has fixed once every agent uses the same module
+  simeng.amCounts = map[int]int32 { simeng.agent(0).actMod.GetBits(): simeng.numAgents }
+  testutil.AssertTrue(u, simeng.SimComplete(2))
+  s = simeng.Summary(2)
+  testutil.AssertInt32Equal(u, s.outcome, FIXED_COOP)
+}
+
+func TestEnsemble(u *testing.T) {
+  e := NewEnsemble()
+  e.Add(SimSummary { outcome: FIXED_COOP, gens: 10, pc: 1, kavg: 4, kavgc: 4, kavgd: math.NaN() })
+  e.Add(SimSummary { outcome: FIXED_DEFECT, gens: 20, pc: 0, kavg: 4, kavgc: math.NaN(), kavgd: 4 })
+  e.Add(SimSummary { outcome: FIXED_DEFECT, gens: 30, pc: 0, kavg: 4, kavgc: math.NaN(), kavgd: 2 })
+  e.Add(SimSummary { outcome: GEN_LIMIT, gens: 100, pc: 0.5, kavg: 4, kavgc: 6, kavgd: 2 })
+
+  testutil.AssertFloat64Equal(u, e.OutcomeFraction(FIXED_COOP), 0.25)
+  testutil.AssertFloat64Equal(u, e.OutcomeFraction(FIXED_DEFECT), 0.5)
+  testutil.AssertFloat64Equal(u, e.OutcomeFraction(GEN_LIMIT), 0.25)
+
+  // only the simulations that fixed count toward the fixation time
+  // -- sd = 10, n = 3, t = 4.303
+  mean, ci := e.FixationTime()
+  testutil.AssertFloat64Equal(u, mean, 20)
+  testutil.AssertTrue(u, math.Abs(ci - 4.303*10/math.Sqrt(3)) < 1e-9)
+
+  // undefined degree statistics are skipped
+  testutil.AssertFloat64Equal(u, e.mean(func(s SimSummary) float64 { return s.kavgc }), 5)
+  testutil.AssertFloat64Equal(u, e.mean(func(s SimSummary) float64 { return s.pc }), 0.375)
+
+  var w bytes.Buffer
+  e.WriteSummary(&w)
+  testutil.AssertTrue(u, strings.Contains(w.String(), "\"nsims\":4,"))
+  testutil.AssertTrue(u, strings.Contains(w.String(), "\"mean-fix-time\":20.00000,"))
+}
+
+func TestMeanCI95(u *testing.T) {
+  mean, ci := meanCI95(nil)
+  testutil.AssertTrue(u, math.IsNaN(mean))
+  testutil.AssertTrue(u, math.IsNaN(ci))
+
+  mean, ci = meanCI95([]float64{ 7 })
+  testutil.AssertFloat64Equal(u, mean, 7)
+  testutil.AssertTrue(u, math.IsNaN(ci))
+
+  testutil.AssertTrue(u, jsonFloat(math.NaN()) == "null")
+}
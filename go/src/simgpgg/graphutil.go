@@ -1,5 +1,7 @@
 package simgpgg
 
+import "errors"
+import "fmt"
 import "goraph"
 import "math"
 import "math/rand"
@@ -61,6 +63,179 @@ func RemoveVerticesFromSlice(vertices []goraph.Vertex, toRemove []goraph.Vertex)
   return newSlice
 }
 
+// Graph implementations that the sim engine can use
+const (
+  GIMPL_ADJLIST int32 = iota // goraph.AdjacencyList (map of neighbor slices)
+  GIMPL_DENSE int32 = iota   // goraph.DenseGraph (indexed, faster on large graphs)
+)
+
+// Parameters of the graph types that only take N and Z
+const (
+  HOLME_KIM_PT = 0.5     // triad formation probability of graph type 13
+  POWER_LAW_EXP = 3      // exponent of the degree distribution of graph type 12
+  SBM_BLOCKS = 2         // number of equal blocks of graph type 14
+  SBM_IN_FRAC = 0.9      // expected fraction of links within a block in graph type 14
+)
+
+// Return an error if a graph of the specified type can't be created with N
+// nodes and average degree Z
+func CheckGraphParams(gtype, N, Z int32) error {
+  switch gtype {
+  case 0, 1, 2, 5, 6:
+    // the graph starts as a regular ring
+    if ((Z < 2) || (Z % 2 != 0) || (Z >= N - 1)) {
+      return fmt.Errorf("graph type %d needs an even Z between 2 and N-2 (N: %d, Z: %d)", gtype, N, Z)
+    }
+  case 3, 4:
+    // M0 = M = Z/2
+    if ((Z < 2) || (Z/2 >= N)) {
+      return fmt.Errorf("graph type %d needs Z/2 between 1 and N-1 (N: %d, Z: %d)", gtype, N, Z)
+    }
+  case 7:
+    // M = N*Z/2 edges
+    if ((Z < 0) || (Z >= N)) {
+      return fmt.Errorf("graph type 7 needs Z between 0 and N-1 (N: %d, Z: %d)", N, Z)
+    }
+  case 8, 9:
+    L := int32(math.Sqrt(float64(N)) + 0.5)
+    if ((L*L != N) || (L < 3)) {
+      return fmt.Errorf("graph type %d needs N to be the square of a number of at least 3 (N: %d)", gtype, N)
+    }
+  case 10:
+    if ((Z < 0) || (Z >= N) || ((N*Z) % 2 != 0)) {
+      return fmt.Errorf("graph type 10 needs Z less than N and N*Z even (N: %d, Z: %d)", N, Z)
+    }
+  case 11:
+    // p = Z/(N-1)
+    if ((N < 2) || (Z < 0) || (Z >= N)) {
+      return fmt.Errorf("graph type 11 needs Z between 0 and N-1 (N: %d, Z: %d)", N, Z)
+    }
+  case 12:
+    // the minimum degree is Z/2
+    if ((Z < 2) || (Z/2 >= N - 1)) {
+      return fmt.Errorf("graph type 12 needs Z/2 between 1 and N-2 (N: %d, Z: %d)", N, Z)
+    }
+  case 13:
+    // M0 = M = Z/2
+    if ((Z < 2) || (Z/2 >= N)) {
+      return fmt.Errorf("graph type 13 needs Z/2 between 1 and N-1 (N: %d, Z: %d)", N, Z)
+    }
+  case 14:
+    // -- the links expected within a block must fit in the smallest block
+    n := N/SBM_BLOCKS
+    if ((n < 2) || (Z < 0) || (SBM_IN_FRAC*float64(Z) > float64(n - 1))) {
+      return fmt.Errorf("graph type 14 needs %g*Z at most N/%d-1 (N: %d, Z: %d)",
+                        SBM_IN_FRAC, SBM_BLOCKS, N, Z)
+    }
+  default:
+    return fmt.Errorf("unknown graph type: %d", gtype)
+  }
+  return nil
+}
+
+// Create a graph of the specified type with N nodes and average degree Z
+func TryNewGraph(gtype, N, Z int32, rnGen *rand.Rand) (goraph.Graph, error) {
+  err := CheckGraphParams(gtype, N, Z)
+  if (err != nil) {
+    return nil, err
+  }
+  switch gtype {
+  case 0:
+    return NewRegularRing(N, Z), nil
+  case 1:
+    return NewHomoRandom(N, Z, rnGen), nil
+  case 2:
+    return NewSmallWorldNet(N, Z, 1, rnGen), nil
+  case 3:
+    // M0 = M = Z/2
+    return TryNewScaleFreeNet(N, Z/2, Z/2, rnGen)
+  case 4:
+    // M0 = M = Z/2
+    return TryNewUniScaleFreeNet(N, Z/2, Z/2, rnGen)
+  case 5:
+    return NewSmallWorldNet(N, Z, 0.1, rnGen), nil
+  case 6:
+    return NewSmallWorldNet(N, Z, 0.4, rnGen), nil
+  case 7:
+    // M = N*Z/2 edges
+    return TryNewErdosRenyiGnm(N, N*Z/2, rnGen)
+  case 8:
+    // N must be a square - Z is always 4
+    return TryNewSquareLattice(latticeSide(N), false)
+  case 9:
+    // N must be a square - Z is always 8
+    return TryNewSquareLattice(latticeSide(N), true)
+  case 11:
+    return NewErdosRenyiGnp(N, float64(Z)/float64(N-1), rnGen), nil
+  case 12:
+    return TryNewConfigModel(powerLawDegrees(N, Z/2, POWER_LAW_EXP, rnGen), rnGen)
+  case 13:
+    // M0 = M = Z/2
+    return TryNewHolmeKim(N, Z/2, Z/2, HOLME_KIM_PT, rnGen)
+  case 14:
+    sizes, P := sbmBlocks(N, Z)
+    return TryNewStochasticBlockModel(sizes, P, rnGen)
+  default:
+    return TryNewRandomRegular(N, Z, rnGen)
+  }
+}
+
+// Return a degree sequence of N nodes drawn from a power law with the
+// specified exponent and minimum degree kmin, capped at N-1.  The sum of the
+// degrees is even.
+func powerLawDegrees(N, kmin int32, exp float64, rnGen *rand.Rand) []int32 {
+  degrees := make([]int32, N)
+  sum := int32(0)
+  for i := range degrees {
+    // -- inverse transform sampling of the continuous power law
+    k := float64(kmin)*math.Pow(1 - RandProb(rnGen), -1/(exp - 1))
+    degrees[i] = int32(math.Min(k, float64(N - 1)))
+    sum += degrees[i]
+  }
+  if (sum % 2 != 0) {
+    if (degrees[0] < N - 1) {
+      degrees[0]++
+    } else {
+      degrees[0]--
+    }
+  }
+  return degrees
+}
+
+// return the sizes and link probabilities of SBM_BLOCKS nearly equal blocks
+// of N nodes with average degree Z, with a fraction SBM_IN_FRAC of the links
+// of each node expected to be within its block
+func sbmBlocks(N, Z int32) ([]int32, [][]float64) {
+  sizes := make([]int32, SBM_BLOCKS)
+  for b := range sizes {
+    sizes[b] = N/SBM_BLOCKS
+  }
+  sizes[0] += N % SBM_BLOCKS
+  // -- the probability of a link between blocks is the same both ways
+  out := (1 - SBM_IN_FRAC)*float64(Z)/(float64(N)*(SBM_BLOCKS - 1)/SBM_BLOCKS)
+  P := make([][]float64, SBM_BLOCKS)
+  for a := range P {
+    P[a] = make([]float64, SBM_BLOCKS)
+    for b := range P[a] {
+      if (a == b) {
+        P[a][b] = SBM_IN_FRAC*float64(Z)/float64(sizes[a] - 1)
+      } else {
+        P[a][b] = out
+      }
+    }
+  }
+  return sizes, P
+}
+
+// return the side of a square lattice with N nodes
+func latticeSide(N int32) int32 {
+  L := int32(math.Sqrt(float64(N)) + 0.5)
+  if (L*L != N) {
+    panic("number of agents is not a square")
+  }
+  return L
+}
+
 // Create a regular ring graph with N nodes each with degree K
 func NewRegularRing(N, K int32) *goraph.AdjacencyList {
   // create the nodes for the graph
@@ -120,10 +295,14 @@ func NewHomoRandom(N, K int32, rnGen *rand.Rand) *goraph.AdjacencyList {
 }
 
 // Create a scale free network using the Barabasi-Albert algorithm
-func NewScaleFreeNet(N, M0, M int32, rnGen *rand.Rand) *goraph.AdjacencyList {
-  // M must be less than M0
-  if (M0 < M) {
-    panic("M0 is less than M")
+func TryNewScaleFreeNet(N, M0, M int32, rnGen *rand.Rand) (*goraph.AdjacencyList, error) {
+  // each new node links to M distinct nodes, so M must be at least 1 and
+  // there must be at least M initial nodes
+  if (M < 1) {
+    return nil, errors.New("M is less than 1")
+  }
+  if ((M0 < M) || (N < M0)) {
+    return nil, errors.New("M0 is less than M or greater than N")
   }
   // create an array that represents the roulette wheel
   // -- initial length is M0
@@ -185,14 +364,27 @@ func NewScaleFreeNet(N, M0, M int32, rnGen *rand.Rand) *goraph.AdjacencyList {
     }
   }
 
+  return graph, nil
+}
+
+// Same as TryNewScaleFreeNet but panics if the parameters are invalid
+func NewScaleFreeNet(N, M0, M int32, rnGen *rand.Rand) *goraph.AdjacencyList {
+  graph, err := TryNewScaleFreeNet(N, M0, M, rnGen)
+  if (err != nil) {
+    panic(err)
+  }
   return graph
 }
 
 // Create a scale free network using uniform attachment
-func NewUniScaleFreeNet(N, M0, M int32, rnGen *rand.Rand) *goraph.AdjacencyList {
-  // M must be less than M0
-  if (M0 < M) {
-    panic("M0 is less than M")
+func TryNewUniScaleFreeNet(N, M0, M int32, rnGen *rand.Rand) (*goraph.AdjacencyList, error) {
+  // each new node links to M distinct nodes, so M must be at least 1 and
+  // there must be at least M initial nodes
+  if (M < 1) {
+    return nil, errors.New("M is less than 1")
+  }
+  if ((M0 < M) || (N < M0)) {
+    return nil, errors.New("M0 is less than M or greater than N")
   }
   // create an array that represents the roulette wheel
   wheel := make([]goraph.Vertex, N)
@@ -246,6 +438,15 @@ func NewUniScaleFreeNet(N, M0, M int32, rnGen *rand.Rand) *goraph.AdjacencyList
     wheelSize++
   }
 
+  return graph, nil
+}
+
+// Same as TryNewUniScaleFreeNet but panics if the parameters are invalid
+func NewUniScaleFreeNet(N, M0, M int32, rnGen *rand.Rand) *goraph.AdjacencyList {
+  graph, err := TryNewUniScaleFreeNet(N, M0, M, rnGen)
+  if (err != nil) {
+    panic(err)
+  }
   return graph
 }
 
@@ -287,3 +488,431 @@ func NewSmallWorldNet(N, K int32, p float64, rnGen *rand.Rand) *goraph.Adjacency
   }
   return graph
 }
+
+// Create an Erdos-Renyi random graph G(N,p) in which each pair of nodes is
+// linked with probability p
+func NewErdosRenyiGnp(N int32, p float64, rnGen *rand.Rand) *goraph.AdjacencyList {
+  graph := goraph.NewAdjacencyList()
+  for i := int32(0); i < N; i++ {
+    graph.AddVertex()
+  }
+  for i := int32(0); i < N; i++ {
+    for j := i+1; j < N; j++ {
+      if (RandProb(rnGen) < p) {
+        graph.AddEdge(goraph.Vertex(i), goraph.Vertex(j))
+      }
+    }
+  }
+  return graph
+}
+
+// Create an Erdos-Renyi random graph G(N,M) with M edges selected uniformly
+// at random from all possible edges
+func TryNewErdosRenyiGnm(N, M int32, rnGen *rand.Rand) (*goraph.AdjacencyList, error) {
+  // M must not exceed the number of possible edges
+  if (int64(M) > int64(N)*int64(N-1)/2) {
+    return nil, errors.New("M is greater than N(N-1)/2")
+  }
+  graph := goraph.NewAdjacencyList()
+  for i := int32(0); i < N; i++ {
+    graph.AddVertex()
+  }
+  // add random edges until there are M edges
+  // -- skip circular and duplicate edges
+  for m := int32(0); m < M; {
+    u := goraph.Vertex(RandInt(rnGen, int64(N)))
+    v := goraph.Vertex(RandInt(rnGen, int64(N)))
+    if ((u != v) && !goraph.VertexSlice(graph.Neighbors(u)).Contains(v)) {
+      graph.AddEdge(u, v)
+      m++
+    }
+  }
+  return graph, nil
+}
+
+// Same as TryNewErdosRenyiGnm but panics if the parameters are invalid
+func NewErdosRenyiGnm(N, M int32, rnGen *rand.Rand) *goraph.AdjacencyList {
+  graph, err := TryNewErdosRenyiGnm(N, M, rnGen)
+  if (err != nil) {
+    panic(err)
+  }
+  return graph
+}
+
+// Create an L x L square lattice with periodic boundaries.  Each node is
+// linked to its 4 nearest neighbors (von Neumann neighborhood) or, if moore
+// is true, to its 8 nearest neighbors (Moore neighborhood).  Node (r, c) is
+// Vertex(r*L + c).
+func TryNewSquareLattice(L int32, moore bool) (*goraph.AdjacencyList, error) {
+  // smaller lattices would have duplicate edges
+  if (L < 3) {
+    return nil, errors.New("L is less than 3")
+  }
+  graph := goraph.NewAdjacencyList()
+  for i := int32(0); i < L*L; i++ {
+    graph.AddVertex()
+  }
+  // each node links to the nodes to its right and below it (and diagonally
+  // below for Moore neighborhoods) so that each edge is only added once
+  offsets := [][2]int32{{0, 1}, {1, 0}}
+  if (moore) {
+    offsets = append(offsets, [2]int32{1, 1}, [2]int32{1, -1})
+  }
+  for r := int32(0); r < L; r++ {
+    for c := int32(0); c < L; c++ {
+      for _, off := range offsets {
+        r2 := (r + off[0] + L) % L
+        c2 := (c + off[1] + L) % L
+        graph.AddEdge(goraph.Vertex(r*L + c), goraph.Vertex(r2*L + c2))
+      }
+    }
+  }
+  return graph, nil
+}
+
+// Same as TryNewSquareLattice but panics if the parameters are invalid
+func NewSquareLattice(L int32, moore bool) *goraph.AdjacencyList {
+  graph, err := TryNewSquareLattice(L, moore)
+  if (err != nil) {
+    panic(err)
+  }
+  return graph
+}
+
+// maximum number of times the stubs of a random regular graph are paired
+// before TryNewRandomRegular gives up
+const MAX_REGULAR_ATTEMPTS = 100
+
+// number of random edges tried per circular or duplicate edge when a
+// pairing of stubs is repaired
+const REGULAR_SWITCH_TRIES = 100
+
+// Create a random regular graph with N nodes each with degree K.  Stubs are
+// paired at random and circular and duplicate edges are then repaired by
+// switching their ends with those of random edges, so the cost doesn't grow
+// with K.  A graph with K above (N-1)/2 is created as the complement of a
+// random regular graph with degree N-1-K.  An error is returned if no
+// simple graph is found in MAX_REGULAR_ATTEMPTS pairings.
+func TryNewRandomRegular(N, K int32, rnGen *rand.Rand) (*goraph.AdjacencyList, error) {
+  // N*K must be even and K must be less than N
+  if ((N*K) % 2 != 0) {
+    return nil, errors.New("N*K is odd")
+  }
+  if (K >= N) {
+    return nil, errors.New("K is not less than N")
+  }
+  if (K < 0) {
+    return nil, errors.New("K is negative")
+  }
+  // -- dense graphs have few valid switches, but their complements are sparse
+  dense := (2*K > N-1)
+  k := K
+  if (dense) {
+    k = N-1-K
+  }
+  for attempt := 0; attempt < MAX_REGULAR_ATTEMPTS; attempt++ {
+    edges, ok := pairRegularStubs(N, k, rnGen)
+    if (!ok) {
+      continue
+    }
+    graph := goraph.NewAdjacencyList()
+    for i := int32(0); i < N; i++ {
+      graph.AddVertex()
+    }
+    if (dense) {
+      linked := make(map[[2]int32]bool, len(edges))
+      for _, e := range edges {
+        linked[e] = true
+      }
+      for u := int32(0); u < N; u++ {
+        for v := u+1; v < N; v++ {
+          if (!linked[[2]int32{u, v}]) {
+            graph.AddEdge(goraph.Vertex(u), goraph.Vertex(v))
+          }
+        }
+      }
+    } else {
+      for _, e := range edges {
+        graph.AddEdge(goraph.Vertex(e[0]), goraph.Vertex(e[1]))
+      }
+    }
+    return graph, nil
+  }
+  return nil, fmt.Errorf("no random regular graph found in %d attempts", MAX_REGULAR_ATTEMPTS)
+}
+
+// return an edge with its ends in ascending order
+func regularEdge(u, v int32) [2]int32 {
+  if (v < u) {
+    return [2]int32{v, u}
+  }
+  return [2]int32{u, v}
+}
+
+// Pair the stubs of N nodes each with degree K at random and repair the
+// circular and duplicate edges.  Each bad edge (u,v) is replaced, together
+// with a random edge (x,y), by (u,x) and (v,y) if neither is circular or
+// already present.  Returns the edges and false if the repair failed.
+func pairRegularStubs(N, K int32, rnGen *rand.Rand) ([][2]int32, bool) {
+  stubs := make([]int32, 0, N*K)
+  for v := int32(0); v < N; v++ {
+    for j := int32(0); j < K; j++ {
+      stubs = append(stubs, v)
+    }
+  }
+  rnGen.Shuffle(len(stubs), func(i, j int) { stubs[i], stubs[j] = stubs[j], stubs[i] })
+  M := len(stubs)/2
+  edges := make([][2]int32, M)
+  count := make(map[[2]int32]int, M)
+  for i := range edges {
+    edges[i] = regularEdge(stubs[2*i], stubs[2*i+1])
+    count[edges[i]]++
+  }
+  isBad := func(i int) bool {
+    return (edges[i][0] == edges[i][1]) || (count[edges[i]] > 1)
+  }
+  var bad []int
+  for i := range edges {
+    if (isBad(i)) {
+      bad = append(bad, i)
+    }
+  }
+  tries := REGULAR_SWITCH_TRIES * len(bad)
+  for (len(bad) > 0) {
+    i := bad[len(bad)-1]
+    if (!isBad(i)) {
+      // -- fixed when the other copy of a duplicate edge was replaced
+      bad = bad[:len(bad)-1]
+      continue
+    }
+    if (tries == 0) {
+      return nil, false
+    }
+    tries--
+    j := rnGen.Intn(M)
+    u, v := edges[i][0], edges[i][1]
+    x, y := edges[j][0], edges[j][1]
+    if (rnGen.Intn(2) == 0) {
+      x, y = y, x
+    }
+    e1, e2 := regularEdge(u, x), regularEdge(v, y)
+    if ((j == i) || (u == x) || (v == y) || (e1 == e2) || (count[e1] > 0) || (count[e2] > 0)) {
+      continue
+    }
+    count[edges[i]]--
+    count[edges[j]]--
+    edges[i], edges[j] = e1, e2
+    count[e1]++
+    count[e2]++
+    bad = bad[:len(bad)-1]
+  }
+  return edges, true
+}
+
+// Same as TryNewRandomRegular but panics if the parameters are invalid
+func NewRandomRegular(N, K int32, rnGen *rand.Rand) *goraph.AdjacencyList {
+  graph, err := TryNewRandomRegular(N, K, rnGen)
+  if (err != nil) {
+    panic(err)
+  }
+  return graph
+}
+
+// Create a graph with the specified degree sequence using the configuration
+// model.  Stubs are paired at random and circular and duplicate edges are
+// discarded (the erased configuration model) so some nodes may end up with a
+// lower degree than requested.
+func TryNewConfigModel(degrees []int32, rnGen *rand.Rand) (*goraph.AdjacencyList, error) {
+  // the sum of the degrees must be even
+  sum := int32(0)
+  for _, k := range degrees {
+    sum += k
+  }
+  if (sum % 2 != 0) {
+    return nil, errors.New("sum of degrees is odd")
+  }
+  graph, _ := pairStubs(degrees, rnGen)
+  return graph, nil
+}
+
+// Same as TryNewConfigModel but panics if the parameters are invalid
+func NewConfigModel(degrees []int32, rnGen *rand.Rand) *goraph.AdjacencyList {
+  graph, err := TryNewConfigModel(degrees, rnGen)
+  if (err != nil) {
+    panic(err)
+  }
+  return graph
+}
+
+// Randomly pair the stubs of the nodes with the specified degrees.  Circular
+// and duplicate edges are discarded.  Returns the graph and true if no edges
+// were discarded.
+func pairStubs(degrees []int32, rnGen *rand.Rand) (*goraph.AdjacencyList, bool) {
+  graph := goraph.NewAdjacencyList()
+  var stubs []goraph.Vertex
+  for _, k := range degrees {
+    v := graph.AddVertex()
+    for j := int32(0); j < k; j++ {
+      stubs = append(stubs, v)
+    }
+  }
+  // shuffle the stubs and link them in pairs
+  rnGen.Shuffle(len(stubs), func(i, j int) { stubs[i], stubs[j] = stubs[j], stubs[i] })
+  complete := true
+  for i := 0; i+1 < len(stubs); i += 2 {
+    u := stubs[i]
+    v := stubs[i+1]
+    if ((u == v) || goraph.VertexSlice(graph.Neighbors(u)).Contains(v)) {
+      complete = false
+    } else {
+      graph.AddEdge(u, v)
+    }
+  }
+  return graph, complete
+}
+
+// Create a clustered scale free network using the Holme-Kim algorithm.  Each
+// new node makes one preferential attachment link followed by M-1 links that
+// are triad formation steps with probability pt (a link to a random neighbor
+// of the node linked by the preferential attachment step) or otherwise
+// preferential attachment steps.
+func TryNewHolmeKim(N, M0, M int32, pt float64, rnGen *rand.Rand) (*goraph.AdjacencyList, error) {
+  // each new node links to M distinct nodes, so M must be at least 1 and
+  // there must be at least M initial nodes
+  if (M < 1) {
+    return nil, errors.New("M is less than 1")
+  }
+  if ((M0 < M) || (N < M0)) {
+    return nil, errors.New("M0 is less than M or greater than N")
+  }
+  // roulette wheel used for preferential attachment (see NewScaleFreeNet)
+  wheel := make([]goraph.Vertex, M0 + 2*(N - M0)*M)
+  wheelSize := 0
+
+  // create M0 nodes to initially populate the graph
+  graph := goraph.NewAdjacencyList()
+  for i := int32(0); i < M0; i++ {
+    wheel[wheelSize] = graph.AddVertex()
+    wheelSize++
+  }
+
+  selected := goraph.VertexSlice(make([]goraph.Vertex, 0, M))
+  for i := M0; i < N; i++ {
+    newNode := graph.AddVertex()
+    selected = selected[:0]
+
+    var pa goraph.Vertex // node linked by the most recent PA step
+    for j := int32(0); j < M; j++ {
+      var v goraph.Vertex
+      found := false
+      // attempt a triad formation step
+      if ((j > 0) && (RandProb(rnGen) < pt)) {
+        candidates := RemoveVerticesFromSlice(graph.Neighbors(pa), selected.Copy())
+        if (len(candidates) > 0) {
+          v = candidates[RandInt(rnGen, int64(len(candidates)))]
+          found = true
+        }
+      }
+      // otherwise perform a preferential attachment step
+      for ; !found; {
+        v = wheel[RandInt(rnGen, int64(wheelSize))]
+        found = !selected.Contains(v)
+        pa = v
+      }
+      selected = append(selected, v)
+    }
+
+    // link newNode with the selected vertices
+    for _, v := range selected {
+      graph.AddEdge(newNode, v)
+      wheel[wheelSize] = newNode
+      wheelSize++
+      wheel[wheelSize] = v
+      wheelSize++
+    }
+  }
+  return graph, nil
+}
+
+// Same as TryNewHolmeKim but panics if the parameters are invalid
+func NewHolmeKim(N, M0, M int32, pt float64, rnGen *rand.Rand) *goraph.AdjacencyList {
+  graph, err := TryNewHolmeKim(N, M0, M, pt, rnGen)
+  if (err != nil) {
+    panic(err)
+  }
+  return graph
+}
+
+// Create a stochastic block model graph.  The nodes are divided into blocks
+// with the specified sizes (the first sizes[0] nodes are in block 0 and so
+// on) and a node in block a is linked to a node in block b with probability
+// P[a][b].
+func TryNewStochasticBlockModel(sizes []int32, P [][]float64, rnGen *rand.Rand) (*goraph.AdjacencyList, error) {
+  // P must be a square matrix with a row for each block
+  if (len(P) != len(sizes)) {
+    return nil, errors.New("P does not have a row for each block")
+  }
+  graph := goraph.NewAdjacencyList()
+  var blocks []int
+  for b, size := range sizes {
+    if (len(P[b]) != len(sizes)) {
+      return nil, errors.New("P does not have a column for each block")
+    }
+    for i := int32(0); i < size; i++ {
+      graph.AddVertex()
+      blocks = append(blocks, b)
+    }
+  }
+  for i := 0; i < len(blocks); i++ {
+    for j := i+1; j < len(blocks); j++ {
+      if (RandProb(rnGen) < P[blocks[i]][blocks[j]]) {
+        graph.AddEdge(goraph.Vertex(i), goraph.Vertex(j))
+      }
+    }
+  }
+  return graph, nil
+}
+
+// Same as TryNewStochasticBlockModel but panics if the parameters are invalid
+func NewStochasticBlockModel(sizes []int32, P [][]float64, rnGen *rand.Rand) *goraph.AdjacencyList {
+  graph, err := TryNewStochasticBlockModel(sizes, P, rnGen)
+  if (err != nil) {
+    panic(err)
+  }
+  return graph
+}
+
+// Return true if every node in the graph can be reached from every other node
+// -- the edges of directed graphs must be followed in their direction
+func IsConnected(graph goraph.Graph) bool {
+  vertices := graph.Vertices()
+  if (len(vertices) == 0) {
+    return true
+  }
+  if (!reachesAll(vertices[0], len(vertices), graph.NeighborsView)) {
+    return false
+  }
+  // a directed graph is connected if every node can also reach the first
+  if digraph, ok := goraph.AsDirected(graph); ok {
+    return reachesAll(vertices[0], len(vertices), digraph.InNeighborsView)
+  }
+  return true
+}
+
+// return true if a breadth first search from v following the specified
+// neighbors reaches all n vertices
+func reachesAll(v goraph.Vertex, n int, neighbors func(goraph.Vertex) []goraph.Vertex) bool {
+  seen := map[goraph.Vertex]bool{ v: true }
+  queue := []goraph.Vertex{ v }
+  for ; len(queue) > 0; {
+    v := queue[0]
+    queue = queue[1:]
+    for _, n := range neighbors(v) {
+      if (!seen[n]) {
+        seen[n] = true
+        queue = append(queue, n)
+      }
+    }
+  }
+  return len(seen) == n
+}
@@ -3,6 +3,9 @@ package simgpgg
 import "testing"
 import "testutil"
 import "goraph"
+import "fmt"
+import "math"
+import "math/rand"
 
 func TestRemoveVerticesFromSlice(u *testing.T) {
   var vertices []goraph.Vertex
@@ -91,3 +94,254 @@ func TestGraphGen(u *testing.T) {
   NewSmallWorldNet(64, 4, float64(0.5), rnGen)
   NewSmallWorldNet(64, 4, float64(1.0), rnGen)
 }
+
+// assert that every node in the graph has degree k
+func assertRegular(u *testing.T, graph goraph.Graph, k int) {
+  for _, v := range graph.Vertices() {
+    if (graph.Degree(v) != k) {
+      testutil.LogErr(u, fmt.Sprintf("vertex %v has degree %d instead of %d", v, graph.Degree(v), k))
+      return
+    }
+  }
+}
+
+// assert that the graph has no circular or duplicate edges
+func assertSimple(u *testing.T, graph goraph.Graph) {
+  for _, v := range graph.Vertices() {
+    Nv := graph.Neighbors(v)
+    if (len(removeDuplicates(Nv)) != len(Nv) || goraph.VertexSlice(Nv).Contains(v)) {
+      testutil.LogErr(u, fmt.Sprintf("vertex %v has circular or duplicate edges", v))
+      return
+    }
+  }
+}
+
+func TestErdosRenyi(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(1))
+  N := int32(200)
+
+  // p = 0 and p = 1 give the empty and complete graphs
+  graph := NewErdosRenyiGnp(N, 0, rnGen)
+  testutil.AssertIntEqual(u, len(graph.Edges()), 0)
+  graph = NewErdosRenyiGnp(N, 1, rnGen)
+  assertRegular(u, graph, int(N-1))
+  testutil.AssertTrue(u, IsConnected(graph))
+
+  // the number of edges is close to p*N(N-1)/2 = 1990 (sd ~ 42)
+  graph = NewErdosRenyiGnp(N, 0.1, rnGen)
+  nedges := len(graph.Edges())
+  testutil.AssertTrue(u, (nedges > 1990-250) && (nedges < 1990+250))
+  assertSimple(u, graph)
+  // -- well above the connectivity threshold ln(N)/N
+  testutil.AssertTrue(u, IsConnected(graph))
+
+  // G(N,M) has exactly M edges
+  graph = NewErdosRenyiGnm(N, 800, rnGen)
+  testutil.AssertIntEqual(u, len(graph.Edges()), 800)
+  testutil.AssertIntEqual(u, len(graph.Vertices()), int(N))
+  assertSimple(u, graph)
+}
+
+func TestSquareLattice(u *testing.T) {
+  L := int32(10)
+  graph := NewSquareLattice(L, false)
+  testutil.AssertIntEqual(u, len(graph.Vertices()), int(L*L))
+  assertRegular(u, graph, 4)
+  assertSimple(u, graph)
+  testutil.AssertTrue(u, IsConnected(graph))
+  // periodic boundaries link the first and last columns
+  testutil.AssertTrue(u, goraph.VertexSlice(graph.Neighbors(0)).Contains(goraph.Vertex(L-1)))
+
+  graph = NewSquareLattice(L, true)
+  assertRegular(u, graph, 8)
+  assertSimple(u, graph)
+  testutil.AssertTrue(u, IsConnected(graph))
+  // diagonal neighbors wrap around the corners
+  testutil.AssertTrue(u, goraph.VertexSlice(graph.Neighbors(0)).Contains(goraph.Vertex(L*L-1)))
+}
+
+func TestRandomRegular(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(2))
+  graph := NewRandomRegular(100, 4, rnGen)
+  assertRegular(u, graph, 4)
+  assertSimple(u, graph)
+  testutil.AssertIntEqual(u, len(graph.Edges()), 200)
+  // random 4-regular graphs are almost always connected
+  testutil.AssertTrue(u, IsConnected(graph))
+
+  // higher degrees don't make the pairing slower
+  for _, k := range []int32{ 8, 16, 32 } {
+    graph = NewRandomRegular(1000, k, rnGen)
+    assertRegular(u, graph, int(k))
+    assertSimple(u, graph)
+    testutil.AssertIntEqual(u, len(graph.Edges()), int(500*k))
+  }
+  // dense graphs, including the complete graph
+  for _, k := range []int32{ 12, 18, 19 } {
+    graph = NewRandomRegular(20, k, rnGen)
+    assertRegular(u, graph, int(k))
+    assertSimple(u, graph)
+  }
+}
+
+func TestConfigModel(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(3))
+  degrees := make([]int32, 100)
+  sum := 0
+  for i := range degrees {
+    degrees[i] = int32(1 + i%5)
+    sum += int(degrees[i])
+  }
+  graph := NewConfigModel(degrees, rnGen)
+  assertSimple(u, graph)
+  testutil.AssertIntEqual(u, len(graph.Vertices()), len(degrees))
+  // erased edges can only lower the degrees
+  total := 0
+  for i, k := range degrees {
+    d := graph.Degree(goraph.Vertex(i))
+    testutil.AssertTrue(u, d <= int(k))
+    total += d
+  }
+  // only a few edges are erased
+  testutil.AssertTrue(u, total >= sum*9/10)
+}
+
+func TestHolmeKim(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(4))
+  N := int32(500)
+  M := int32(3)
+  graph := NewHolmeKim(N, M, M, 0.8, rnGen)
+  assertSimple(u, graph)
+  testutil.AssertIntEqual(u, len(graph.Edges()), int((N-M)*M))
+  testutil.AssertTrue(u, IsConnected(graph))
+  // each new node has at least M links
+  for i := M; i < N; i++ {
+    testutil.AssertTrue(u, graph.Degree(goraph.Vertex(i)) >= int(M))
+  }
+  // preferential attachment produces hubs
+  kmax := 0
+  for _, v := range graph.Vertices() {
+    if (graph.Degree(v) > kmax) { kmax = graph.Degree(v) }
+  }
+  testutil.AssertTrue(u, kmax > int(4*M))
+}
+
+func TestStochasticBlockModel(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(5))
+  sizes := []int32{10, 20}
+
+  // complete blocks without links between them
+  graph := NewStochasticBlockModel(sizes, [][]float64{{1, 0}, {0, 1}}, rnGen)
+  for i := 0; i < 30; i++ {
+    if (i < 10) {
+      testutil.AssertIntEqual(u, graph.Degree(goraph.Vertex(i)), 9)
+    } else {
+      testutil.AssertIntEqual(u, graph.Degree(goraph.Vertex(i)), 19)
+    }
+  }
+  testutil.AssertFalse(u, IsConnected(graph))
+
+  // a complete bipartite graph
+  graph = NewStochasticBlockModel(sizes, [][]float64{{0, 1}, {1, 0}}, rnGen)
+  testutil.AssertIntEqual(u, graph.Degree(0), 20)
+  testutil.AssertIntEqual(u, graph.Degree(29), 10)
+  testutil.AssertTrue(u, IsConnected(graph))
+}
+
+func TestGraphTypeDegrees(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(6))
+  N := int32(1000)
+  Z := int32(8)
+  // the graph types built from the new generators have about the
+  // requested average degree
+  for _, gtype := range []int32{ 11, 12, 13, 14 } {
+    graph, err := TryNewGraph(gtype, N, Z, rnGen)
+    testutil.AssertTrue(u, err == nil)
+    assertSimple(u, graph)
+    kavg := float64(2*len(graph.Edges()))/float64(N)
+    if (math.Abs(kavg - float64(Z)) > 0.2*float64(Z)) {
+      testutil.LogErr(u, fmt.Sprintf("graph type %d has average degree %g instead of about %d", gtype, kavg, Z))
+    }
+  }
+
+  // most of the links of the block model are within the blocks
+  graph, _ := TryNewGraph(14, N, Z, rnGen)
+  within := 0
+  for _, e := range graph.Edges() {
+    if ((e.U < goraph.Vertex(N/2)) == (e.V < goraph.Vertex(N/2))) { within++ }
+  }
+  testutil.AssertBinomial(u, within, len(graph.Edges()), SBM_IN_FRAC)
+
+  // the power law degrees have the minimum degree Z/2 and an even sum
+  degrees := powerLawDegrees(N, Z/2, POWER_LAW_EXP, rnGen)
+  sum := int32(0)
+  for _, k := range degrees {
+    testutil.AssertTrue(u, (k >= Z/2) && (k < N))
+    sum += k
+  }
+  testutil.AssertInt32Equal(u, sum % 2, 0)
+}
+
+func TestIsConnectedDirected(u *testing.T) {
+  graph := goraph.NewDigraph()
+  for i := 0; i < 3; i++ {
+    graph.AddVertex()
+  }
+  // a directed path is not connected
+  graph.AddEdge(0, 1)
+  graph.AddEdge(1, 2)
+  testutil.AssertFalse(u, IsConnected(graph))
+  // a directed cycle is connected
+  graph.AddEdge(2, 0)
+  testutil.AssertTrue(u, IsConnected(graph))
+}
+
+func TestCheckGraphParams(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(1))
+  // valid parameters create a graph
+  for gtype := int32(0); gtype <= 14; gtype++ {
+    N := int32(16)
+    testutil.AssertTrue(u, CheckGraphParams(gtype, N, 4) == nil)
+    graph, err := TryNewGraph(gtype, N, 4, rnGen)
+    testutil.AssertTrue(u, err == nil)
+    testutil.AssertIntEqual(u, len(graph.Vertices()), int(N))
+  }
+  // invalid parameters are reported instead of panicking
+  testutil.AssertTrue(u, CheckGraphParams(0, 16, 3) != nil)
+  testutil.AssertTrue(u, CheckGraphParams(0, 4, 4) != nil)
+  testutil.AssertTrue(u, CheckGraphParams(3, 16, 1) != nil)
+  testutil.AssertTrue(u, CheckGraphParams(7, 16, 16) != nil)
+  testutil.AssertTrue(u, CheckGraphParams(8, 10, 4) != nil)
+  testutil.AssertTrue(u, CheckGraphParams(10, 9, 3) != nil)
+  testutil.AssertTrue(u, CheckGraphParams(11, 16, 16) != nil)
+  testutil.AssertTrue(u, CheckGraphParams(12, 16, 1) != nil)
+  testutil.AssertTrue(u, CheckGraphParams(13, 16, 0) != nil)
+  testutil.AssertTrue(u, CheckGraphParams(14, 16, 9) != nil)
+  testutil.AssertTrue(u, CheckGraphParams(15, 16, 4) != nil)
+  _, err := TryNewGraph(8, 10, 4, rnGen)
+  testutil.AssertTrue(u, err != nil)
+
+  // the generators report bad parameters
+  _, err = TryNewScaleFreeNet(10, 1, 2, rnGen)
+  testutil.AssertTrue(u, err != nil)
+  _, err = TryNewRandomRegular(5, 3, rnGen)
+  testutil.AssertTrue(u, err != nil)
+  _, err = TryNewConfigModel([]int32{1, 2}, rnGen)
+  testutil.AssertTrue(u, err != nil)
+  _, err = TryNewStochasticBlockModel([]int32{2, 2}, [][]float64{{1, 0}}, rnGen)
+  testutil.AssertTrue(u, err != nil)
+  _, err = TryNewHolmeKim(10, 2, 3, 0.5, rnGen)
+  testutil.AssertTrue(u, err != nil)
+  _, err = TryNewHolmeKim(10, 2, 0, 0.5, rnGen)
+  testutil.AssertTrue(u, err != nil)
+  _, err = TryNewHolmeKim(10, 12, 2, 0.5, rnGen)
+  testutil.AssertTrue(u, err != nil)
+}
+
+func TestTryFermi(u *testing.T) {
+  _, err := TryFermi(-1, 1, 0)
+  testutil.AssertTrue(u, err != nil)
+  Pf, err := TryFermi(0, 1, 0)
+  testutil.AssertTrue(u, err == nil)
+  testutil.AssertFloat64Equal(u, Pf, 0.5)
+}
@@ -0,0 +1,102 @@
+package simgpgg
+
+import "goraph"
+import "io"
+
+// the vertex given for the missing end of a link that was only added or
+// only removed
+const NO_VERTEX goraph.Vertex = -1
+
+// An observer of the events of a simulation.  The engine reports each event
+// to its observers in the order they were added.  Embed NopObserver to
+// observe only some of the events.
+type Observer interface {
+  // called before the first generation
+  SimStart(simeng *SimEngine)
+
+  // called before the updates of a generation
+  GenStart(simeng *SimEngine, gen int32)
+
+  // called after the updates of a generation
+  GenEnd(simeng *SimEngine, gen int32)
+
+  // the agent at v switched between cooperating and defecting or, in
+  // reputation games, adopted a different action module
+  StrategyChange(simeng *SimEngine, v goraph.Vertex)
+
+  // x replaced its link to oldy with a link to newy - oldy is NO_VERTEX if
+  // a link was only added and newy is NO_VERTEX if a link was only removed
+  Rewire(simeng *SimEngine, x goraph.Vertex, oldy goraph.Vertex, newy goraph.Vertex)
+
+  // called after the last generation with the number of generations
+  // completed
+  SimEnd(simeng *SimEngine, gens int32)
+}
+
+// An observer that ignores every event
+type NopObserver struct {}
+
+func (self NopObserver) SimStart(simeng *SimEngine) {}
+func (self NopObserver) GenStart(simeng *SimEngine, gen int32) {}
+func (self NopObserver) GenEnd(simeng *SimEngine, gen int32) {}
+func (self NopObserver) StrategyChange(simeng *SimEngine, v goraph.Vertex) {}
+func (self NopObserver) Rewire(simeng *SimEngine, x goraph.Vertex, oldy goraph.Vertex, newy goraph.Vertex) {}
+func (self NopObserver) SimEnd(simeng *SimEngine, gens int32) {}
+
+// Add an observer that is notified of the events of every run
+func (self *SimEngine) AddObserver(o Observer) {
+  self.observers = append(self.observers, o)
+}
+
+// tell the observers that the strategy of the agent at v changed
+func (self *SimEngine) notifyStrategyChange(v goraph.Vertex) {
+  for _, o := range self.observers {
+    o.StrategyChange(self, v)
+  }
+}
+
+// tell the observers that x replaced its link to oldy with a link to newy
+func (self *SimEngine) notifyRewire(x goraph.Vertex, oldy goraph.Vertex, newy goraph.Vertex) {
+  for _, o := range self.observers {
+    o.Rewire(self, x, oldy, newy)
+  }
+}
+
+// Return true if the agent at v is a cooperator
+func (self *SimEngine) Cooperates(v goraph.Vertex) bool {
+  return self.agent(v).cooperate
+}
+
+// An observer that writes the population statistics (strategy percentages)
+// of each generation as CSV
+type PStatsWriter struct {
+  NopObserver
+  w io.Writer
+}
+
+func NewPStatsWriter(w io.Writer) *PStatsWriter {
+  return &PStatsWriter { w: w }
+}
+
+func (self *PStatsWriter) SimStart(simeng *SimEngine) {
+  simeng.WritePStatsHeader(self.w)
+}
+
+func (self *PStatsWriter) GenEnd(simeng *SimEngine, gen int32) {
+  simeng.WritePStats(self.w, gen)
+}
+
+// An observer that writes the strategy and degree of each agent as CSV at
+// the end of the simulation
+type DegreeHistWriter struct {
+  NopObserver
+  w io.Writer
+}
+
+func NewDegreeHistWriter(w io.Writer) *DegreeHistWriter {
+  return &DegreeHistWriter { w: w }
+}
+
+func (self *DegreeHistWriter) SimEnd(simeng *SimEngine, gens int32) {
+  simeng.DegreeHistogramData(self.w)
+}
@@ -0,0 +1,67 @@
+package simgpgg
+
+import "testing"
+import "testutil"
+import "goraph"
+
+// an observer that checks that the events it is told about account for the
+// changes to the strategies and links of the agents
+type checkingObserver struct {
+  NopObserver
+  t *testing.T
+  Nc int32       // cooperators according to the events
+  edges int      // links according to the events
+  gens int32     // generations ended
+  simEnds int
+}
+
+func (self *checkingObserver) SimStart(simeng *SimEngine) {
+  self.Nc = simeng.Nc
+  self.edges = len(simeng.graph.Edges())
+}
+
+func (self *checkingObserver) GenEnd(simeng *SimEngine, gen int32) {
+  testutil.AssertInt32Equal(self.t, gen, self.gens)
+  self.gens++
+}
+
+func (self *checkingObserver) StrategyChange(simeng *SimEngine, v goraph.Vertex) {
+  if (simeng.Cooperates(v)) {
+    self.Nc++
+  } else {
+    self.Nc--
+  }
+}
+
+func (self *checkingObserver) Rewire(simeng *SimEngine, x goraph.Vertex, oldy goraph.Vertex, newy goraph.Vertex) {
+  if (oldy != NO_VERTEX) {
+    testutil.AssertFalse(self.t, simeng.graph.HasEdge(x, oldy))
+    self.edges--
+  }
+  if (newy != NO_VERTEX) {
+    testutil.AssertTrue(self.t, simeng.graph.HasEdge(x, newy))
+    self.edges++
+  }
+}
+
+func (self *checkingObserver) SimEnd(simeng *SimEngine, gens int32) {
+  testutil.AssertInt32Equal(self.t, gens, self.gens)
+  self.simEnds++
+}
+
+func TestObserver(u *testing.T) {
+  for _, srule := range []int32{ SRULE_NEIGHBOR, SRULE_DRIFT } {
+    simeng := NewSimEngine(30, 200, 0, 4, 3, 1, 1, 1, 1)
+    simeng.SetStructureRule(NewStructureRule(srule, 1))
+    simeng.SetExploration(0.05)
+    o := &checkingObserver { t: u }
+    gens := simeng.Run(o)
+    testutil.AssertInt32Equal(u, gens, 200)
+    testutil.AssertIntEqual(u, o.simEnds, 1)
+    testutil.AssertInt32Equal(u, o.Nc, simeng.Nc)
+    testutil.AssertIntEqual(u, o.edges, len(simeng.graph.Edges()))
+    // the observer only observes the run it was passed to
+    simeng.Run()
+    testutil.AssertIntEqual(u, o.simEnds, 1)
+  }
+}
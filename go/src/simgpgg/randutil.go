@@ -3,22 +3,33 @@ package simgpgg
 import "math/rand"
 import "math"
 import "time"
+import "fmt"
 
 // return the value of the Fermi distribution for the specified values
 // of beta, p1 and p2: 1 / (1 + e^(-beta*(p1-p2)))
-func Fermi(beta, p1, p2 float64) float64 {
-  if (beta < 0) {
-    panic("beta < 0")
+// -- an error is returned if beta is negative or NaN
+func TryFermi(beta, p1, p2 float64) (float64, error) {
+  if (!(beta >= 0)) {
+    return 0, fmt.Errorf("beta must not be negative (beta: %v)", beta)
   }
   one := float64(1)
   if (math.IsInf(beta, +1)) {
-    return one
+    return one, nil
   } else if (beta == float64(0)) {
-    return float64(0.5)
+    return float64(0.5), nil
   } else {
     exp := (-beta)*(p1 - p2)
-    return one/(one + math.Exp(exp))
+    return one/(one + math.Exp(exp)), nil
+  }
+}
+
+// Same as TryFermi but panics if beta is negative
+func Fermi(beta, p1, p2 float64) float64 {
+  Pf, err := TryFermi(beta, p1, p2)
+  if (err != nil) {
+    panic(err)
   }
+  return Pf
 }
 
 // Return a new random number generator.  This generator is NOT protected
@@ -47,3 +58,26 @@ func RandBool(source *rand.Rand) bool {
 func RandProb(source *rand.Rand) float64 {
   return source.Float64()
 }
+
+// Generate a random index into the slice of non-negative weights.  The
+// chance that an index is selected is proportional to its weight.
+func RandWeighted(source *rand.Rand, weights []float64) int {
+  total := float64(0)
+  for _, w := range weights {
+    total += w
+  }
+  r := RandProb(source)*total
+  for i, w := range weights {
+    r -= w
+    if (r < 0) {
+      return i
+    }
+  }
+  // rounding error - return the last index with a positive weight
+  for i := len(weights)-1; i > 0; i-- {
+    if (weights[i] > 0) {
+      return i
+    }
+  }
+  return 0
+}
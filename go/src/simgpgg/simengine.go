@@ -2,17 +2,21 @@ package simgpgg
 
 import "math/rand"
 import "goraph"
+import "simbase"
+import "simpgg"
 import "fmt"
 import "io"
+import "sort"
 
 // A simulation engine for simulating the public goods games
 // played among agents occupying the nodes of a graph.
 type SimEngine struct {
-  graph goraph.Graph // the graph that holds the agents
+  graph *goraph.Attributed // the graph that holds the agents
   numAgents int32    // total number of agents being simulated
   avgdeg int32       // average degree of the graph (z)
   gtype int32        // the type of graph
-  agents []*Agent    // list of agents
+  agents *goraph.VertexAttr[*Agent] // the agent held by each vertex
+  vertices []goraph.Vertex // the vertices that hold agents in increasing order
   Nc int32           // current number of cooperators
   Nd int32           // current number of defectors
   numGens int32      // number of generations to be simulated
@@ -22,41 +26,75 @@ type SimEngine struct {
   betaa float64      // the selection strength for structure updates
   W float64          // relative frequency of structure updates
   rnGen *rand.Rand   // hold a RN generator
+  useRep bool        // agents use action modules and reputations to choose actions
+  fine float64       // fine paid by a non-contributor to each punisher
+  pcost float64      // cost paid by a punisher for each non-contributor punished
+  pexeerr float32    // probability of execution error for action modules
+  amCounts map[int]int32 // number of agents using each action module
+  stepMode int32     // the updates that make up one time step
+  structRule StructureRule // rule used to update the network structure
+  stratRule StrategyRule   // rule used to update the strategies of the agents
+  cscheme int32      // how cooperators contribute to their games
+  mu float64         // probability that an update is a random exploration
+  burnin int32       // generations before stationary statistics are measured
+                     // -- negative if they aren't measured
+  gimpl int32        // the implementation of the graph
+  stat stationaryStats // statistics measured after the burn in
+  stop func() bool   // if not nil, Run stops early once it returns true
+  observers []Observer // notified of the events of each run
+  payoutsValid bool  // the payouts of all agents are up to date except for
+                     // the games of the changed agents (population scope only)
+  changed map[goraph.Vertex]bool // agents whose strategy or links changed
+                                 // since the payouts were last played
 }
 
+// the name of the vertex attribute that holds the agents
+const AGENT_ATTR = "agent"
+
+// Time step semantics for RunSim
+const (
+  STEP_ASYNC int32 = iota // a single asynchronous update
+  STEP_MC int32 = iota    // a Monte Carlo step: N asynchronous updates
+  STEP_SYNC int32 = iota  // a synchronous update of all agents
+)
+
+// Contribution schemes that determine what a cooperator contributes to each
+// of the k+1 games it plays
+const (
+  CONTRIB_GAME int32 = iota       // cost per game
+  CONTRIB_INDIVIDUAL int32 = iota // cost per individual split across its games
+  CONTRIB_DEGREE int32 = iota     // cost per game scaled by (k+1)/(z+1)
+)
+
 // Make a new SimEngine with the specified parameters
 func NewSimEngine(numAgents int32, numGens int32, gtype int32, avgdeg int32, mult int32,
                   cost int32, W float64, betae float64, betaa float64) *SimEngine {
-  // initialize simengine
-  rnGen := NewRandNumGen()
+  return NewSimEngineWithRNG(numAgents, numGens, gtype, avgdeg, mult, cost, W, betae, betaa,
+                             NewRandNumGen())
+}
+
+// Make a new SimEngine that uses the provided random number generator.  The
+// generator must not be shared with another SimEngine that runs concurrently.
+func NewSimEngineWithRNG(numAgents int32, numGens int32, gtype int32, avgdeg int32, mult int32,
+                         cost int32, W float64, betae float64, betaa float64,
+                         rnGen *rand.Rand) *SimEngine {
   // initialize graphtype
-  var graph goraph.Graph
-  switch gtype {
-  case 0:
-    graph = NewRegularRing(numAgents, avgdeg)
-  case 1:
-    graph = NewHomoRandom(numAgents, avgdeg, rnGen)
-  case 2:
-    graph = NewSmallWorldNet(numAgents, avgdeg, 1, rnGen)
-  case 3:
-    // M0 = M = Z/2
-    graph = NewScaleFreeNet(numAgents, avgdeg/2, avgdeg/2, rnGen)
-  case 4:
-    // M0 = M = Z/2
-    graph = NewUniScaleFreeNet(numAgents, avgdeg/2, avgdeg/2, rnGen)
-  case 5:
-    graph = NewSmallWorldNet(numAgents, avgdeg, 0.1, rnGen)
-  case 6:
-    graph = NewSmallWorldNet(numAgents, avgdeg, 0.4, rnGen)
+  graph, err := TryNewGraph(gtype, numAgents, avgdeg, rnGen)
+  if (err != nil) {
+    panic(err)
   }
   // create the agents
-  agents := make([]*Agent, numAgents)
-  // create agents
+  // -- each agent is stored as an attribute of its vertex so that it stays
+  //    with the vertex when the graph changes
+  attributed := goraph.NewAttributed(graph)
+  agents := goraph.NewVertexAttr[*Agent](attributed, AGENT_ATTR)
+  vertices := make([]goraph.Vertex, numAgents)
   Nd := int32(0)
   Nc := int32(0)
   for i := int32(0); i < numAgents; i++ {
     cooperate := RandBool(rnGen)
-    agents[i] = NewAgent(cooperate)
+    vertices[i] = goraph.Vertex(i)
+    agents.Set(vertices[i], NewAgent(cooperate))
     if (cooperate) {
       Nc += 1
     } else {
@@ -66,77 +104,370 @@ func NewSimEngine(numAgents int32, numGens int32, gtype int32, avgdeg int32, mul
 
   return &SimEngine { numAgents: numAgents, numGens: numGens, avgdeg: avgdeg,
                       mult: mult, cost: cost, W: W, betae: betae, betaa: betaa,
-                      rnGen: rnGen, graph: graph, gtype: gtype, agents: agents,
-                      Nc: Nc, Nd: Nd }
+                      rnGen: rnGen, graph: attributed, gtype: gtype, agents: agents,
+                      vertices: vertices,
+                      Nc: Nc, Nd: Nd, structRule: &RewireNeighborRule { beta: betaa },
+                      stratRule: &FermiRule { beta: betae }, burnin: -1 }
 }
 
+// Make a new SimEngine whose agents use PGG action modules to decide whether
+// to contribute and whether to punish based on their own reputation and the
+// reputation of the group.  Action modules evolve through Fermi imitation.
+func NewRepSimEngine(numAgents int32, numGens int32, gtype int32, avgdeg int32, mult int32,
+                     cost int32, W float64, betae float64, betaa float64,
+                     fine float64, pcost float64, pexeerr float32) *SimEngine {
+  return NewRepSimEngineWithRNG(numAgents, numGens, gtype, avgdeg, mult, cost, W, betae, betaa,
+                                fine, pcost, pexeerr, NewRandNumGen())
+}
+
+// Make a new reputation-driven SimEngine that uses the provided random number
+// generator.
+func NewRepSimEngineWithRNG(numAgents int32, numGens int32, gtype int32, avgdeg int32, mult int32,
+                            cost int32, W float64, betae float64, betaa float64,
+                            fine float64, pcost float64, pexeerr float32,
+                            rnGen *rand.Rand) *SimEngine {
+  simeng := NewSimEngineWithRNG(numAgents, numGens, gtype, avgdeg, mult, cost, W, betae, betaa,
+                                rnGen)
+  simeng.useRep = true
+  simeng.fine = fine
+  simeng.pcost = pcost
+  simeng.pexeerr = pexeerr
+
+  // replace the agents with agents that use action modules
+  simeng.Nc = 0
+  simeng.Nd = 0
+  simeng.amCounts = make(map[int]int32)
+  for _, v := range simeng.vertices {
+    agent := NewRepAgent(pexeerr, simeng.rnGen)
+    simeng.agents.Set(v, agent)
+    if (agent.cooperate) {
+      simeng.Nc += 1
+    } else {
+      simeng.Nd += 1
+    }
+    simeng.amCounts[agent.actMod.GetBits()] += 1
+  }
+  return simeng
+}
+
+// Set a function that Run calls after each generation to find out whether
+// it should stop early, e.g. because the program was interrupted
+func (self *SimEngine) SetStop(stop func() bool) {
+  self.stop = stop
+}
+
+// Set the updates that make up one time step of the simulation
+func (self *SimEngine) SetStepMode(stepMode int32) {
+  self.stepMode = stepMode
+}
+
+// Return the label for the unit of time used by the simulation
+func (self *SimEngine) StepUnit() string {
+  switch self.stepMode {
+  case STEP_MC:
+    return "mcs"
+  case STEP_SYNC:
+    return "sync"
+  default:
+    return "update"
+  }
+}
+
+// Run the simulation, writing the population statistics of each generation to
+// psWriter and the degree histogram of the final generation to dhWriter.
+// Returns the number of generations completed.
 func (self *SimEngine) RunSim(psWriter io.Writer, dhWriter io.Writer) int32 {
-  // write header to population stats files
-  self.WritePStatsHeader(psWriter)
+  return self.Run(NewPStatsWriter(psWriter), NewDegreeHistWriter(dhWriter))
+}
+
+// Run the simulation until one strategy is eliminated, the generation limit
+// is reached or the stop function returns true.  The observers passed to Run
+// are notified of the events of this run along with the observers added to
+// the engine.  Returns the number of generations completed.
+func (self *SimEngine) Run(observers ...Observer) int32 {
+  numObservers := len(self.observers)
+  self.observers = append(self.observers, observers...)
+  defer func() { self.observers = self.observers[:numObservers] }()
+  for _, o := range self.observers {
+    o.SimStart(self)
+  }
 
   // calculate probability that a structure update occurs
   stratUpdProb := float64(1)/(float64(1) + self.W)
 
   // loop until one strategy is eliminated or the max num of gens is reached
   var g int32
-  for g = int32(0); (!self.SimComplete(g)); g++ {
-    // randomly select an agent
-    x := goraph.Vertex(RandInt(self.rnGen, int64(self.numAgents)))
-    // get the neighbors of x
-    Nx := self.graph.Neighbors(x)
-    // randomly select a neighbr of x
-    y := goraph.Vertex(Nx[RandInt(self.rnGen, int64(len(Nx)))])
-    // get the neighbors of y
-    Ny := self.graph.Neighbors(y)
-    // create combined list of agents without duplicates
-    sponsors := make([]goraph.Vertex,2)
-    sponsors[0] = x
-    sponsors[1] = y
-    // need accurate payout information for agents x and y
-    // -- set their payouts equal to zro
-    for i := 0; i < len(sponsors); i++ {
-      self.agents[sponsors[i]].payouts = float64(0)
+  for g = int32(0); (!self.SimComplete(g) && !self.stopRequested()); g++ {
+    for _, o := range self.observers {
+      o.GenStart(self, g)
     }
-    // add neighbors to list of game sponsors
-    // -- don't need accurate payout information for these agents
-    sponsors = append(sponsors, Nx...)
-    sponsors = append(sponsors, Ny...)
-    sponsors = removeDuplicates(sponsors)
-    // play the games
-    for i := 0; i < len(sponsors); i++ {
-      sponsor := sponsors[i]
-      players := append(self.graph.Neighbors(sponsor), sponsor)
-      self.PlayGame(players)
+    switch self.stepMode {
+    case STEP_MC:
+      // a Monte Carlo step gives each agent one update on average
+      for i := int32(0); i < self.numAgents; i++ {
+        self.AsyncUpdate(stratUpdProb)
+      }
+    case STEP_SYNC:
+      self.SyncUpdate(stratUpdProb)
+    default:
+      self.AsyncUpdate(stratUpdProb)
     }
-
-    if (RandProb(self.rnGen) <= stratUpdProb) {
-      // update agent strategy - if appropriate
-      self.UpdateStrategy(x, y)
-    } else {
-      // update network structure - if appropriate
-      self.UpdateStructure(x, y)
+    // measure the stationary statistics
+    if ((self.burnin >= 0) && (g >= self.burnin)) {
+      self.stat.add(self)
+    }
+    for _, o := range self.observers {
+      o.GenEnd(self, g)
     }
-    // write out population stats
-    self.WritePStats(psWriter, g)
   }
 
-  self.DegreeHistogramData(dhWriter)
+  for _, o := range self.observers {
+    o.SimEnd(self, g)
+  }
 
   // return number of generations completed
   return g
 }
 
+// return true if the simulation should stop early
+func (self *SimEngine) stopRequested() bool {
+  return (self.stop != nil) && self.stop()
+}
+
+// Update a single randomly selected agent x by comparing its payout with the
+// payout of a randomly selected neighbor y
+func (self *SimEngine) AsyncUpdate(stratUpdProb float64) {
+  // randomly select an agent
+  x := self.vertices[RandInt(self.rnGen, int64(len(self.vertices)))]
+  // x explores a random strategy instead of updating with probability mu
+  if ((self.mu > 0) && (RandProb(self.rnGen) < self.mu)) {
+    self.explore(x)
+    return
+  }
+  // get the neighbors of x
+  Nx := self.graph.NeighborsView(x)
+  // an agent without neighbors doesn't play any games
+  if (len(Nx) <= 0) {
+    return
+  }
+  // randomly select a neighbr of x
+  y := goraph.Vertex(Nx[RandInt(self.rnGen, int64(len(Nx)))])
+  // need accurate payout information for the agents that the strategy
+  // update rule compares
+  // -- a rule that compares the whole population keeps every payout up to
+  //    date and only replays the games that changed since the last update
+  switch self.stratRule.Scope() {
+  case SCOPE_NEIGHBORHOOD:
+    self.playGamesOf(append([]goraph.Vertex{ x }, Nx...), false)
+  case SCOPE_POPULATION:
+    self.playGamesOf(self.stalePayouts(), true)
+  default:
+    self.playGamesOf([]goraph.Vertex{ x, y }, false)
+  }
+
+  if (RandProb(self.rnGen) <= stratUpdProb) {
+    // update agent strategy - if appropriate
+    self.UpdateStrategy(x, y)
+  } else if (self.payoutsValid) {
+    // update network structure - if appropriate
+    // -- the rules only change the links of x and y, so the other agents
+    //    whose links changed are found by comparing their neighbors
+    before := self.neighborCounts(x, y)
+    self.UpdateStructure(x, y)
+    after := self.neighborCounts(x, y)
+    self.markChanged(x)
+    self.markChanged(y)
+    for v, n := range after {
+      if (before[v] != n) {
+        self.markChanged(v)
+      }
+    }
+    for v, n := range before {
+      if (after[v] != n) {
+        self.markChanged(v)
+      }
+    }
+  } else {
+    // update network structure - if appropriate
+    self.UpdateStructure(x, y)
+  }
+}
+
+// return the number of times that each vertex appears as a neighbor of x and
+// of y
+func (self *SimEngine) neighborCounts(x goraph.Vertex, y goraph.Vertex) map[goraph.Vertex]int {
+  counts := make(map[goraph.Vertex]int)
+  for _, v := range self.graph.NeighborsView(x) {
+    counts[v]++
+  }
+  for _, v := range self.graph.NeighborsView(y) {
+    counts[v]++
+  }
+  return counts
+}
+
+// Reset the payouts of the specified agents and play every game that they
+// play in.  The other players of those games end up with inaccurate payouts
+// unless keep is true, in which case their payouts are restored.
+func (self *SimEngine) playGamesOf(agents []goraph.Vertex, keep bool) {
+  for _, v := range agents {
+    self.agent(v).payouts = float64(0)
+    self.agent(v).contrib = float64(0)
+  }
+  // create combined list of game sponsors without duplicates
+  sponsors := append([]goraph.Vertex{}, agents...)
+  for _, v := range agents {
+    sponsors = append(sponsors, self.graph.NeighborsView(v)...)
+  }
+  sponsors = removeDuplicates(sponsors)
+  // record the payouts of the other players
+  var others map[goraph.Vertex][2]float64
+  if (keep) {
+    reset := make(map[goraph.Vertex]bool, len(agents))
+    for _, v := range agents {
+      reset[v] = true
+    }
+    others = make(map[goraph.Vertex][2]float64)
+    for _, sponsor := range sponsors {
+      for _, v := range append(self.coplayers(sponsor), sponsor) {
+        if (!reset[v]) {
+          others[v] = [2]float64{ self.agent(v).payouts, self.agent(v).contrib }
+        }
+      }
+    }
+  }
+  // play the games
+  // -- the players slice is reused for each game
+  var players []goraph.Vertex
+  for i := 0; i < len(sponsors); i++ {
+    sponsor := sponsors[i]
+    players = append(append(players[:0], self.coplayers(sponsor)...), sponsor)
+    self.PlayGame(players)
+  }
+  for v, po := range others {
+    self.agent(v).payouts = po[0]
+    self.agent(v).contrib = po[1]
+  }
+}
+
+// Return the agents whose payouts changed since the games were last played:
+// the players of the games that a changed agent plays in.  All of the agents
+// are returned the first time.
+func (self *SimEngine) stalePayouts() []goraph.Vertex {
+  if (!self.payoutsValid) {
+    self.payoutsValid = true
+    self.changed = make(map[goraph.Vertex]bool)
+    return self.graph.Vertices()
+  }
+  var stale []goraph.Vertex
+  for v := range self.changed {
+    // -- v plays in the games sponsored by itself and its neighbors
+    for _, sponsor := range append(self.graph.Neighbors(v), v) {
+      stale = append(append(stale, self.coplayers(sponsor)...), sponsor)
+    }
+  }
+  self.changed = make(map[goraph.Vertex]bool)
+  return removeDuplicates(stale)
+}
+
+// record that the strategy or the links of the agent at v changed
+// -- only needed while the payouts of the whole population are kept up to
+//    date
+func (self *SimEngine) markChanged(v goraph.Vertex) {
+  if (self.payoutsValid) {
+    self.changed[v] = true
+  }
+}
+
+// Update all agents at once.  Every agent plays every game and then compares
+// its payout with the payout of a randomly selected neighbor.  Strategy
+// updates are based on the strategies held at the start of the step and are
+// applied together.  Structure updates are applied after the strategy updates.
+func (self *SimEngine) SyncUpdate(stratUpdProb float64) {
+  vertices := self.graph.Vertices()
+  self.payoutsValid = false
+  // every agent needs accurate payout information
+  for _, v := range vertices {
+    agent := self.agent(v)
+    agent.payouts = float64(0)
+    agent.contrib = float64(0)
+  }
+  var players []goraph.Vertex
+  for _, sponsor := range vertices {
+    players = append(append(players[:0], self.coplayers(sponsor)...), sponsor)
+    self.PlayGame(players)
+  }
+
+  // record the strategies held at the start of the step
+  cooperate := make(map[goraph.Vertex]bool, len(vertices))
+  actMods := make(map[goraph.Vertex]*simpgg.ActionModule, len(vertices))
+  for _, v := range vertices {
+    cooperate[v] = self.agent(v).cooperate
+    actMods[v] = self.agent(v).actMod
+  }
+
+  // each agent selects a neighbor and the type of update to perform
+  var imitations []goraph.Edge
+  var rewires []goraph.Edge
+  var explorers []goraph.Vertex
+  for _, x := range vertices {
+    // x explores a random strategy instead of updating with probability mu
+    if ((self.mu > 0) && (RandProb(self.rnGen) < self.mu)) {
+      explorers = append(explorers, x)
+      continue
+    }
+    Nx := self.graph.NeighborsView(x)
+    if (len(Nx) <= 0) {
+      continue
+    }
+    y := Nx[RandInt(self.rnGen, int64(len(Nx)))]
+    if (RandProb(self.rnGen) <= stratUpdProb) {
+      learner, model, ok := self.stratRule.Select(self, x, y)
+      if (ok) {
+        imitations = append(imitations, goraph.Edge{U: learner, V: model})
+      }
+    } else {
+      rewires = append(rewires, goraph.Edge{U: x, V: y})
+    }
+  }
+
+  // apply the strategy updates
+  for _, e := range imitations {
+    self.imitate(e.U, cooperate[e.V], actMods[e.V])
+  }
+  for _, x := range explorers {
+    self.explore(x)
+  }
+  // apply the structure updates
+  // -- an earlier update may already have removed the link between x and y
+  for _, e := range rewires {
+    if (self.graph.HasEdge(e.U, e.V)) {
+      self.UpdateStructure(e.U, e.V)
+    }
+  }
+}
+
 func (self *SimEngine) SimComplete(genNum int32) bool {
+  if (self.mu > 0) {
+    // exploration means that no strategy is ever eliminated for good
+    return (genNum >= self.numGens)
+  }
+  if (self.useRep) {
+    // agents may change actions at any time so the simulation is complete
+    // once all agents use the same action module
+    return (genNum >= self.numGens) || (len(self.amCounts) <= 1)
+  }
   return (genNum >= self.numGens) || (self.Nc >= self.numAgents) || (self.Nd >= self.numAgents)
 }
 
-// calculate the public goods payout for the specified set of players
+// calculate the public goods payout for the specified set of players when
+// each cooperator contributes the full cost to the game
 func (self *SimEngine) CalcPayouts(players []goraph.Vertex) (Pc, Pd float64) {
   Nc := int32(0)
   Nd := int32(0)
   // count up cooperators and defectors
   for i := 0; i < len(players); i++ {
-    if (self.agents[players[i]].cooperate) {
+    if (self.agent(players[i]).cooperate) {
       Nc += 1
     } else {
       Nd += 1
@@ -148,96 +479,315 @@ func (self *SimEngine) CalcPayouts(players []goraph.Vertex) (Pc, Pd float64) {
   return Pc, Pd
 }
 
+// Set the contribution scheme used by cooperators
+func (self *SimEngine) SetContribScheme(cscheme int32) {
+  self.cscheme = cscheme
+  self.payoutsValid = false
+}
+
+// return the amount that the agent at vertex v contributes to each game it
+// plays when it cooperates
+func (self *SimEngine) Contribution(v goraph.Vertex) float64 {
+  switch self.cscheme {
+  case CONTRIB_INDIVIDUAL:
+    return float64(self.cost)/float64(self.graph.Degree(v) + 1)
+  case CONTRIB_DEGREE:
+    return float64(self.cost)*float64(self.graph.Degree(v) + 1)/float64(self.avgdeg + 1)
+  default:
+    return float64(self.cost)
+  }
+}
+
 // play a public goods game with the specified set of agents
-func (self *SimEngine) PlayGame(players []goraph.Vertex) {
-  // calculate payouts
-  Pc, Pd := self.CalcPayouts(players)
+// -- the contribution made by each player is returned
+func (self *SimEngine) PlayGame(players []goraph.Vertex) []float64 {
+  if (self.useRep) {
+    return self.PlayRepGame(players)
+  }
+  // collect contributions
+  contribs := make([]float64, len(players))
+  pool := float64(0)
+  for i := 0; i < len(players); i++ {
+    if (self.agent(players[i]).cooperate) {
+      contribs[i] = self.Contribution(players[i])
+      pool += contribs[i]
+    }
+  }
   // distribute payouts
+  share := float64(self.mult)*pool/float64(len(players))
   for i := 0; i < len(players); i++ {
-    player := self.agents[players[i]]
-    if (player.cooperate) {
-      player.payouts += Pc
-    } else {
-      player.payouts += Pd
+    player := self.agent(players[i])
+    player.payouts += share - contribs[i]
+    player.contrib += contribs[i]
+  }
+  return contribs
+}
+
+// calculate the reputation of the specified set of players
+// -- the group is GOOD if at least half of the players are GOOD
+func (self *SimEngine) GroupRep(players []goraph.Vertex) simbase.Rep {
+  ngood := 0
+  for i := 0; i < len(players); i++ {
+    if (self.agent(players[i]).rep == simbase.GOOD) {
+      ngood += 1
     }
   }
+  if (2*ngood >= len(players)) {
+    return simbase.GOOD
+  } else {
+    return simbase.BAD
+  }
 }
 
-// update the strategy of agent x based on the payouts
-func (self *SimEngine) UpdateStrategy(x goraph.Vertex, y goraph.Vertex) {
-  // get the agents
-  agentx := self.agents[x]
-  agenty := self.agents[y]
-
-  // calculate the probability that x's strategy will be updated
-  Pe := Fermi(self.betae, float64(agenty.payouts), float64(agentx.payouts))
-
-  // update x's strategy if appropriate
-  if ((RandProb(self.rnGen) < Pe) && (agentx.cooperate != agenty.cooperate)) {
-    if (agentx.cooperate) {
-      self.Nc -= 1
-      self.Nd += 1
+// play a public goods game in which each player uses its action module to
+// decide whether to contribute and whether to punish the non-contributors
+// -- the contribution made by each player is returned
+func (self *SimEngine) PlayRepGame(players []goraph.Vertex) []float64 {
+  // all players judge the group by its reputation before the game
+  groupRep := self.GroupRep(players)
+
+  // ask each player to choose its actions
+  contribute := make([]bool, len(players))
+  punish := make([]bool, len(players))
+  Nc := int32(0)
+  Np := int32(0)
+  for i := 0; i < len(players); i++ {
+    agent := self.agent(players[i])
+    contribute[i] = agent.actMod.ChooseContribute(agent.rep, groupRep, self.rnGen)
+    punish[i] = agent.actMod.ChoosePunish(agent.rep, groupRep, self.rnGen)
+    if (contribute[i]) { Nc += 1 }
+    if (punish[i]) { Np += 1 }
+  }
+  Nd := int32(len(players)) - Nc
+
+  // collect contributions
+  contribs := make([]float64, len(players))
+  pool := float64(0)
+  for i := 0; i < len(players); i++ {
+    if (contribute[i]) {
+      contribs[i] = self.Contribution(players[i])
+      pool += contribs[i]
+    }
+  }
+
+  // distribute payouts
+  share := float64(self.mult)*pool/float64(len(players))
+  for i := 0; i < len(players); i++ {
+    agent := self.agent(players[i])
+    agent.payouts += share - contribs[i]
+    agent.contrib += contribs[i]
+    // -- players don't punish themselves
+    punishers := Np
+    if (punish[i]) { punishers -= 1 }
+    targets := Nd
+    if (!contribute[i]) { targets -= 1 }
+    if (!contribute[i]) {
+      agent.payouts -= self.fine*float64(punishers)
+    }
+    if (punish[i]) {
+      agent.payouts -= self.pcost*float64(targets)
+    }
+    // contributors earn a GOOD reputation and non-contributors a BAD one
+    if (contribute[i]) {
+      agent.rep = simbase.GOOD
     } else {
-      self.Nd -= 1
-      self.Nc += 1
+      agent.rep = simbase.BAD
     }
-    agentx.cooperate = agenty.cooperate
+    // the agent's most recent action determines its strategy
+    self.setCooperate(players[i], contribute[i])
   }
+  return contribs
 }
 
-// update the structure of the network based on the payouts
-func (self *SimEngine) UpdateStructure(x goraph.Vertex, y goraph.Vertex) {
-  // check to see if y is a cooperator
-  agenty := self.agents[y]
-  if (agenty.cooperate) {
-    // link to cooperator is satisfactory - no network update required
+// set the strategy of the agent at v and update the strategy counts
+func (self *SimEngine) setCooperate(v goraph.Vertex, cooperate bool) {
+  agent := self.agent(v)
+  if (agent.cooperate == cooperate) {
     return
   }
-  agentx := self.agents[x]
+  if (agent.cooperate) {
+    self.Nc -= 1
+    self.Nd += 1
+  } else {
+    self.Nd -= 1
+    self.Nc += 1
+  }
+  agent.cooperate = cooperate
+  self.notifyStrategyChange(v)
+}
 
-  // get the list of y's neighbors minus agent x
-  Ny := self.graph.Neighbors(y)
-  Ny = RemoveVertexFromSlice(Ny, x)
+// update the strategy of an agent after x has been paired with its neighbor y
+// -- the strategy update rule selects the agent that updates its strategy
+func (self *SimEngine) UpdateStrategy(x goraph.Vertex, y goraph.Vertex) {
+  // select the agent that updates its strategy and the agent it imitates
+  learner, model, ok := self.stratRule.Select(self, x, y)
 
-  // if x is y's last neighbor then structure update cannot be done
-  if (len(Ny) <= 0) {
-    return
+  // update the learner's strategy if appropriate
+  if (ok) {
+    agentm := self.agent(model)
+    self.imitate(learner, agentm.cooperate, agentm.actMod)
+  }
+}
+
+// return the agent at vertex v
+func (self *SimEngine) agent(v goraph.Vertex) *Agent {
+  agent, _ := self.agents.Get(v)
+  return agent
+}
+
+// return the agents that play in the game sponsored by v along with v
+// -- on a directed graph agents learn from their out-neighbors and play the
+//    games sponsored by their out-neighbors, so v plays with its in-neighbors
+func (self *SimEngine) coplayers(v goraph.Vertex) []goraph.Vertex {
+  if digraph, ok := goraph.AsDirected(self.graph); ok {
+    return digraph.InNeighborsView(v)
   }
+  return self.graph.NeighborsView(v)
+}
 
-  // calculate the probability that x's link to y will be updated
-  Pa := Fermi(self.betaa, float64(agentx.payouts), float64(agenty.payouts))
+// Switch the graph to the specified implementation.  The vertices and edges
+// are kept but the order of each vertex's neighbors may change.  The graph is
+// left untouched when it already uses the requested implementation.
+func (self *SimEngine) SetGraphImpl(gimpl int32) {
+  switch gimpl {
+  case GIMPL_DENSE:
+    if _, ok := self.graph.Unwrap().(*goraph.DenseGraph); !ok {
+      self.holdAgents(goraph.NewDenseGraphFrom(self.graph.Unwrap()))
+    }
+  default:
+    gimpl = GIMPL_ADJLIST
+    if _, ok := self.graph.Unwrap().(*goraph.AdjacencyList); !ok {
+      self.holdAgents(goraph.NewAdjacencyListFrom(self.graph.Unwrap()))
+    }
+  }
+  self.gimpl = gimpl
+}
 
-  // switch x's link with y if appropriate
-  if (RandProb(self.rnGen) <= Pa) {
-    // remove all shared neighbors
-    Nx := self.graph.Neighbors(x)
-    Ny = RemoveVerticesFromSlice(Ny, Nx)
+// Replace the graph that holds the agents, e.g. with a clone of a graph that
+// is shared by several simulations.  The graph is used as is, so it must not
+// be changed by anything else while the simulation runs.  Vertex v of the
+// graph holds the agent that vertex v of the current graph holds, so the
+// graphs must have the same vertices.
+func (self *SimEngine) SetGraph(graph goraph.Graph) {
+  vertices := graph.Vertices()
+  if (len(vertices) != len(self.vertices)) {
+    panic(fmt.Sprintf("graph has %d vertices for %d agents", len(vertices), len(self.vertices)))
+  }
+  for _, v := range vertices {
+    if _, ok := self.agents.Get(v); !ok {
+      panic(fmt.Sprintf("graph vertex does not hold an agent: %v", v))
+    }
+  }
+  self.holdAgents(graph)
+  self.payoutsValid = false
+}
 
-    // vx's new neighbor
-    var newy goraph.Vertex
+// move the agents to the same vertices of the specified graph
+func (self *SimEngine) holdAgents(graph goraph.Graph) {
+  attributed := goraph.NewAttributed(graph)
+  agents := goraph.NewVertexAttr[*Agent](attributed, AGENT_ATTR)
+  for _, v := range self.vertices {
+    agents.Set(v, self.agent(v))
+  }
+  self.graph = attributed
+  self.agents = agents
+}
+
+// Remove the vertex v along with the agent that it holds.  The agent's
+// strategy no longer counts towards the population and its neighbors lose
+// the link to it along with the game that it sponsored.
+func (self *SimEngine) RemoveVertex(v goraph.Vertex) {
+  agent, ok := self.agents.Get(v)
+  if (!ok) {
+    panic(fmt.Sprintf("graph vertex does not hold an agent: %v", v))
+  }
+  if (agent.cooperate) {
+    self.Nc -= 1
+  } else {
+    self.Nd -= 1
+  }
+  if (self.useRep) {
+    self.removeAMCount(agent.actMod.GetBits())
+  }
+  // -- the agent attribute is removed along with the vertex
+  self.graph.RemoveVertex(v)
+  i := sort.Search(len(self.vertices), func(i int) bool { return self.vertices[i] >= v })
+  self.vertices = append(self.vertices[:i], self.vertices[i+1:]...)
+  self.numAgents -= 1
+  self.payoutsValid = false
+}
 
-    if (len(Ny) > 0) { // y has some neighbors that are not x's neighbors
-      // select new neighbor from y's neighbors
-      newy = Ny[RandInt(self.rnGen, int64(len(Ny)))]
-    } else { // y doesn't have any neighbors that are not also x's neighbors
-      // get all vertices except x, y and x's neighbors
-      // -- Note that Nx includes y
-      available := RemoveVerticesFromSlice(self.graph.Vertices(), append(Nx, x))
+// Set the rule used to update the strategies of the agents
+func (self *SimEngine) SetStrategyRule(rule StrategyRule) {
+  self.stratRule = rule
+  self.payoutsValid = false
+}
 
-      // chose an newy if some vertices are available
-      if (len(available) > 0) { // some nodes are available to choose from
-        newy = available[RandInt(self.rnGen, int64(len(available)))]
-      } else { // no nodes are available to choose from
-        return
-      }
+// the agent at v adopts the specified strategy
+// -- agents that use action modules adopt the action module instead
+func (self *SimEngine) imitate(v goraph.Vertex, cooperate bool, actMod *simpgg.ActionModule) {
+  agent := self.agent(v)
+  if (self.useRep) {
+    if (!agent.actMod.SameBits(actMod)) {
+      self.removeAMCount(agent.actMod.GetBits())
+      agent.actMod = actMod.Copy()
+      self.amCounts[agent.actMod.GetBits()] += 1
+      self.markChanged(v)
+      self.notifyStrategyChange(v)
     }
+  } else if (agent.cooperate != cooperate) {
+    self.markChanged(v)
+    self.setCooperate(v, cooperate)
+  }
+}
 
-    // replace y with newy
-    self.graph.RemoveEdge(x, y)
-    self.graph.AddEdge(x, newy)
+// the agent at vertex x switches to the other strategy
+// -- agents that use action modules switch to a random action module
+func (self *SimEngine) explore(x goraph.Vertex) {
+  agent := self.agent(x)
+  self.markChanged(x)
+  if (self.useRep) {
+    self.removeAMCount(agent.actMod.GetBits())
+    agent.actMod = NewRepAgent(self.pexeerr, self.rnGen).actMod
+    self.amCounts[agent.actMod.GetBits()] += 1
+    self.notifyStrategyChange(x)
+  } else {
+    self.setCooperate(x, !agent.cooperate)
   }
 }
 
+// Set the probability that an update is a random exploration of strategies
+func (self *SimEngine) SetExploration(mu float64) {
+  self.mu = mu
+}
+
+// Set the number of generations to complete before the stationary
+// statistics are measured.  They aren't measured if burnin is negative,
+// which is the default, as measuring them after every update slows down
+// asynchronous runs.
+func (self *SimEngine) SetBurnIn(burnin int32) {
+  self.burnin = burnin
+}
+
+// decrement the number of agents using the specified action module
+// -- modules that are no longer used are removed from the counts
+func (self *SimEngine) removeAMCount(bits int) {
+  self.amCounts[bits] -= 1
+  if (self.amCounts[bits] <= 0) {
+    delete(self.amCounts, bits)
+  }
+}
+
+// update the structure of the network based on the payouts
+func (self *SimEngine) UpdateStructure(x goraph.Vertex, y goraph.Vertex) {
+  self.structRule.UpdateStructure(self, x, y)
+}
+
+// Set the rule used to update the structure of the network
+func (self *SimEngine) SetStructureRule(rule StructureRule) {
+  self.structRule = rule
+}
+
 // remove the duplicates from the slice
 // see https://play.golang.org/p/HJEm6qy5wb
 func removeDuplicates(a []goraph.Vertex) []goraph.Vertex {
@@ -262,6 +812,19 @@ func (self *SimEngine) String() string {
   s = fmt.Sprintf("%s\n  \"%v\":%d,", s, "cost", self.cost)
   s = fmt.Sprintf("%s\n  \"%v\":%.5f,", s, "betae", self.betae)
   s = fmt.Sprintf("%s\n  \"%v\":%.5f,", s, "betaa", self.betaa)
+  s = fmt.Sprintf("%s\n  \"%v\":\"%s\",", s, "step", self.StepUnit())
+  s = fmt.Sprintf("%s\n  \"%v\":\"%v\",", s, "srule", self.structRule)
+  s = fmt.Sprintf("%s\n  \"%v\":\"%v\",", s, "urule", self.stratRule)
+  s = fmt.Sprintf("%s\n  \"%v\":%d,", s, "cscheme", self.cscheme)
+  s = fmt.Sprintf("%s\n  \"%v\":%.5f,", s, "mu", self.mu)
+  s = fmt.Sprintf("%s\n  \"%v\":%d,", s, "burnin", self.burnin)
+  s = fmt.Sprintf("%s\n  \"%v\":%d,", s, "gimpl", self.gimpl)
+  s = fmt.Sprintf("%s\n  \"%v\":%t,", s, "rep", self.useRep)
+  if (self.useRep) {
+    s = fmt.Sprintf("%s\n  \"%v\":%.5f,", s, "fine", self.fine)
+    s = fmt.Sprintf("%s\n  \"%v\":%.5f,", s, "pcost", self.pcost)
+    s = fmt.Sprintf("%s\n  \"%v\":%.5f,", s, "pexeerr", self.pexeerr)
+  }
   s = fmt.Sprintf("%s\n  \"%v\":%.5f", s, "W", self.W)
   s = fmt.Sprintf("%s\n  }", s)
   return s
@@ -270,7 +833,8 @@ func (self *SimEngine) String() string {
 // write the header for the population statistics file
 func (self *SimEngine) WritePStatsHeader(w io.Writer) {
   // write out headers
-  fmt.Fprintf(w, "%s,%s,%s\n", "g", "Pc", "Pd")
+  // -- the first column is labelled with the unit of time
+  fmt.Fprintf(w, "%s,%s,%s\n", self.StepUnit(), "Pc", "Pd")
 }
 
 // write population statistics for current gen to pstats file
@@ -286,7 +850,7 @@ func (self *SimEngine) DegreeHistogramData(w io.Writer) {
   // write data
   var strat string
   for _, v := range self.graph.Vertices() {
-    if (self.agents[v].cooperate) {
+    if (self.agent(v).cooperate) {
       strat = "C"
     } else {
       strat = "D"
@@ -294,3 +858,20 @@ func (self *SimEngine) DegreeHistogramData(w io.Writer) {
     fmt.Fprintf(w, "%v,%s,%d\n", v, strat, self.graph.Degree(v))
   }
 }
+
+// Write the graph as a goraph edge list with the strategy and payouts of
+// each agent as vertex attributes
+func (self *SimEngine) WriteGraph(w io.Writer) error {
+  g := goraph.NewAttributed(self.graph.Unwrap())
+  strategy := goraph.NewVertexAttr[string](g, "strategy")
+  payouts := goraph.NewVertexAttr[float64](g, "payouts")
+  for _, v := range self.graph.Vertices() {
+    if (self.agent(v).cooperate) {
+      strategy.Set(v, "C")
+    } else {
+      strategy.Set(v, "D")
+    }
+    payouts.Set(v, self.agent(v).payouts)
+  }
+  return goraph.WriteEdgeList(w, g)
+}
@@ -4,6 +4,10 @@ import "testing"
 import "testutil"
 import "goraph"
 import "math"
+import "bytes"
+import "strings"
+import "simbase"
+import "simpgg"
 
 func NewTestSimEngine() *SimEngine {
   numAgents := int32(7)
@@ -18,6 +22,108 @@ func NewTestSimEngine() *SimEngine {
   return NewSimEngine(numAgents, numGens, gtype, avgdeg, mult, cost, W, betae, betaa)
 }
 
+func NewTestRepSimEngine() *SimEngine {
+  numAgents := int32(7)
+  gtype := int32(0)
+  avgdeg := int32(4)
+  numGens := int32(5)
+  mult := int32(3)
+  cost := int32(1)
+  W := float64(0)
+  betae := math.Inf(+1)
+  betaa := math.Inf(+1)
+  fine := float64(3)
+  pcost := float64(1)
+  pexeerr := float32(0)
+  return NewRepSimEngine(numAgents, numGens, gtype, avgdeg, mult, cost, W, betae, betaa,
+                         fine, pcost, pexeerr)
+}
+
+func TestPlayRepGame(u *testing.T) {
+  simeng := NewTestRepSimEngine()
+  vertices := simeng.graph.Vertices()
+  goraph.VertexSlice(vertices).Sort()
+  players := vertices[:3]
+
+  // player 0 always contributes and punishes
+  simeng.agent(players[0]).actMod = simpgg.NewActionModule(true, true, true, true,
+                                                            true, true, true, true, 0)
+  // player 1 never contributes or punishes
+  simeng.agent(players[1]).actMod = simpgg.NewActionModule(false, false, false, false,
+                                                            false, false, false, false, 0)
+  // player 2 always contributes and never punishes
+  simeng.agent(players[2]).actMod = simpgg.NewActionModule(true, true, true, true,
+                                                            false, false, false, false, 0)
+
+  simeng.PlayGame(players)
+
+  // share of the pool is r*c*Nc/N = 3*1*2/3 = 2
+  testutil.AssertFloat64Equal(u, simeng.agent(players[0]).payouts, 2-1-1)
+  testutil.AssertFloat64Equal(u, simeng.agent(players[1]).payouts, 2-3)
+  testutil.AssertFloat64Equal(u, simeng.agent(players[2]).payouts, 2-1)
+
+  // contributors are GOOD and non-contributors are BAD
+  testutil.AssertTrue(u, simeng.agent(players[0]).rep == simbase.GOOD)
+  testutil.AssertTrue(u, simeng.agent(players[1]).rep == simbase.BAD)
+  testutil.AssertTrue(u, simeng.agent(players[2]).rep == simbase.GOOD)
+
+  // strategy counts reflect the most recent actions
+  testutil.AssertTrue(u, simeng.agent(players[0]).cooperate)
+  testutil.AssertFalse(u, simeng.agent(players[1]).cooperate)
+  testutil.AssertTrue(u, simeng.agent(players[2]).cooperate)
+  Nc := int32(0)
+  for _, v := range simeng.vertices {
+    agent := simeng.agent(v)
+    if (agent.cooperate) { Nc += 1 }
+  }
+  testutil.AssertInt32Equal(u, simeng.Nc, Nc)
+  testutil.AssertInt32Equal(u, simeng.Nd, simeng.numAgents-Nc)
+}
+
+func TestGroupRep(u *testing.T) {
+  simeng := NewTestRepSimEngine()
+  players := simeng.graph.Vertices()[:4]
+
+  for _, v := range players {
+    simeng.agent(v).rep = simbase.GOOD
+  }
+  testutil.AssertTrue(u, simeng.GroupRep(players) == simbase.GOOD)
+  simeng.agent(players[0]).rep = simbase.BAD
+  simeng.agent(players[1]).rep = simbase.BAD
+  testutil.AssertTrue(u, simeng.GroupRep(players) == simbase.GOOD)
+  simeng.agent(players[2]).rep = simbase.BAD
+  testutil.AssertTrue(u, simeng.GroupRep(players) == simbase.BAD)
+}
+
+func TestUpdateStrategyRep(u *testing.T) {
+  simeng := NewTestRepSimEngine()
+
+  // select an agent x and one of its neighbors y
+  x := simeng.graph.Vertices()[0]
+  y := simeng.graph.Neighbors(x)[0]
+  simeng.agent(x).payouts = 99
+  simeng.agent(y).payouts = 100
+
+  // make sure x and y use different action modules
+  simeng.removeAMCount(simeng.agent(x).actMod.GetBits())
+  flipped := simpgg.NewActionModule(simeng.agent(y).actMod.GetBit(0) == 0, true, true, true,
+                                    true, true, true, true, 0)
+  simeng.agent(x).actMod = flipped
+  simeng.amCounts[flipped.GetBits()] += 1
+
+  // x imitates y since y has the higher payout and betae is infinite
+  simeng.UpdateStrategy(x, y)
+  testutil.AssertTrue(u, simeng.agent(x).actMod.SameBits(simeng.agent(y).actMod))
+  testutil.AssertFalse(u, simeng.agent(x).actMod == simeng.agent(y).actMod)
+
+  // action module counts still cover every agent
+  total := int32(0)
+  for _, count := range simeng.amCounts {
+    total += count
+  }
+  testutil.AssertInt32Equal(u, total, simeng.numAgents)
+}
+
 func TestPlayGame(u *testing.T) {
   simeng := NewTestSimEngine()
   vertices := simeng.graph.Vertices()
@@ -32,7 +138,7 @@ func TestPlayGame(u *testing.T) {
   var agent *Agent
   var po float64
   for _, v := range vertices {
-    agent = simeng.agents[v]
+    agent = simeng.agent(v)
     if (agent.cooperate) {
       po = Pc
     } else {
@@ -48,10 +154,10 @@ func TestUpdateStrategy(u *testing.T) {
   // select an agent x
   x := simeng.graph.Vertices()[0]
   // set agent x's payout to 99
-  simeng.agents[x].payouts = 99
+  simeng.agent(x).payouts = 99
   // make sure x is a cooperator
-  if (!simeng.agents[x].cooperate) {
-    simeng.agents[x].cooperate = true
+  if (!simeng.agent(x).cooperate) {
+    simeng.agent(x).cooperate = true
     simeng.Nc += 1
     simeng.Nd -= 1
   }
@@ -59,10 +165,10 @@ func TestUpdateStrategy(u *testing.T) {
   Nx := simeng.graph.Neighbors(x)
   y := Nx[0]
   // set y's payout equal to 100
-  simeng.agents[y].payouts = 100
+  simeng.agent(y).payouts = 100
   // make sure y is a defectors
-  if (simeng.agents[y].cooperate) {
-    simeng.agents[y].cooperate = false
+  if (simeng.agent(y).cooperate) {
+    simeng.agent(y).cooperate = false
     simeng.Nc -= 1
     simeng.Nd += 1
   }
@@ -73,8 +179,8 @@ func TestUpdateStrategy(u *testing.T) {
   // update structure and make sure that y is emoved from x's neighbors
   simeng.UpdateStrategy(x, y)
 
-  testutil.AssertFalse(u, simeng.agents[x].cooperate)
-  testutil.AssertFalse(u, simeng.agents[y].cooperate)
+  testutil.AssertFalse(u, simeng.agent(x).cooperate)
+  testutil.AssertFalse(u, simeng.agent(y).cooperate)
   testutil.AssertInt32Equal(u, simeng.Nc, nc-1)
   testutil.AssertInt32Equal(u, simeng.Nd, nd+1)
 }
@@ -85,14 +191,14 @@ func TestUpdateStructure(u *testing.T) {
   // select an agent x
   x := simeng.graph.Vertices()[0]
   // set agent x's payout to 100
-  simeng.agents[x].payouts = 100
+  simeng.agent(x).payouts = 100
   // select one of its neighbors
   Nx := simeng.graph.Neighbors(x)
   y := Nx[0]
   // set y's payout equal to 99
-  simeng.agents[y].payouts = 99
+  simeng.agent(y).payouts = 99
   // make sure y is a defectors
-  simeng.agents[y].cooperate = false
+  simeng.agent(y).cooperate = false
 
   // update structure and make sure that y is emoved from x's neighbors
   simeng.UpdateStructure(x, y)
@@ -112,13 +218,13 @@ func TestUpdateStructure2(u *testing.T) {
   // select an agent x
   x := vertices[0]
   // set agent x's payout to 100
-  simeng.agents[x].payouts = 100
+  simeng.agent(x).payouts = 100
   // select an agent y
   y := vertices[1]
   // set y's payout equal to 99
-  simeng.agents[y].payouts = 99
+  simeng.agent(y).payouts = 99
   // make sure y is a defectors
-  simeng.agents[y].cooperate = false
+  simeng.agent(y).cooperate = false
 
   // --------------------------------------
   // test case when x is y's only neighbor
@@ -236,3 +342,351 @@ func TestUpdateStructure2(u *testing.T) {
   testutil.AssertTrue(u, goraph.VertexSlice(Ny).Contains(x))
   testutil.AssertTrue(u, goraph.VertexSlice(Ny).Contains(loner))
 }
+
+func TestStepModes(u *testing.T) {
+  modes := []int32{STEP_ASYNC, STEP_MC, STEP_SYNC}
+  units := []string{"update", "mcs", "sync"}
+  for i, mode := range modes {
+    simeng := NewTestSimEngine()
+    simeng.SetStepMode(mode)
+    var ps, dh bytes.Buffer
+    g := simeng.RunSim(&ps, &dh)
+
+    // the pstats file is labelled with the unit of time
+    testutil.AssertTrue(u, strings.HasPrefix(ps.String(), units[i] + ",Pc,Pd\n"))
+    testutil.AssertTrue(u, g <= simeng.numGens)
+
+    // strategy counts remain consistent
+    Nc := int32(0)
+    for _, v := range simeng.vertices {
+      agent := simeng.agent(v)
+      if (agent.cooperate) { Nc += 1 }
+    }
+    testutil.AssertInt32Equal(u, simeng.Nc, Nc)
+    testutil.AssertInt32Equal(u, simeng.Nd, simeng.numAgents-Nc)
+  }
+}
+
+func TestSyncUpdate(u *testing.T) {
+  simeng := NewTestSimEngine()
+  vertices := simeng.graph.Vertices()
+  goraph.VertexSlice(vertices).Sort()
+
+  // a single defector among cooperators earns the highest payout
+  for _, v := range vertices {
+    simeng.agent(v).cooperate = true
+  }
+  simeng.agent(vertices[0]).cooperate = false
+  simeng.Nc = simeng.numAgents - 1
+  simeng.Nd = 1
+
+  // all updates are based on the strategies at the start of the step so
+  // only the defector's neighbors can become defectors
+  simeng.SyncUpdate(float64(1))
+  N0 := goraph.VertexSlice(simeng.graph.Neighbors(vertices[0]))
+  for _, v := range vertices {
+    if ((v != vertices[0]) && !N0.Contains(v)) {
+      testutil.AssertTrue(u, simeng.agent(v).cooperate)
+    }
+  }
+  testutil.AssertTrue(u, simeng.Nd <= int32(len(N0)) + 1)
+}
+
+func TestContribSchemes(u *testing.T) {
+  schemes := []int32{CONTRIB_GAME, CONTRIB_INDIVIDUAL, CONTRIB_DEGREE}
+  for _, cscheme := range schemes {
+    simeng := NewTestSimEngine()
+    simeng.SetContribScheme(cscheme)
+    vertices := simeng.graph.Vertices()
+    goraph.VertexSlice(vertices).Sort()
+
+    // make the degrees heterogeneous
+    simeng.graph.RemoveEdge(vertices[0], simeng.graph.Neighbors(vertices[0])[0])
+    for _, v := range simeng.vertices {
+      agent := simeng.agent(v)
+      agent.cooperate = true
+    }
+
+    players := append(simeng.graph.Neighbors(vertices[0]), vertices[0])
+    contribs := simeng.PlayGame(players)
+
+    pool := float64(0)
+    for i, v := range players {
+      k := float64(simeng.graph.Degree(v))
+      var expected float64
+      switch cscheme {
+      case CONTRIB_INDIVIDUAL:
+        expected = float64(simeng.cost)/(k+1)
+      case CONTRIB_DEGREE:
+        expected = float64(simeng.cost)*(k+1)/float64(simeng.avgdeg+1)
+      default:
+        expected = float64(simeng.cost)
+      }
+      testutil.AssertFloat64Equal(u, contribs[i], expected)
+      testutil.AssertFloat64Equal(u, simeng.agent(v).contrib, expected)
+      pool += expected
+    }
+    // every player receives the same share of the pool
+    share := float64(simeng.mult)*pool/float64(len(players))
+    for i, v := range players {
+      testutil.AssertFloat64Equal(u, simeng.agent(v).payouts, share - contribs[i])
+    }
+  }
+}
+
+func TestContribTracking(u *testing.T) {
+  schemes := []int32{CONTRIB_GAME, CONTRIB_INDIVIDUAL, CONTRIB_DEGREE}
+  for _, useRep := range []bool{ false, true } {
+    for _, cscheme := range schemes {
+      var simeng *SimEngine
+      if (useRep) {
+        simeng = NewTestRepSimEngine()
+      } else {
+        simeng = NewTestSimEngine()
+      }
+      simeng.SetContribScheme(cscheme)
+      vertices := simeng.graph.Vertices()
+      goraph.VertexSlice(vertices).Sort()
+      // make the degrees heterogeneous
+      simeng.graph.RemoveEdge(vertices[0], simeng.graph.Neighbors(vertices[0])[0])
+      // -- even agents always contribute and odd agents never do
+      for _, v := range vertices {
+        agent := simeng.agent(v)
+        agent.cooperate = (v%2 == 0)
+        agent.actMod = simpgg.NewActionModule(v%2 == 0, v%2 == 0, v%2 == 0, v%2 == 0,
+                                              false, false, false, false, 0)
+      }
+
+      // each agent plays the k+1 games sponsored by itself and its neighbors
+      // and its contribution to each game is added to its total
+      sums := make(map[goraph.Vertex]float64)
+      for _, sponsor := range vertices {
+        players := append(simeng.graph.Neighbors(sponsor), sponsor)
+        contribs := simeng.PlayGame(players)
+        for i, v := range players {
+          if (v%2 == 0) {
+            testutil.AssertFloat64Equal(u, contribs[i], simeng.Contribution(v))
+          } else {
+            testutil.AssertFloat64Equal(u, contribs[i], 0)
+          }
+          sums[v] += contribs[i]
+        }
+      }
+      for _, v := range vertices {
+        contrib := simeng.agent(v).contrib
+        testutil.AssertTrue(u, math.Abs(contrib - sums[v]) < 1e-9)
+        k := float64(simeng.graph.Degree(v))
+        switch {
+        case (v%2 != 0):
+          testutil.AssertFloat64Equal(u, contrib, 0)
+        case (cscheme == CONTRIB_INDIVIDUAL):
+          // -- the cost is split across the agent's games
+          testutil.AssertTrue(u, math.Abs(contrib - float64(simeng.cost)) < 1e-9)
+        case (cscheme == CONTRIB_DEGREE):
+          testutil.AssertTrue(u, math.Abs(contrib - float64(simeng.cost)*(k+1)*(k+1)/float64(simeng.avgdeg+1)) < 1e-9)
+        default:
+          testutil.AssertFloat64Equal(u, contrib, float64(simeng.cost)*(k+1))
+        }
+      }
+    }
+  }
+}
+
+func TestExploration(u *testing.T) {
+  // with mu = 1 every update is an exploration
+  simeng := NewTestSimEngine()
+  simeng.SetExploration(1)
+  Nc := simeng.Nc
+  simeng.AsyncUpdate(1)
+  testutil.AssertTrue(u, (simeng.Nc == Nc+1) || (simeng.Nc == Nc-1))
+  testutil.AssertInt32Equal(u, simeng.Nc+simeng.Nd, simeng.numAgents)
+
+  // exploring agents in a fixed population make the run last until the
+  // generation limit
+  for _, v := range simeng.vertices {
+    agent := simeng.agent(v)
+    agent.cooperate = true
+  }
+  simeng.Nc = simeng.numAgents
+  simeng.Nd = 0
+  testutil.AssertFalse(u, simeng.SimComplete(0))
+  testutil.AssertTrue(u, simeng.SimComplete(simeng.numGens))
+
+  // agents that use action modules switch action modules
+  simeng = NewTestRepSimEngine()
+  simeng.SetExploration(1)
+  simeng.SetStepMode(STEP_SYNC)
+  simeng.SyncUpdate(1)
+  count := int32(0)
+  for _, n := range simeng.amCounts {
+    count += n
+  }
+  testutil.AssertInt32Equal(u, count, simeng.numAgents)
+}
+
+func TestStationaryStats(u *testing.T) {
+  simeng := NewTestSimEngine()
+  simeng.SetExploration(0.1)
+  simeng.SetBurnIn(2)
+  var ps, dh bytes.Buffer
+  g := simeng.RunSim(&ps, &dh)
+  testutil.AssertInt32Equal(u, g, simeng.numGens)
+
+  // only the generations after the burn in are measured
+  s := simeng.Summary(g)
+  testutil.AssertInt32Equal(u, s.statgens, simeng.numGens-2)
+  testutil.AssertTrue(u, (s.statpc >= 0) && (s.statpc <= 1))
+  // the rewiring rule preserves the degree of the regular ring
+  testutil.AssertFloat64Equal(u, s.statkavg, 4)
+
+  // nothing is measured without a measurement window
+  simeng = NewTestSimEngine()
+  simeng.SetBurnIn(simeng.numGens)
+  g = simeng.RunSim(&ps, &dh)
+  s = simeng.Summary(g)
+  testutil.AssertInt32Equal(u, s.statgens, 0)
+  testutil.AssertTrue(u, math.IsNaN(s.statpc))
+
+  // the stationary statistics aren't measured by default
+  simeng = NewTestSimEngine()
+  g = simeng.RunSim(&ps, &dh)
+  s = simeng.Summary(g)
+  testutil.AssertInt32Equal(u, s.statgens, 0)
+  testutil.AssertTrue(u, math.IsNaN(s.statkavg))
+}
+
+func TestSetGraphImpl(u *testing.T) {
+  simeng := NewTestSimEngine()
+  nedges := len(simeng.graph.Edges())
+  simeng.SetGraphImpl(GIMPL_DENSE)
+  _, ok := simeng.graph.Unwrap().(*goraph.DenseGraph)
+  testutil.AssertTrue(u, ok)
+  testutil.AssertIntEqual(u, len(simeng.graph.Edges()), nedges)
+
+  // the simulation runs on the dense graph
+  simeng.W = 0.5
+  simeng.SetStepMode(STEP_SYNC)
+  var ps, dh bytes.Buffer
+  simeng.RunSim(&ps, &dh)
+  testutil.AssertInt32Equal(u, simeng.Nc+simeng.Nd, simeng.numAgents)
+
+  simeng.SetGraphImpl(GIMPL_ADJLIST)
+  _, ok = simeng.graph.Unwrap().(*goraph.AdjacencyList)
+  testutil.AssertTrue(u, ok)
+}
+
+func TestSetGraphImplUnchanged(u *testing.T) {
+  simeng := NewTestSimEngine()
+  graph := simeng.graph.Unwrap()
+  simeng.SetGraphImpl(GIMPL_ADJLIST)
+  testutil.AssertTrue(u, simeng.graph.Unwrap() == graph)
+
+  simeng.SetGraphImpl(GIMPL_DENSE)
+  graph = simeng.graph.Unwrap()
+  simeng.SetGraphImpl(GIMPL_DENSE)
+  testutil.AssertTrue(u, simeng.graph.Unwrap() == graph)
+  testutil.AssertInt32Equal(u, simeng.gimpl, GIMPL_DENSE)
+}
+
+func TestRemoveVertex(u *testing.T) {
+  for _, simeng := range []*SimEngine{ NewTestSimEngine(), NewTestRepSimEngine() } {
+    // each of the remaining vertices keeps its agent
+    held := make(map[goraph.Vertex]*Agent)
+    for _, v := range simeng.vertices {
+      held[v] = simeng.agent(v)
+    }
+    simeng.RemoveVertex(2)
+    delete(held, 2)
+    testutil.AssertTrue(u, simeng.agent(2) == nil)
+    testutil.AssertInt32Equal(u, simeng.numAgents, 6)
+    testutil.AssertIntEqual(u, len(simeng.vertices), 6)
+    testutil.AssertIntEqual(u, simeng.agents.Len(), 6)
+    for v, agent := range held {
+      testutil.AssertTrue(u, simeng.agent(v) == agent)
+    }
+
+    // the agents stay with their vertices when the implementation changes
+    simeng.SetGraphImpl(GIMPL_DENSE)
+    for v, agent := range held {
+      testutil.AssertTrue(u, simeng.agent(v) == agent)
+    }
+
+    // the simulation only updates the remaining agents and the strategy
+    // counts remain consistent
+    simeng.W = 1
+    var ps, dh bytes.Buffer
+    simeng.RunSim(&ps, &dh)
+    Nc := int32(0)
+    for _, v := range simeng.vertices {
+      testutil.AssertTrue(u, simeng.agent(v) == held[v])
+      if (simeng.agent(v).cooperate) { Nc += 1 }
+    }
+    testutil.AssertInt32Equal(u, simeng.Nc, Nc)
+    testutil.AssertInt32Equal(u, simeng.Nd, simeng.numAgents-Nc)
+    testutil.AssertFalse(u, simeng.graph.HasVertex(2))
+  }
+}
+
+func TestDirectedGames(u *testing.T) {
+  simeng := NewTestSimEngine()
+  graph := goraph.NewDigraph()
+  for range simeng.vertices {
+    graph.AddVertex()
+  }
+  // 1 and 2 play in the game sponsored by 0 but 0 doesn't play in theirs
+  graph.AddEdge(1, 0)
+  graph.AddEdge(2, 0)
+  simeng.SetGraph(graph)
+  testutil.AssertIntEqual(u, len(simeng.coplayers(0)), 2)
+  testutil.AssertIntEqual(u, len(simeng.coplayers(1)), 0)
+}
+
+func TestWriteGraph(u *testing.T) {
+  simeng := NewTestSimEngine()
+  var buf bytes.Buffer
+  err := simeng.WriteGraph(&buf)
+  testutil.AssertTrue(u, err == nil)
+  strat := "D"
+  if (simeng.agent(0).cooperate) {
+    strat = "C"
+  }
+  testutil.AssertTrue(u, strings.Contains(buf.String(), "v 0 strategy=" + strat + " payouts=0\n"))
+  testutil.AssertIntEqual(u, strings.Count(buf.String(), "\ne "), len(simeng.graph.Edges()))
+}
+
+func TestSetGraph(u *testing.T) {
+  simeng := NewTestSimEngine()
+  shared := goraph.NewAdjacencyListFrom(simeng.graph)
+  var before, after bytes.Buffer
+  goraph.WriteEdgeList(&before, shared)
+  simeng.SetGraph(goraph.Clone(shared))
+  simeng.W = 1
+  var ps, dh bytes.Buffer
+  simeng.RunSim(&ps, &dh)
+  // rewiring the clone leaves the shared graph unchanged
+  goraph.WriteEdgeList(&after, shared)
+  testutil.AssertTrue(u, before.String() == after.String())
+
+  // the graph must hold every agent
+  defer func() {
+    testutil.AssertTrue(u, recover() != nil)
+  }()
+  small := goraph.NewAdjacencyList()
+  small.AddVertex()
+  simeng.SetGraph(small)
+}
+
+func TestSetStop(u *testing.T) {
+  simeng := NewTestSimEngine()
+  simeng.SetExploration(1)
+  calls := 0
+  simeng.SetStop(func() bool {
+    calls++
+    return calls > 3
+  })
+  var ps, dh bytes.Buffer
+  gens := simeng.RunSim(&ps, &dh)
+  testutil.AssertInt32Equal(u, gens, 3)
+  // a header and a line for each completed generation
+  testutil.AssertIntEqual(u, strings.Count(ps.String(), "\n"), 4)
+}
@@ -0,0 +1,202 @@
+package simgpgg
+
+import "goraph"
+import "math"
+
+// A rule for updating the strategies of the agents after agent x has been
+// paired with its neighbor y.  Each rule has its own selection strength.
+type StrategyRule interface {
+  // select the agent whose strategy is replaced (the learner) and the agent
+  // whose strategy it adopts (the model) - ok is false if no agent changes
+  // its strategy
+  Select(simeng *SimEngine, x goraph.Vertex, y goraph.Vertex) (learner goraph.Vertex, model goraph.Vertex, ok bool)
+
+  // return the agents whose payouts the rule compares
+  Scope() int32
+
+  // return the name of the rule
+  String() string
+}
+
+// Strategy update rules that can be selected by number
+const (
+  URULE_FERMI int32 = iota        // pairwise comparison with a random neighbor
+  URULE_DEATH_BIRTH int32 = iota  // copy a neighbor in proportion to fitness
+  URULE_BIRTH_DEATH int32 = iota  // reproduce in proportion to fitness
+  URULE_IMITATE_BEST int32 = iota // unconditional imitation of the best neighbor
+  URULE_REPLICATOR int32 = iota   // replicator rule with normalised payouts
+)
+
+// The agents whose payouts a strategy update rule compares
+const (
+  SCOPE_PAIR int32 = iota         // x and y
+  SCOPE_NEIGHBORHOOD int32 = iota // x and its neighbors
+  SCOPE_POPULATION int32 = iota   // all of the agents
+)
+
+// Create the strategy update rule with the specified number and selection
+// strength.  The pairwise Fermi rule is returned for unknown numbers.
+func NewStrategyRule(urule int32, beta float64) StrategyRule {
+  switch urule {
+  case URULE_DEATH_BIRTH:
+    return &DeathBirthRule { beta: beta }
+  case URULE_BIRTH_DEATH:
+    return &BirthDeathRule { beta: beta }
+  case URULE_IMITATE_BEST:
+    return &ImitateBestRule { beta: beta }
+  case URULE_REPLICATOR:
+    return &ReplicatorRule { beta: beta }
+  default:
+    return &FermiRule { beta: beta }
+  }
+}
+
+// x adopts the strategy of y with probability Fermi(beta, Py, Px)
+type FermiRule struct {
+  beta float64 // selection strength
+}
+
+func (self *FermiRule) Select(simeng *SimEngine, x goraph.Vertex, y goraph.Vertex) (goraph.Vertex, goraph.Vertex, bool) {
+  Pe := Fermi(self.beta, simeng.agent(y).payouts, simeng.agent(x).payouts)
+  return x, y, (RandProb(simeng.rnGen) < Pe)
+}
+
+func (self *FermiRule) Scope() int32 {
+  return SCOPE_PAIR
+}
+
+func (self *FermiRule) String() string {
+  return "fermi"
+}
+
+// x is replaced by a copy of one of its neighbors selected in proportion to
+// the fitness e^(beta*P) of the neighbors
+type DeathBirthRule struct {
+  beta float64 // selection strength
+}
+
+func (self *DeathBirthRule) Select(simeng *SimEngine, x goraph.Vertex, y goraph.Vertex) (goraph.Vertex, goraph.Vertex, bool) {
+  Nx := simeng.graph.NeighborsView(x)
+  if (len(Nx) <= 0) {
+    return x, x, false
+  }
+  return x, Nx[RandWeighted(simeng.rnGen, simeng.fitness(self.beta, Nx))], true
+}
+
+func (self *DeathBirthRule) Scope() int32 {
+  return SCOPE_NEIGHBORHOOD
+}
+
+func (self *DeathBirthRule) String() string {
+  return "death-birth"
+}
+
+// An agent selected from the whole population in proportion to its fitness
+// e^(beta*P) reproduces and its offspring replaces a random neighbor.  The
+// pairing of x and y is ignored.
+type BirthDeathRule struct {
+  beta float64 // selection strength
+}
+
+func (self *BirthDeathRule) Select(simeng *SimEngine, x goraph.Vertex, y goraph.Vertex) (goraph.Vertex, goraph.Vertex, bool) {
+  vertices := simeng.graph.Vertices()
+  model := vertices[RandWeighted(simeng.rnGen, simeng.fitness(self.beta, vertices))]
+  Nm := simeng.graph.NeighborsView(model)
+  if (len(Nm) <= 0) {
+    return model, model, false
+  }
+  return Nm[RandInt(simeng.rnGen, int64(len(Nm)))], model, true
+}
+
+func (self *BirthDeathRule) Scope() int32 {
+  return SCOPE_POPULATION
+}
+
+func (self *BirthDeathRule) String() string {
+  return "birth-death"
+}
+
+// x adopts the strategy of its neighbor with the highest payout, if that
+// payout is higher than its own, with probability Fermi(beta, Pbest, Px).
+// Ties are broken at random and an infinite beta gives the deterministic rule.
+type ImitateBestRule struct {
+  beta float64 // selection strength
+}
+
+func (self *ImitateBestRule) Select(simeng *SimEngine, x goraph.Vertex, y goraph.Vertex) (goraph.Vertex, goraph.Vertex, bool) {
+  // find the neighbors with the highest payout
+  var best []goraph.Vertex
+  maxPO := math.Inf(-1)
+  for _, v := range simeng.graph.NeighborsView(x) {
+    po := simeng.agent(v).payouts
+    if (po > maxPO) {
+      best = []goraph.Vertex{ v }
+      maxPO = po
+    } else if (po == maxPO) {
+      best = append(best, v)
+    }
+  }
+  Px := simeng.agent(x).payouts
+  if ((len(best) <= 0) || (maxPO <= Px)) {
+    return x, x, false
+  }
+  model := best[RandInt(simeng.rnGen, int64(len(best)))]
+  return x, model, (RandProb(simeng.rnGen) < Fermi(self.beta, maxPO, Px))
+}
+
+func (self *ImitateBestRule) Scope() int32 {
+  return SCOPE_NEIGHBORHOOD
+}
+
+func (self *ImitateBestRule) String() string {
+  return "imitate-best"
+}
+
+// x adopts the strategy of y with probability beta*(Py - Px)/D when y has
+// the higher payout.  D is the largest payout difference that the games of x
+// and y allow: r*c*(max(kx, ky) + 1).  The probability is capped at one.
+type ReplicatorRule struct {
+  beta float64 // selection strength (1 gives the standard replicator rule)
+}
+
+func (self *ReplicatorRule) Select(simeng *SimEngine, x goraph.Vertex, y goraph.Vertex) (goraph.Vertex, goraph.Vertex, bool) {
+  diff := simeng.agent(y).payouts - simeng.agent(x).payouts
+  if (diff <= 0) {
+    return x, y, false
+  }
+  kmax := simeng.graph.Degree(x)
+  if (simeng.graph.Degree(y) > kmax) {
+    kmax = simeng.graph.Degree(y)
+  }
+  D := float64(simeng.mult*simeng.cost)*float64(kmax + 1)
+  Pe := math.Min(self.beta*diff/D, 1)
+  return x, y, (RandProb(simeng.rnGen) < Pe)
+}
+
+func (self *ReplicatorRule) Scope() int32 {
+  return SCOPE_PAIR
+}
+
+func (self *ReplicatorRule) String() string {
+  return "replicator"
+}
+
+// return the fitness e^(beta*P) of each of the agents
+// -- the max payout is subtracted to avoid overflow
+func (self *SimEngine) fitness(beta float64, vertices []goraph.Vertex) []float64 {
+  maxPO := math.Inf(-1)
+  for _, v := range vertices {
+    maxPO = math.Max(maxPO, self.agent(v).payouts)
+  }
+  weights := make([]float64, len(vertices))
+  for i, v := range vertices {
+    diff := self.agent(v).payouts - maxPO
+    if (diff == 0) {
+      // -- avoid inf*0 when beta is infinite
+      weights[i] = float64(1)
+    } else {
+      weights[i] = math.Exp(beta*diff)
+    }
+  }
+  return weights
+}
@@ -0,0 +1,146 @@
+package simgpgg
+
+import "testing"
+import "testutil"
+import "goraph"
+import "math"
+import "bytes"
+import "fmt"
+import "math/rand"
+
+// set up a test engine in which x is a defector whose neighbors are all
+// cooperators and the neighbor best has the highest payout
+func newStrategyTest(rule StrategyRule) (*SimEngine, goraph.Vertex, goraph.Vertex) {
+  simeng := NewTestSimEngine()
+  simeng.SetStrategyRule(rule)
+  x := simeng.graph.Vertices()[0]
+  for _, v := range simeng.graph.Vertices() {
+    simeng.agent(v).payouts = 0
+    simeng.setCooperate(v, v != x)
+  }
+  best := simeng.graph.Neighbors(x)[0]
+  simeng.agent(best).payouts = 10
+  return simeng, x, best
+}
+
+func TestNewStrategyRule(u *testing.T) {
+  testutil.AssertTrue(u, NewStrategyRule(URULE_FERMI, 1).String() == "fermi")
+  testutil.AssertTrue(u, NewStrategyRule(URULE_DEATH_BIRTH, 1).String() == "death-birth")
+  testutil.AssertTrue(u, NewStrategyRule(URULE_BIRTH_DEATH, 1).String() == "birth-death")
+  testutil.AssertTrue(u, NewStrategyRule(URULE_IMITATE_BEST, 1).String() == "imitate-best")
+  testutil.AssertTrue(u, NewStrategyRule(URULE_REPLICATOR, 1).String() == "replicator")
+  testutil.AssertTrue(u, NewStrategyRule(99, 1).String() == "fermi")
+}
+
+func TestStrategyRules(u *testing.T) {
+  rules := []int32{URULE_FERMI, URULE_DEATH_BIRTH, URULE_IMITATE_BEST, URULE_REPLICATOR}
+  for _, urule := range rules {
+    simeng, x, best := newStrategyTest(NewStrategyRule(urule, math.Inf(+1)))
+
+    // x adopts the strategy of its best neighbor
+    simeng.UpdateStrategy(x, best)
+    testutil.AssertTrue(u, simeng.agent(x).cooperate)
+    testutil.AssertInt32Equal(u, simeng.Nc, simeng.numAgents)
+  }
+}
+
+func TestImitateBestRule(u *testing.T) {
+  simeng, x, best := newStrategyTest(NewStrategyRule(URULE_IMITATE_BEST, math.Inf(+1)))
+
+  // x does not imitate neighbors that don't do better than x
+  simeng.agent(x).payouts = 10
+  _, _, ok := simeng.stratRule.Select(simeng, x, best)
+  testutil.AssertFalse(u, ok)
+
+  // the best neighbor is imitated even when x is paired with another
+  simeng.agent(x).payouts = 0
+  other := simeng.graph.Neighbors(x)[1]
+  learner, model, ok := simeng.stratRule.Select(simeng, x, other)
+  testutil.AssertTrue(u, ok)
+  testutil.AssertTrue(u, learner == x)
+  testutil.AssertTrue(u, model == best)
+}
+
+func TestBirthDeathRule(u *testing.T) {
+  simeng, x, best := newStrategyTest(NewStrategyRule(URULE_BIRTH_DEATH, math.Inf(+1)))
+
+  // the agent with the highest payout reproduces into one of its neighbors
+  learner, model, ok := simeng.stratRule.Select(simeng, x, best)
+  testutil.AssertTrue(u, ok)
+  testutil.AssertTrue(u, model == best)
+  testutil.AssertTrue(u, goraph.VertexSlice(simeng.graph.Neighbors(best)).Contains(learner))
+}
+
+func TestReplicatorRule(u *testing.T) {
+  simeng, x, best := newStrategyTest(NewStrategyRule(URULE_REPLICATOR, 0))
+
+  // no selection means no updates
+  _, _, ok := simeng.stratRule.Select(simeng, x, best)
+  testutil.AssertFalse(u, ok)
+
+  // agents never imitate neighbors with lower payouts
+  simeng.SetStrategyRule(NewStrategyRule(URULE_REPLICATOR, math.Inf(+1)))
+  _, _, ok = simeng.stratRule.Select(simeng, best, x)
+  testutil.AssertFalse(u, ok)
+}
+
+func TestFitness(u *testing.T) {
+  simeng, x, best := newStrategyTest(NewStrategyRule(URULE_DEATH_BIRTH, 1))
+  weights := simeng.fitness(math.Inf(+1), []goraph.Vertex{ x, best })
+  testutil.AssertFloat64Equal(u, weights[0], 0)
+  testutil.AssertFloat64Equal(u, weights[1], 1)
+
+  weights = simeng.fitness(0, []goraph.Vertex{ x, best })
+  testutil.AssertFloat64Equal(u, weights[0], 1)
+  testutil.AssertFloat64Equal(u, weights[1], 1)
+}
+
+func TestStrategyRuleScopes(u *testing.T) {
+  // every rule runs in both the asynchronous and synchronous modes
+  rules := []int32{URULE_FERMI, URULE_DEATH_BIRTH, URULE_BIRTH_DEATH, URULE_IMITATE_BEST, URULE_REPLICATOR}
+  for _, urule := range rules {
+    for _, mode := range []int32{STEP_MC, STEP_SYNC} {
+      simeng := NewTestSimEngine()
+      simeng.SetStrategyRule(NewStrategyRule(urule, 1))
+      simeng.SetStepMode(mode)
+      var ps, dh bytes.Buffer
+      simeng.RunSim(&ps, &dh)
+      testutil.AssertInt32Equal(u, simeng.Nc+simeng.Nd, simeng.numAgents)
+    }
+  }
+}
+
+func TestBirthDeathPayouts(u *testing.T) {
+  N := int32(200)
+  srules := []int32{ SRULE_NEIGHBOR, SRULE_RANDOM, SRULE_PAYOUT, SRULE_DEGREE, SRULE_EITHER, SRULE_DRIFT }
+  for _, srule := range srules {
+    simeng := NewSimEngineWithRNG(N, 100, 1, 4, 3, 1, 0.5, 1, 1, rand.New(rand.NewSource(1)))
+    simeng.SetStrategyRule(NewStrategyRule(URULE_BIRTH_DEATH, 1))
+    simeng.SetStructureRule(NewStructureRule(srule, 1))
+    simeng.SetContribScheme(CONTRIB_INDIVIDUAL)
+    simeng.SetExploration(0.1)
+    vertices := simeng.graph.Vertices()
+    payouts := make([]float64, N)
+    for i := 0; i < 300; i++ {
+      simeng.AsyncUpdate(0.5)
+
+      // only the games of the agents that changed are played again, so far
+      // fewer than all of the agents are refreshed
+      stale := simeng.stalePayouts()
+      testutil.AssertTrue(u, len(stale) < int(N)/2)
+      simeng.playGamesOf(stale, true)
+      for _, v := range vertices {
+        payouts[v] = simeng.agent(v).payouts
+      }
+      // the payouts are the same as when every game is played again
+      simeng.playGamesOf(vertices, false)
+      for _, v := range vertices {
+        if (math.Abs(simeng.agent(v).payouts - payouts[v]) > 1e-9) {
+          testutil.LogErr(u, fmt.Sprintf("%v update %d: agent %v has payout %g instead of %g",
+                                         simeng.structRule, i, v, payouts[v], simeng.agent(v).payouts))
+          return
+        }
+      }
+    }
+  }
+}
@@ -0,0 +1,269 @@
+package simgpgg
+
+import "goraph"
+import "math"
+
+// A rule for updating the structure of the network after agent x has been
+// paired with its neighbor y.  Each rule has its own selection strength.
+type StructureRule interface {
+  // update the links of x and y based on their strategies and payouts
+  UpdateStructure(simeng *SimEngine, x goraph.Vertex, y goraph.Vertex)
+
+  // return the name of the rule
+  String() string
+}
+
+// Structural update rules that can be selected by number
+const (
+  SRULE_NEIGHBOR int32 = iota // rewire to a neighbor of y
+  SRULE_RANDOM int32 = iota   // rewire to a random non-neighbor
+  SRULE_PAYOUT int32 = iota   // rewire preferentially to high payout vertices
+  SRULE_DEGREE int32 = iota   // rewire preferentially to high degree vertices
+  SRULE_EITHER int32 = iota   // either endpoint can break the link
+  SRULE_DRIFT int32 = iota    // create and delete links
+)
+
+// Create the structural update rule with the specified number and selection
+// strength.  The original rule is returned for unknown numbers.
+func NewStructureRule(srule int32, beta float64) StructureRule {
+  switch srule {
+  case SRULE_RANDOM:
+    return &RandomRewireRule { beta: beta }
+  case SRULE_PAYOUT:
+    return &PrefRewireRule { beta: beta, byPayout: true }
+  case SRULE_DEGREE:
+    return &PrefRewireRule { beta: beta, byPayout: false }
+  case SRULE_EITHER:
+    return &EitherEndRule { beta: beta }
+  case SRULE_DRIFT:
+    return &DriftRule { beta: beta }
+  default:
+    return &RewireNeighborRule { beta: beta }
+  }
+}
+
+// When y defects, x breaks its link with y with probability
+// Fermi(beta, Px, Py) and links to a random neighbor of y, or failing that
+// to a random non-neighbor.
+type RewireNeighborRule struct {
+  beta float64 // selection strength
+}
+
+func (self *RewireNeighborRule) UpdateStructure(simeng *SimEngine, x goraph.Vertex, y goraph.Vertex) {
+  // check to see if y is a cooperator
+  agenty := simeng.agent(y)
+  if (agenty.cooperate) {
+    // link to cooperator is satisfactory - no network update required
+    return
+  }
+  agentx := simeng.agent(x)
+
+  // if x is y's last neighbor then structure update cannot be done
+  if (simeng.graph.Degree(y) <= 1) {
+    return
+  }
+
+  // calculate the probability that x's link to y will be updated
+  Pa := Fermi(self.beta, float64(agentx.payouts), float64(agenty.payouts))
+
+  // switch x's link with y if appropriate
+  if (RandProb(simeng.rnGen) <= Pa) {
+    simeng.rewireAway(x, y)
+  }
+}
+
+func (self *RewireNeighborRule) String() string {
+  return "neighbor"
+}
+
+// When y defects, x breaks its link with y with probability
+// Fermi(beta, Px, Py) and links to a uniformly random non-neighbor.
+type RandomRewireRule struct {
+  beta float64 // selection strength
+}
+
+func (self *RandomRewireRule) UpdateStructure(simeng *SimEngine, x goraph.Vertex, y goraph.Vertex) {
+  agenty := simeng.agent(y)
+  if ((agenty.cooperate) || (simeng.graph.Degree(y) <= 1)) {
+    return
+  }
+  agentx := simeng.agent(x)
+  Pa := Fermi(self.beta, float64(agentx.payouts), float64(agenty.payouts))
+  if (RandProb(simeng.rnGen) <= Pa) {
+    available := simeng.nonNeighbors(x)
+    if (len(available) > 0) {
+      simeng.replaceLink(x, y, available[RandInt(simeng.rnGen, int64(len(available)))])
+    }
+  }
+}
+
+func (self *RandomRewireRule) String() string {
+  return "random"
+}
+
+// When y defects, x breaks its link with y with probability
+// Fermi(beta, Px, Py) and links to a non-neighbor selected in proportion to
+// (k+1)^beta, where k is its degree, or to e^(beta*P) when preferring high
+// payouts.  The most recent payouts of the candidates are used.  A beta of
+// zero selects uniformly and an infinite beta selects among the candidates
+// with the highest degree or payout.
+type PrefRewireRule struct {
+  beta float64  // selection strength
+  byPayout bool // prefer high payout (true) or high degree (false) vertices
+}
+
+func (self *PrefRewireRule) UpdateStructure(simeng *SimEngine, x goraph.Vertex, y goraph.Vertex) {
+  agenty := simeng.agent(y)
+  if ((agenty.cooperate) || (simeng.graph.Degree(y) <= 1)) {
+    return
+  }
+  agentx := simeng.agent(x)
+  Pa := Fermi(self.beta, float64(agentx.payouts), float64(agenty.payouts))
+  if (RandProb(simeng.rnGen) > Pa) {
+    return
+  }
+  available := simeng.nonNeighbors(x)
+  if (len(available) <= 0) {
+    return
+  }
+  simeng.replaceLink(x, y, available[RandWeighted(simeng.rnGen, self.weights(simeng, available))])
+}
+
+// return the weight of each candidate: e^(beta*s), where s is the payout or
+// ln(k+1)
+func (self *PrefRewireRule) weights(simeng *SimEngine, available []goraph.Vertex) []float64 {
+  scores := make([]float64, len(available))
+  for i, v := range available {
+    if (self.byPayout) {
+      scores[i] = simeng.agent(v).payouts
+    } else {
+      scores[i] = math.Log(float64(simeng.graph.Degree(v) + 1))
+    }
+  }
+  // -- subtract the max score to avoid overflow
+  maxScore := math.Inf(-1)
+  for _, score := range scores {
+    maxScore = math.Max(maxScore, score)
+  }
+  weights := make([]float64, len(available))
+  for i, score := range scores {
+    diff := score - maxScore
+    if (diff == 0) {
+      // -- avoid inf*0 when beta is infinite
+      weights[i] = float64(1)
+    } else {
+      weights[i] = math.Exp(self.beta*diff)
+    }
+  }
+  return weights
+}
+
+func (self *PrefRewireRule) String() string {
+  if (self.byPayout) {
+    return "payout"
+  } else {
+    return "degree"
+  }
+}
+
+// Either endpoint can break the link.  x acts with probability
+// Fermi(beta, Px, Py), otherwise y acts.  The acting agent breaks the link if
+// its partner defects and links to a random neighbor of its partner, or
+// failing that to a random non-neighbor.
+type EitherEndRule struct {
+  beta float64 // selection strength
+}
+
+func (self *EitherEndRule) UpdateStructure(simeng *SimEngine, x goraph.Vertex, y goraph.Vertex) {
+  agentx := simeng.agent(x)
+  agenty := simeng.agent(y)
+  if (agentx.cooperate && agenty.cooperate) {
+    return
+  }
+  // select the agent that acts
+  actor, partner := x, y
+  if (RandProb(simeng.rnGen) > Fermi(self.beta, agentx.payouts, agenty.payouts)) {
+    actor, partner = y, x
+  }
+  // links to cooperators are satisfactory
+  if ((simeng.agent(partner).cooperate) || (simeng.graph.Degree(partner) <= 1)) {
+    return
+  }
+  simeng.rewireAway(actor, partner)
+}
+
+func (self *EitherEndRule) String() string {
+  return "either"
+}
+
+// Links are created and deleted so the number of edges can drift.  When y
+// defects, x deletes its link with y with probability Fermi(beta, Px, Py).
+// When y cooperates, x links to a random neighbor of y with probability
+// Fermi(beta, Py, Px).  No agent is left without neighbors.
+type DriftRule struct {
+  beta float64 // selection strength
+}
+
+func (self *DriftRule) UpdateStructure(simeng *SimEngine, x goraph.Vertex, y goraph.Vertex) {
+  agentx := simeng.agent(x)
+  agenty := simeng.agent(y)
+  if (agenty.cooperate) {
+    // x links to one of y's neighbors that isn't already its neighbor
+    Pc := Fermi(self.beta, agenty.payouts, agentx.payouts)
+    if (RandProb(simeng.rnGen) <= Pc) {
+      Nx := simeng.graph.Neighbors(x)
+      Ny := RemoveVerticesFromSlice(simeng.graph.Neighbors(y), append(Nx, x))
+      if (len(Ny) > 0) {
+        newy := Ny[RandInt(simeng.rnGen, int64(len(Ny)))]
+        simeng.graph.AddEdge(x, newy)
+        simeng.notifyRewire(x, NO_VERTEX, newy)
+      }
+    }
+  } else {
+    // x deletes its link with y
+    if ((simeng.graph.Degree(x) <= 1) || (simeng.graph.Degree(y) <= 1)) {
+      return
+    }
+    Pd := Fermi(self.beta, agentx.payouts, agenty.payouts)
+    if (RandProb(simeng.rnGen) <= Pd) {
+      simeng.graph.RemoveEdge(x, y)
+      simeng.notifyRewire(x, y, NO_VERTEX)
+    }
+  }
+}
+
+func (self *DriftRule) String() string {
+  return "drift"
+}
+
+// x breaks its link with y and links to a random neighbor of y that is not
+// already x's neighbor, or failing that to a random non-neighbor
+func (self *SimEngine) rewireAway(x goraph.Vertex, y goraph.Vertex) {
+  // get the list of y's neighbors that aren't x's neighbors
+  // -- Note that Nx includes y
+  Nx := self.graph.Neighbors(x)
+  Ny := RemoveVerticesFromSlice(self.graph.Neighbors(y), append(Nx, x))
+
+  if (len(Ny) > 0) { // y has some neighbors that are not x's neighbors
+    // select new neighbor from y's neighbors
+    self.replaceLink(x, y, Ny[RandInt(self.rnGen, int64(len(Ny)))])
+  } else { // y doesn't have any neighbors that are not also x's neighbors
+    available := self.nonNeighbors(x)
+    // chose an newy if some vertices are available
+    if (len(available) > 0) {
+      self.replaceLink(x, y, available[RandInt(self.rnGen, int64(len(available)))])
+    }
+  }
+}
+
+// return all vertices except x and x's neighbors
+func (self *SimEngine) nonNeighbors(x goraph.Vertex) []goraph.Vertex {
+  return RemoveVerticesFromSlice(self.graph.Vertices(), append(self.graph.Neighbors(x), x))
+}
+
+// replace x's link to y with a link to newy
+func (self *SimEngine) replaceLink(x goraph.Vertex, y goraph.Vertex, newy goraph.Vertex) {
+  self.graph.RemoveEdge(x, y)
+  self.graph.AddEdge(x, newy)
+  self.notifyRewire(x, y, newy)
+}
@@ -0,0 +1,134 @@
+package simgpgg
+
+import "testing"
+import "testutil"
+import "goraph"
+import "math"
+import "math/rand"
+
+// set up a test engine in which x has a higher payout than its defecting
+// neighbor y
+func newStructureTest(rule StructureRule) (*SimEngine, goraph.Vertex, goraph.Vertex) {
+  simeng := NewTestSimEngine()
+  simeng.SetStructureRule(rule)
+  x := simeng.graph.Vertices()[0]
+  y := simeng.graph.Neighbors(x)[0]
+  simeng.agent(x).payouts = 100
+  simeng.agent(y).payouts = 99
+  simeng.agent(y).cooperate = false
+  return simeng, x, y
+}
+
+func TestNewStructureRule(u *testing.T) {
+  testutil.AssertTrue(u, NewStructureRule(SRULE_NEIGHBOR, 1).String() == "neighbor")
+  testutil.AssertTrue(u, NewStructureRule(SRULE_RANDOM, 1).String() == "random")
+  testutil.AssertTrue(u, NewStructureRule(SRULE_PAYOUT, 1).String() == "payout")
+  testutil.AssertTrue(u, NewStructureRule(SRULE_DEGREE, 1).String() == "degree")
+  testutil.AssertTrue(u, NewStructureRule(SRULE_EITHER, 1).String() == "either")
+  testutil.AssertTrue(u, NewStructureRule(SRULE_DRIFT, 1).String() == "drift")
+  testutil.AssertTrue(u, NewStructureRule(99, 1).String() == "neighbor")
+}
+
+func TestRewireRules(u *testing.T) {
+  rules := []int32{SRULE_NEIGHBOR, SRULE_RANDOM, SRULE_PAYOUT, SRULE_DEGREE, SRULE_EITHER}
+  for _, srule := range rules {
+    simeng, x, y := newStructureTest(NewStructureRule(srule, math.Inf(+1)))
+    simeng.agent(x).cooperate = true
+    nedges := len(simeng.graph.Edges())
+    kx := simeng.graph.Degree(x)
+
+    simeng.UpdateStructure(x, y)
+
+    // x has rewired its link with y to another vertex
+    testutil.AssertFalse(u, goraph.VertexSlice(simeng.graph.Neighbors(x)).Contains(y))
+    testutil.AssertIntEqual(u, simeng.graph.Degree(x), kx)
+    testutil.AssertIntEqual(u, len(simeng.graph.Edges()), nedges)
+  }
+}
+
+func TestEitherEndRule(u *testing.T) {
+  simeng, x, y := newStructureTest(NewStructureRule(SRULE_EITHER, math.Inf(+1)))
+
+  // links between cooperators are never broken
+  simeng.agent(x).cooperate = true
+  simeng.agent(y).cooperate = true
+  simeng.UpdateStructure(x, y)
+  testutil.AssertTrue(u, goraph.VertexSlice(simeng.graph.Neighbors(x)).Contains(y))
+
+  // with both agents defecting one of them breaks the link
+  simeng.agent(x).cooperate = false
+  simeng.agent(y).cooperate = false
+  ky := simeng.graph.Degree(y)
+  simeng.UpdateStructure(x, y)
+  testutil.AssertFalse(u, goraph.VertexSlice(simeng.graph.Neighbors(x)).Contains(y))
+  testutil.AssertTrue(u, simeng.graph.Degree(x) + simeng.graph.Degree(y) == 4 + ky - 1)
+}
+
+func TestDriftRule(u *testing.T) {
+  simeng, x, y := newStructureTest(NewStructureRule(SRULE_DRIFT, math.Inf(+1)))
+  nedges := len(simeng.graph.Edges())
+
+  // x deletes its link with the defector y
+  simeng.UpdateStructure(x, y)
+  testutil.AssertFalse(u, goraph.VertexSlice(simeng.graph.Neighbors(x)).Contains(y))
+  testutil.AssertIntEqual(u, len(simeng.graph.Edges()), nedges-1)
+
+  // x links to a neighbor of its cooperating neighbor z
+  z := simeng.graph.Neighbors(x)[0]
+  simeng.agent(z).cooperate = true
+  Nz := RemoveVerticesFromSlice(simeng.graph.Neighbors(z), append(simeng.graph.Neighbors(x), x))
+  simeng.UpdateStructure(x, z)
+  if (len(Nz) > 0) {
+    testutil.AssertIntEqual(u, len(simeng.graph.Edges()), nedges)
+  } else {
+    testutil.AssertIntEqual(u, len(simeng.graph.Edges()), nedges-1)
+  }
+}
+
+func TestPrefRewireDegreeBeta(u *testing.T) {
+  const N = 5000
+  simeng := NewTestSimEngine()
+  x := simeng.graph.Vertices()[0]
+  available := simeng.nonNeighbors(x)
+  testutil.AssertIntEqual(u, len(available), 2)
+  // -- lower the degree of the first candidate from 4 to 3
+  c, other := available[0], available[1]
+  for _, v := range simeng.graph.Neighbors(c) {
+    if (v != other) {
+      simeng.graph.RemoveEdge(c, v)
+      break
+    }
+  }
+  testutil.AssertIntEqual(u, simeng.graph.Degree(c), 3)
+
+  // the first candidate is selected with probability 4^beta/(4^beta + 5^beta)
+  rnGen := rand.New(rand.NewSource(1))
+  for _, beta := range []float64{ 0, 1, 4 } {
+    rule := &PrefRewireRule { beta: beta }
+    n := 0
+    for i := 0; i < N; i++ {
+      if (RandWeighted(rnGen, rule.weights(simeng, available)) == 0) { n++ }
+    }
+    p := math.Pow(4, beta)/(math.Pow(4, beta) + math.Pow(5, beta))
+    testutil.AssertBinomial(u, n, N, p)
+    if (beta > 0) {
+      // -- a stronger selection changes the choice
+      testutil.AssertTrue(u, testutil.BinomialPValue(n, N, 0.5) < testutil.SIGNIFICANCE)
+    }
+  }
+  // only the candidate with the highest degree is selected when beta is
+  // infinite
+  rule := &PrefRewireRule { beta: math.Inf(+1) }
+  for i := 0; i < 100; i++ {
+    testutil.AssertIntEqual(u, RandWeighted(rnGen, rule.weights(simeng, available)), 1)
+  }
+}
+
+func TestRandWeighted(u *testing.T) {
+  rnGen := NewRandNumGen()
+  weights := []float64{0, 1, 0}
+  for i := 0; i < 10; i++ {
+    testutil.AssertIntEqual(u, RandWeighted(rnGen, weights), 1)
+  }
+}
+
@@ -5,38 +5,68 @@ import "fmt"
 import "simbase"
 
 /*
-  Action module for a PGG agent.  The agent can choose to take one or more  of
+  Action module for a PGG agent.  The agent can choose to take one or more of
   the following actions during a round:
     - Contribute to the common pool
     - Punish non-contributors
-  If the agent choose to take neither of these actions then they are considered
-  a non-participant.
-
-  Besides these two action, the game can also be expanded to include the following
-  additional actions:
     - Reward contributors
-    - Punish non-punishers
-  These actions may be included in a future version of this simulation.
+    - Punish non-punishers (second-order punishment)
+  If the agent chooses not to contribute or punish then they are considered
+  a non-participant.
 
-  An action module for PGG is represented as two sets of 4 bits.  The first set
-  of four bits determines whether the agent contributes while the second set of
-  bits determines whether the agent punishes.
+  An action module for PGG is represented as four sets of 4 bits, one
+  simbase.ActionModule per action.  Each set of bits determines whether the
+  agent takes the action based on its own reputation and the reputation of
+  its group.  The costs and effects of the actions are defined by Game.
 */
 type ActionModule struct {
   cam *simbase.ActionModule // determines when the agent contributes
   pam *simbase.ActionModule // determines when the agent punishes
+  ram *simbase.ActionModule // determines when the agent rewards contributors
+  sam *simbase.ActionModule // determines when the agent punishes non-punishers
   pexeerr float32
 }
 
+// Number of bits in the genome of an action module
+const NUM_AM_BITS = 16
+
+// Create an action module that contributes and punishes as specified and
+// never rewards or punishes non-punishers.
 func NewActionModule(c1 bool, c2 bool, c3 bool, c4 bool,
                      p1 bool, p2 bool, p3 bool, p4 bool, pexeerr float32) *ActionModule {
   return &ActionModule { cam: simbase.NewActionModule(c1, c2, c3, c4, pexeerr),
                          pam: simbase.NewActionModule(p1, p2, p3, p4, pexeerr),
+                         ram: simbase.NewActionModule(false, false, false, false, pexeerr),
+                         sam: simbase.NewActionModule(false, false, false, false, pexeerr),
                          pexeerr: pexeerr }
 }
 
+// Create an action module from its bits in the order returned by GetBits
+func NewActionModuleFromBits(bits int, pexeerr float32) *ActionModule {
+  module := func(shift int) *simbase.ActionModule {
+    return simbase.NewActionModule(bits&(8<<shift) != 0, bits&(4<<shift) != 0,
+                                   bits&(2<<shift) != 0, bits&(1<<shift) != 0, pexeerr)
+  }
+  return &ActionModule { cam: module(4), pam: module(0), ram: module(12), sam: module(8),
+                         pexeerr: pexeerr }
+}
+
+// Create an action module with random bits
+func NewRandActionModule(pexeerr float32, rnGen *rand.Rand) *ActionModule {
+  return NewActionModuleFromBits(rnGen.Intn(1 << NUM_AM_BITS), pexeerr)
+}
+
 func (am *ActionModule) Copy() *ActionModule {
-  return &ActionModule { cam: am.cam.Copy(), pam: am.pam.Copy(), pexeerr: am.pexeerr }
+  return &ActionModule { cam: am.cam.Copy(), pam: am.pam.Copy(), ram: am.ram.Copy(),
+                         sam: am.sam.Copy(), pexeerr: am.pexeerr }
+}
+
+// clone the action module using the specified probability of bit mutation
+func (am *ActionModule) CloneWithMutations(pactmut float64, rnGen *rand.Rand) *ActionModule {
+  return &ActionModule { cam: am.cam.CloneWithMutations(pactmut, rnGen),
+                         pam: am.pam.CloneWithMutations(pactmut, rnGen),
+                         ram: am.ram.CloneWithMutations(pactmut, rnGen),
+                         sam: am.sam.CloneWithMutations(pactmut, rnGen), pexeerr: am.pexeerr }
 }
 
 func (self *ActionModule) ChooseContribute(agent simbase.Rep, group simbase.Rep, rnGen *rand.Rand) bool {
@@ -47,19 +77,45 @@ func (self *ActionModule) ChoosePunish(agent simbase.Rep, group simbase.Rep, rnG
   return self.pam.ChooseAction(agent, group, rnGen)
 }
 
+func (self *ActionModule) ChooseReward(agent simbase.Rep, group simbase.Rep, rnGen *rand.Rand) bool {
+  return self.ram.ChooseAction(agent, group, rnGen)
+}
+
+func (self *ActionModule) ChooseSecondOrderPunish(agent simbase.Rep, group simbase.Rep, rnGen *rand.Rand) bool {
+  return self.sam.ChooseAction(agent, group, rnGen)
+}
+
 // return true of the two modules have the same bits
 func (self *ActionModule) SameBits(am *ActionModule) bool {
-  return (self.cam.SameBits(am.cam) && self.pam.SameBits(am.pam) )
+  return (self.cam.SameBits(am.cam) && self.pam.SameBits(am.pam) &&
+          self.ram.SameBits(am.ram) && self.sam.SameBits(am.sam))
 }
 
+// return bit i of the module - bits 0-3 are the contribute bits, 4-7 the
+// punish bits, 8-11 the reward bits and 12-15 the second-order punish bits
 func (self *ActionModule) GetBit(i int) int {
-  if (i < 4) {
+  switch {
+  case (i < 4):
     return self.cam.GetBit(i)
-  } else {
+  case (i < 8):
     return self.pam.GetBit(i-4)
+  case (i < 12):
+    return self.ram.GetBit(i-8)
+  default:
+    return self.sam.GetBit(i-12)
   }
 }
 
+// return the bits of the module as an integer in the range [0, 65535]
+// -- the low order byte holds the contribute bits followed by the punish
+//    bits so that modules that never reward or punish non-punishers are in
+//    the range [0, 255]
+// -- the high order byte holds the reward bits followed by the second-order
+//    punish bits
+func (self *ActionModule) GetBits() int {
+  return self.ram.GetBits()<<12 + self.sam.GetBits()<<8 + self.cam.GetBits()<<4 + self.pam.GetBits()
+}
+
 func (self *ActionModule) WriteSimParams() {
   fmt.Printf("  \"pexeerr\":%.5f\n", self.pexeerr)
 }
@@ -0,0 +1,44 @@
+package simpgg
+
+import "math/rand"
+import "fmt"
+import "simbase"
+
+// An agent that uses an action module to decide whether to contribute to the
+// common pool and whether to punish the non-contributors of its group
+type Agent struct {
+  tribe *Tribe
+  rep simbase.Rep
+  actMod *ActionModule
+  payout float64
+  pactmut float64 // mu_s - action module bit mutation probability
+}
+
+// Create a new agent with a random action module.  By default the agent has
+// a GOOD reputation.
+func NewAgent(t *Tribe, pactmut float64, pexeerr float32, rnGen *rand.Rand) *Agent {
+  actMod := NewRandActionModule(pexeerr, rnGen)
+  return &Agent { tribe: t, rep: simbase.GOOD, payout: 0, pactmut: pactmut, actMod: actMod }
+}
+
+// Create a child of this agent to be part of the specified next generation
+// tribe.  The new agent's action module is a clone of its parent's action
+// module (possibly with mutations).  The other attributes are set to default
+// values.
+func (parent *Agent) CreateChild(nextGen *Tribe, rnGen *rand.Rand) *Agent {
+  inheritedActMod := parent.actMod.CloneWithMutations(parent.pactmut, rnGen)
+  return &Agent { tribe: nextGen, rep: simbase.GOOD, payout: 0,
+                  actMod: inheritedActMod, pactmut: parent.pactmut }
+}
+
+// Reset the agent's internal state to prepare for participation in the
+// next generation.
+func (self *Agent) Reset() {
+  self.rep = simbase.GOOD
+  self.payout = 0
+}
+
+func (self *Agent) WriteSimParams() {
+  fmt.Printf("  \"pactmut\":%.5f,\n", self.pactmut)
+  self.actMod.WriteSimParams()
+}
@@ -0,0 +1,91 @@
+package simpgg
+
+import "math/rand"
+import "fmt"
+import "simbase"
+
+/*
+ * An asessment module that assigns a reputation to an agent based on the
+ * agent's reputation, the reputation of its group and whether the agent
+ * contributed to the common pool.
+ *
+ *   agent rep  group rep  agent act    result rep
+ *   ---------  ---------  -----------  ----------
+ *     GOOD        GOOD    contribute    bits[0]
+ *     GOOD        GOOD    defect        bits[1]
+ *     GOOD        BAD     contribute    bits[2]
+ *     GOOD        BAD     defect        bits[3]
+ *     BAD         GOOD    contribute    bits[4]
+ *     BAD         GOOD    defect        bits[5]
+ *     BAD         BAD     contribute    bits[6]
+ *     BAD         BAD     defect        bits[7]
+ */
+type AssessModule struct {
+  bits [8]simbase.Rep
+  passerr float32 // mu_a - assessment error - assign wrong reputation
+}
+
+func NewAssessModule(r1 simbase.Rep, r2 simbase.Rep, r3 simbase.Rep, r4 simbase.Rep,
+                     r5 simbase.Rep, r6 simbase.Rep, r7 simbase.Rep, r8 simbase.Rep,
+                     passerr float32) *AssessModule {
+  return &AssessModule { bits: [8]simbase.Rep{r1, r2, r3, r4, r5, r6, r7, r8},
+                         passerr: passerr }
+}
+
+// Create an assessment module with random bits
+func NewRandAssessModule(passerr float32, rnGen *rand.Rand) *AssessModule {
+  return NewAssessModule(simbase.RandRep(rnGen), simbase.RandRep(rnGen),
+                         simbase.RandRep(rnGen), simbase.RandRep(rnGen),
+                         simbase.RandRep(rnGen), simbase.RandRep(rnGen),
+                         simbase.RandRep(rnGen), simbase.RandRep(rnGen), passerr)
+}
+
+func (am *AssessModule) Copy() *AssessModule {
+  return &AssessModule { bits: am.bits, passerr: am.passerr }
+}
+
+// return true of the two modules have the same bits
+func (self *AssessModule) SameBits(am *AssessModule) bool {
+  return (self == am) || (self.bits == am.bits)
+}
+
+// return the bits of the module as an integer in the range [0, 255]
+func (self *AssessModule) GetBits() int {
+  rval := int(0)
+  for i := 0; i < 8; i++ {
+    rval = 2*rval + self.GetBit(i)
+  }
+  return rval
+}
+
+func (self *AssessModule) GetBit(i int) int {
+  if (self.bits[i] == simbase.GOOD) {
+    return 1
+  } else {
+    return 0
+  }
+}
+
+func (self *AssessModule) AssignRep(agent simbase.Rep, group simbase.Rep, contribute bool,
+                                    rnGen *rand.Rand) simbase.Rep {
+  // find the bit for the situation - see the table above
+  i := 0
+  if (agent == simbase.BAD) { i += 4 }
+  if (group == simbase.BAD) { i += 2 }
+  if (!contribute) { i += 1 }
+  rval := self.bits[i]
+  // check assessment error
+  if (simbase.RandPercent(rnGen) < float64(self.passerr)) {
+    if (rval == simbase.GOOD) {
+      rval = simbase.BAD
+    } else {
+      rval = simbase.GOOD
+    }
+  }
+  // return the reputation
+  return rval
+}
+
+func (self *AssessModule) WriteSimParams() {
+  fmt.Printf("  \"passerr\":%.5f,\n", self.passerr)
+}
@@ -0,0 +1,176 @@
+package simpgg
+
+import "math/rand"
+import "fmt"
+import "simbase"
+
+// The parameters of the public goods game played by the agents of a tribe
+type Game struct {
+  cost float64   // contribution made by each contributor
+  mult float64   // contribution multiplier (r)
+  fine float64   // fine paid by a non-contributor to each punisher
+  pcost float64  // cost paid by a punisher for each non-contributor punished
+  reward float64 // reward received by a contributor from each rewarder
+  rcost float64  // cost paid by a rewarder for each contributor rewarded
+  spfine float64 // fine paid by a non-punisher to each second-order punisher
+  spcost float64 // cost paid by a second-order punisher for each non-punisher punished
+  groupSize int  // number of agents in each group
+  rounds int     // number of rounds played by each tribe per generation
+}
+
+func NewGame(cost float64, mult float64, fine float64, pcost float64,
+             groupSize int, rounds int) *Game {
+  return &Game { cost: cost, mult: mult, fine: fine, pcost: pcost,
+                 groupSize: groupSize, rounds: rounds }
+}
+
+// Set the costs and effects of rewarding contributors and punishing
+// non-punishers.  Both are free and have no effect by default.
+func (self *Game) SetIncentives(reward float64, rcost float64, spfine float64, spcost float64) {
+  self.reward = reward
+  self.rcost = rcost
+  self.spfine = spfine
+  self.spcost = spcost
+}
+
+// The payout each player receives in each game so that no payout is negative
+// -- a player loses the most by contributing and by paying every fine and
+//    every cost of punishing and rewarding for all other players
+func (self *Game) Endowment(n int) float64 {
+  return self.cost + (self.fine + self.pcost + self.rcost + self.spfine + self.spcost)*float64(n-1)
+}
+
+// calculate the reputation of a group of agents
+// -- the group is GOOD if at least half of the agents are GOOD
+func GroupRep(group []*Agent) simbase.Rep {
+  ngood := 0
+  for _, agent := range group {
+    if (agent.rep == simbase.GOOD) {
+      ngood += 1
+    }
+  }
+  if (2*ngood >= len(group)) {
+    return simbase.GOOD
+  } else {
+    return simbase.BAD
+  }
+}
+
+// The number of agents in a round that took each action
+type RoundActions struct {
+  Contrib int  // agents that contributed
+  Punish int   // agents that punished non-contributors
+  Reward int   // agents that rewarded contributors
+  SPunish int  // agents that punished non-punishers
+}
+
+// Play a round of the game in a group of agents.  Each agent uses its action
+// module to decide whether to contribute, punish the non-contributors,
+// reward the contributors and punish the non-punishers, based on its own
+// reputation and the reputation of the group.  The assessment module then
+// assigns each agent a new reputation.  The total payout earned by the group
+// is returned along with the number of agents that took each action.
+//
+// A non-punisher is an agent that did not punish even though another agent
+// of the group did not contribute.  Agents don't reward or punish themselves.
+func (self *Game) PlayRound(group []*Agent, assessMod *AssessModule,
+                            rnGen *rand.Rand) (payouts float64, acts RoundActions) {
+  // all agents judge the group by its reputation before the round
+  groupRep := GroupRep(group)
+
+  // ask each agent to choose its actions
+  n := len(group)
+  contribute := make([]bool, n)
+  punish := make([]bool, n)
+  reward := make([]bool, n)
+  spunish := make([]bool, n)
+  for i, agent := range group {
+    contribute[i] = agent.actMod.ChooseContribute(agent.rep, groupRep, rnGen)
+    punish[i] = agent.actMod.ChoosePunish(agent.rep, groupRep, rnGen)
+    reward[i] = agent.actMod.ChooseReward(agent.rep, groupRep, rnGen)
+    spunish[i] = agent.actMod.ChooseSecondOrderPunish(agent.rep, groupRep, rnGen)
+    if (contribute[i]) { acts.Contrib += 1 }
+    if (punish[i]) { acts.Punish += 1 }
+    if (reward[i]) { acts.Reward += 1 }
+    if (spunish[i]) { acts.SPunish += 1 }
+  }
+  ncontrib := acts.Contrib
+  npunish := acts.Punish
+  ndefect := n - ncontrib
+  // find the non-punishers
+  nonPunisher := make([]bool, n)
+  nnonp := 0
+  for i := 0; i < n; i++ {
+    others := ndefect
+    if (!contribute[i]) { others -= 1 }
+    if (!punish[i] && (others > 0)) {
+      nonPunisher[i] = true
+      nnonp += 1
+    }
+  }
+
+  // distribute the pool, fines and punishment costs
+  share := self.mult*self.cost*float64(ncontrib)/float64(n)
+  for i, agent := range group {
+    payout := self.Endowment(n) + share
+    if (contribute[i]) {
+      payout -= self.cost
+    }
+    // -- agents don't punish themselves
+    if (!contribute[i]) {
+      punishers := npunish
+      if (punish[i]) { punishers -= 1 }
+      payout -= self.fine*float64(punishers)
+    }
+    if (punish[i]) {
+      targets := ndefect
+      if (!contribute[i]) { targets -= 1 }
+      payout -= self.pcost*float64(targets)
+    }
+    // -- rewarders pay for each other contributor and contributors receive
+    //    the reward from each other rewarder
+    if (reward[i]) {
+      targets := ncontrib
+      if (contribute[i]) { targets -= 1 }
+      payout -= self.rcost*float64(targets)
+    }
+    if (contribute[i]) {
+      rewarders := acts.Reward
+      if (reward[i]) { rewarders -= 1 }
+      payout += self.reward*float64(rewarders)
+    }
+    // -- second-order punishers pay for each other non-punisher and
+    //    non-punishers are fined by each other second-order punisher
+    if (spunish[i]) {
+      targets := nnonp
+      if (nonPunisher[i]) { targets -= 1 }
+      payout -= self.spcost*float64(targets)
+    }
+    if (nonPunisher[i]) {
+      punishers := acts.SPunish
+      if (spunish[i]) { punishers -= 1 }
+      payout -= self.spfine*float64(punishers)
+    }
+    agent.payout += payout
+    payouts += payout
+  }
+
+  // assign new reputations
+  for i, agent := range group {
+    agent.rep = assessMod.AssignRep(agent.rep, groupRep, contribute[i], rnGen)
+  }
+  return payouts, acts
+}
+
+func (self *Game) WriteSimParams() {
+  fmt.Printf("  \"cost\":%.5f,\n", self.cost)
+  fmt.Printf("  \"mult\":%.5f,\n", self.mult)
+  fmt.Printf("  \"fine\":%.5f,\n", self.fine)
+  fmt.Printf("  \"pcost\":%.5f,\n", self.pcost)
+  fmt.Printf("  \"reward\":%.5f,\n", self.reward)
+  fmt.Printf("  \"rcost\":%.5f,\n", self.rcost)
+  fmt.Printf("  \"spfine\":%.5f,\n", self.spfine)
+  fmt.Printf("  \"spcost\":%.5f,\n", self.spcost)
+  fmt.Printf("  \"gsize\":%d,\n", self.groupSize)
+  fmt.Printf("  \"rounds\":%d,\n", self.rounds)
+}
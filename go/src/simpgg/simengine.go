@@ -0,0 +1,273 @@
+package simpgg
+
+import "math"
+import "math/rand"
+import "fmt"
+import "sort"
+import "simbase"
+
+// A simulation engine for simulating the public goods game played by agents
+// divided into tribes.  The agents of each tribe play the game in groups
+// and the tribe's assessment module assigns them reputations.  Agents evolve
+// within their tribes and tribes evolve through conflict.
+type SimEngine struct {
+  tribes []*Tribe
+  numTribes int
+  game *Game
+  totalPayouts float64
+  rnGen *rand.Rand
+  pcon float32 // prob of tribal conflict
+  beta float64 // conflict selection strength
+  eta  float64 // assess module bit switching selection strength
+  pmig float32 // prob of migration
+  passmut float64 // prob of assess module bit mutation
+}
+
+// Make a new simulation engine.  Parameters missing from the map take their
+// default values.
+func NewSimEngine(numTribes int, numAgents int, params map[string]float64) *SimEngine {
+  return NewSimEngineWithRNG(numTribes, numAgents, params, simbase.NewRandNumGen())
+}
+
+// Make a new simulation engine that uses the provided random number generator.
+func NewSimEngineWithRNG(numTribes int, numAgents int, params map[string]float64,
+                         rnGen *rand.Rand) *SimEngine {
+  // get parameters
+  param := func(name string, def float64) float64 {
+    value, ok := params[name]
+    if (!ok) { value = def }
+    return value
+  }
+  game := NewGame(param(simbase.COST_F, simbase.COST), param(MULT_F, MULT),
+                  param(FINE_F, FINE), param(PCOST_F, PCOST),
+                  int(param(GSIZE_F, GSIZE)), int(param(ROUNDS_F, ROUNDS)))
+  game.SetIncentives(param(REWARD_F, REWARD), param(RCOST_F, RCOST),
+                     param(SPFINE_F, SPFINE), param(SPCOST_F, SPCOST))
+
+  // create tribes
+  tribes := make([]*Tribe, numTribes)
+  for i := 0; i < numTribes; i++ {
+    tribes[i] = NewTribe(numAgents, float32(param(simbase.PASSE_F, simbase.PASSERR)),
+                         param(simbase.PACTM_F, simbase.PACTMUT),
+                         float32(param(simbase.PEXEE_F, simbase.PEXEERR)), rnGen)
+  }
+
+  return &SimEngine { tribes: tribes, numTribes: numTribes, game: game, rnGen: rnGen,
+                      pcon: float32(param(simbase.PCON_F, simbase.PCON)),
+                      beta: param(simbase.BETA_F, simbase.BETA),
+                      eta: param(simbase.ETA_F, simbase.ETA),
+                      pmig: float32(param(simbase.PMIG_F, simbase.PMIG)),
+                      passmut: param(simbase.PASSM_F, simbase.PASSMUT) }
+}
+
+// Return an error describing the first invalid parameter
+func CheckParams(numTribes int, numAgents int, params map[string]float64) error {
+  switch {
+  case (numTribes < 1):
+    return fmt.Errorf("-%s: number of tribes must be at least 1", simbase.TRIBES_F)
+  case (numAgents < 2):
+    return fmt.Errorf("-%s: number of agents must be at least 2", simbase.AGENTS_F)
+  }
+  gsize, ok := params[GSIZE_F]
+  if (ok && ((gsize < 2) || (gsize != math.Trunc(gsize)))) {
+    return fmt.Errorf("-%s: group size must be a whole number of at least 2", GSIZE_F)
+  }
+  rounds, ok := params[ROUNDS_F]
+  if (ok && ((rounds < 1) || (rounds != math.Trunc(rounds)))) {
+    return fmt.Errorf("-%s: number of rounds must be a whole number of at least 1", ROUNDS_F)
+  }
+  // costs, fines and selection strengths must not be negative
+  for _, f := range []string{simbase.COST_F, MULT_F, FINE_F, PCOST_F, REWARD_F, RCOST_F,
+                             SPFINE_F, SPCOST_F, simbase.BETA_F} {
+    if (params[f] < 0) {
+      return fmt.Errorf("-%s: must not be negative", f)
+    }
+  }
+  // probabilities must be between 0 and 1
+  for _, f := range []string{simbase.ETA_F, simbase.PCON_F, simbase.PMIG_F, simbase.PASSM_F,
+                             simbase.PACTM_F, simbase.PASSE_F, simbase.PEXEE_F} {
+    if ((params[f] < 0) || (params[f] > 1)) {
+      return fmt.Errorf("-%s: probability must be between 0 and 1", f)
+    }
+  }
+  return nil
+}
+
+// Get the total payouts earned by all tribes in the most recent generation
+func (self *SimEngine) GetTotalPayouts() float64 {
+  return self.totalPayouts
+}
+
+// Reset the simulation to prepare for participation in the next generation.
+func (self *SimEngine) Reset() {
+  self.totalPayouts = 0
+  for i := 0; i < self.numTribes; i++ {
+    self.tribes[i].Reset()
+  }
+}
+
+// Play the required rounds of the game to complete the current generation.
+// Create and return the next generation.
+func (self *SimEngine) PlayRounds() (nextGen []*Tribe) {
+  nextGen = make([]*Tribe, self.numTribes)
+  for i := 0; i < self.numTribes; i++ {
+    self.totalPayouts += self.tribes[i].PlayRounds(self.game, self.rnGen)
+    nextGen[i] = self.tribes[i].CreateNextGen(self.rnGen)
+  }
+  return nextGen
+}
+
+// a conflict won by the tribe at index winner of the current generation
+type conflict struct {
+  winner int
+  loser int
+}
+
+// Evolve the tribal assessment modules based on the average payouts earned
+// by each tribe during the last generation.  The loser of each conflict
+// shifts its assessment module toward the winner's and takes in some of the
+// winner's action modules.
+func (self *SimEngine) EvolveTribes(nextGen []*Tribe) {
+  // iterate over the tribes and select pairs for conflict
+  var conflicts []conflict
+  for i := 0; i < self.numTribes; i++ {
+    for j := i+1; j < self.numTribes; j++ {
+      if (simbase.RandPercent(self.rnGen) < float64(self.pcon)) {
+        w, l := self.Conflict(i, j, self.rnGen)
+        conflicts = append(conflicts, conflict { winner: w, loser: l })
+      }
+    }
+  }
+
+  // tribes with a lower payout go first so that tribes with higher payouts
+  // can undo the changes they make
+  sort.SliceStable(conflicts, func(a, b int) bool {
+    return self.tribes[conflicts[a].winner].totalPayouts < self.tribes[conflicts[b].winner].totalPayouts
+  })
+  for _, c := range conflicts {
+    // winner comes from original list (source of modifications)
+    // loser comes from new list (will be modified)
+    winner := self.tribes[c.winner]
+    loser := nextGen[c.loser]
+    self.ShiftAssessMod(winner, loser, self.tribes[c.loser].AvgPayout(), self.rnGen)
+    self.MigrateAgents(winner, loser, self.rnGen)
+  }
+
+  // replace the original tribes with the new tribes
+  self.tribes = nextGen
+}
+
+// Determine the tribe that wins the conflict
+func (self *SimEngine) Conflict(a int, b int, rnGen *rand.Rand) (winner, loser int) {
+  avgPayoutA := self.tribes[a].AvgPayout()
+  avgPayoutB := self.tribes[b].AvgPayout()
+  if (math.IsInf(self.beta, 1)) {
+    // if beta is infinite then tribe with higher payout always wins
+    if (avgPayoutB > avgPayoutA) {
+      return b, a
+    }
+    return a, b
+  }
+  p := 1/(1 + math.Exp(-self.beta*(avgPayoutB - avgPayoutA)))
+  if (simbase.RandPercent(rnGen) < p) {
+    return b, a
+  }
+  return a, b
+}
+
+// Shift the loser's assessment module toward the winner's assessment module.
+// The loser's payout is the average payout it earned in the last generation.
+func (self *SimEngine) ShiftAssessMod(winner *Tribe, loser *Tribe, poL float64, rnGen *rand.Rand) {
+  poW := winner.AvgPayout()
+  // calculate probability that loser's bit value will flip to winner's bit value
+  var pflip float64
+  if ((poW == 0) && (poL == 0)) {
+    if (self.eta > 0) {
+      pflip = 1
+    }
+  } else {
+    pflip = (self.eta*poW)/((self.eta*poW) + (1 - self.eta)*poL)
+  }
+  // mutate the loser's assessment module
+  // -- only bits that match the winner's bits are mutated
+  loser.assessMod = loser.assessMod.Copy()
+  for i := 0; i < 8; i++ {
+    bitSame := loser.assessMod.bits[i] == winner.assessMod.bits[i]
+    if ((!bitSame) && (pflip != 0) && (simbase.RandPercent(rnGen) < pflip)) {
+      loser.assessMod.bits[i] = winner.assessMod.bits[i]
+    }
+    if (bitSame && (simbase.RandPercent(rnGen) < self.passmut)) {
+      if (loser.assessMod.bits[i] == simbase.GOOD) {
+        loser.assessMod.bits[i] = simbase.BAD
+      } else {
+        loser.assessMod.bits[i] = simbase.GOOD
+      }
+    }
+  }
+}
+
+// Migrate some agents from the first tribe to the second tribe
+func (self *SimEngine) MigrateAgents(from *Tribe, to *Tribe, rnGen *rand.Rand) {
+  for i := 0; i < to.numAgents; i++ {
+    if (simbase.RandPercent(rnGen) < float64(self.pmig)) {
+      to.agents[i].actMod = from.agents[i].actMod
+    }
+  }
+}
+
+// Statistics collected for a generation
+type Stats struct {
+  Assess [8]int   // number of tribes with each assessment module bit set
+  Action [NUM_AM_BITS]int // number of agents with each action module bit set
+  Contrib float64 // fraction of decisions that were to contribute
+  Punish float64  // fraction of decisions that were to punish
+  Reward float64  // fraction of decisions that were to reward
+  SPunish float64 // fraction of decisions that were to punish non-punishers
+  GoodRep float64 // fraction of agents with a GOOD reputation
+}
+
+// Collect statistics for the most recently played generation.  It must be
+// called after PlayRounds and before EvolveTribes.
+func (self *SimEngine) GetStats() Stats {
+  var stats Stats
+  ncontrib, npunish, nreward, nspunish, nacts, ngood, nagents := 0, 0, 0, 0, 0, 0, 0
+  for _, tribe := range self.tribes {
+    for j := 0; j < 8; j++ {
+      stats.Assess[j] += tribe.assessMod.GetBit(j)
+    }
+    for _, agent := range tribe.agents {
+      for j := 0; j < NUM_AM_BITS; j++ {
+        stats.Action[j] += agent.actMod.GetBit(j)
+      }
+      if (agent.rep == simbase.GOOD) {
+        ngood += 1
+      }
+    }
+    ncontrib += tribe.ncontrib
+    npunish += tribe.npunish
+    nreward += tribe.nreward
+    nspunish += tribe.nspunish
+    nacts += tribe.nacts
+    nagents += tribe.numAgents
+  }
+  if (nacts > 0) {
+    stats.Contrib = float64(ncontrib)/float64(nacts)
+    stats.Punish = float64(npunish)/float64(nacts)
+    stats.Reward = float64(nreward)/float64(nacts)
+    stats.SPunish = float64(nspunish)/float64(nacts)
+  }
+  stats.GoodRep = float64(ngood)/float64(nagents)
+  return stats
+}
+
+func (self *SimEngine) WriteSimParams() {
+  fmt.Printf("  \"ntribes\":%d,\n", self.numTribes)
+  fmt.Printf("  \"beta\":%.5f,\n", self.beta)
+  fmt.Printf("  \"eta\":%.5f,\n", self.eta)
+  fmt.Printf("  \"pcon\":%.5f,\n", self.pcon)
+  fmt.Printf("  \"pmig\":%.5f,\n", self.pmig)
+  fmt.Printf("  \"passmut\":%.5f,\n", self.passmut)
+  // write game and tribe sim parameters
+  self.game.WriteSimParams()
+  self.tribes[0].WriteSimParams()
+}
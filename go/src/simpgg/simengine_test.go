@@ -0,0 +1,166 @@
+package simpgg
+
+import "testing"
+import "testutil"
+import "math/rand"
+import "simbase"
+
+func TestAssignRep(u *testing.T) {
+  rnGen := simbase.NewRandNumGen()
+  G, B := simbase.GOOD, simbase.BAD
+
+  // stern judging: contributing is GOOD unless the group is BAD
+  am := NewAssessModule(G, B, B, G, G, B, B, G, 0)
+  testutil.AssertTrue(u, am.AssignRep(G, G, true, rnGen) == G)
+  testutil.AssertTrue(u, am.AssignRep(G, G, false, rnGen) == B)
+  testutil.AssertTrue(u, am.AssignRep(G, B, true, rnGen) == B)
+  testutil.AssertTrue(u, am.AssignRep(G, B, false, rnGen) == G)
+  testutil.AssertTrue(u, am.AssignRep(B, G, true, rnGen) == G)
+  testutil.AssertTrue(u, am.AssignRep(B, B, false, rnGen) == G)
+  testutil.AssertIntEqual(u, am.GetBits(), 0x99)
+}
+
+// create a group of agents that always take the same actions
+func newTestGroup(n int, contribute bool, punish bool) []*Agent {
+  group := make([]*Agent, n)
+  for i := 0; i < n; i++ {
+    actMod := NewActionModule(contribute, contribute, contribute, contribute,
+                              punish, punish, punish, punish, 0)
+    group[i] = &Agent { rep: simbase.GOOD, actMod: actMod }
+  }
+  return group
+}
+
+func TestPlayRound(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(1))
+  game := NewGame(1, 3, 1, 0.5, 4, 1)
+  am := NewAssessModule(simbase.GOOD, simbase.BAD, simbase.GOOD, simbase.BAD,
+                        simbase.GOOD, simbase.BAD, simbase.GOOD, simbase.BAD, 0)
+
+  // two punishing contributors and two defectors
+  group := append(newTestGroup(2, true, true), newTestGroup(2, false, false)...)
+  payouts, acts := game.PlayRound(group, am, rnGen)
+  testutil.AssertIntEqual(u, acts.Contrib, 2)
+  testutil.AssertIntEqual(u, acts.Punish, 2)
+  testutil.AssertIntEqual(u, acts.Reward, 0)
+  // endowment 1+1.5*3 = 5.5 and share 3*2/4 = 1.5
+  // -- a contributor pays 1 and 0.5 to punish each of the 2 defectors
+  testutil.AssertFloat64Equal(u, group[0].payout, 5.5+1.5-1-1)
+  // -- a defector is fined by both punishers
+  testutil.AssertFloat64Equal(u, group[2].payout, 5.5+1.5-2)
+  testutil.AssertFloat64Equal(u, payouts, 2*5+2*5)
+  // the assessment module rewards contributors
+  testutil.AssertTrue(u, group[0].rep == simbase.GOOD)
+  testutil.AssertTrue(u, group[2].rep == simbase.BAD)
+  // no payout is negative in the worst case
+  worst := newTestGroup(4, false, true)
+  game.PlayRound(worst, am, rnGen)
+  testutil.AssertTrue(u, worst[0].payout >= 0)
+}
+
+func TestPlayRoundIncentives(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(1))
+  game := NewGame(1, 2, 1, 0.5, 3, 1)
+  game.SetIncentives(0.6, 0.2, 0.8, 0.4)
+  // endowment 1+(1+0.5+0.2+0.8+0.4)*2 = 6.8
+  testutil.AssertFloat64Equal(u, game.Endowment(3), 6.8)
+  am := NewAssessModule(simbase.GOOD, simbase.BAD, simbase.GOOD, simbase.BAD,
+                        simbase.GOOD, simbase.BAD, simbase.GOOD, simbase.BAD, 0)
+
+  // a rewarding second-order punisher, a contributor that doesn't punish
+  // and a defector - bits are contribute, punish, reward and second order
+  group := []*Agent {
+    &Agent { rep: simbase.GOOD, actMod: NewActionModuleFromBits(0xFFFF, 0) },
+    &Agent { rep: simbase.GOOD, actMod: NewActionModuleFromBits(0x00F0, 0) },
+    &Agent { rep: simbase.GOOD, actMod: NewActionModuleFromBits(0x0000, 0) },
+  }
+  _, acts := game.PlayRound(group, am, rnGen)
+  testutil.AssertIntEqual(u, acts.Reward, 1)
+  testutil.AssertIntEqual(u, acts.SPunish, 1)
+  share := 2.0*2/3
+  // -- agent 0 punishes the defector, rewards agent 1 and punishes agent 1
+  //    as the only non-punisher
+  testutil.AssertFloat64Equal(u, group[0].payout, 6.8+share-1-0.5-0.2-0.4)
+  // -- agent 1 is rewarded and fined as a non-punisher
+  testutil.AssertFloat64Equal(u, group[1].payout, 6.8+share-1+0.6-0.8)
+  // -- agent 2 is fined as a defector but had no one to punish
+  testutil.AssertFloat64Equal(u, group[2].payout, 6.8+share-1)
+}
+
+func TestActionModuleBits(u *testing.T) {
+  am := NewActionModuleFromBits(0xA5C3, 0)
+  testutil.AssertIntEqual(u, am.GetBits(), 0xA5C3)
+  // contribute bits come from the second byte and punish bits from the first
+  testutil.AssertIntEqual(u, am.GetBit(0), 1)
+  testutil.AssertIntEqual(u, am.GetBit(2), 0)
+  testutil.AssertIntEqual(u, am.GetBit(7), 1)
+  // reward bits 1010 and second-order punish bits 0101
+  testutil.AssertIntEqual(u, am.GetBit(8), 1)
+  testutil.AssertIntEqual(u, am.GetBit(9), 0)
+  testutil.AssertIntEqual(u, am.GetBit(12), 0)
+  testutil.AssertIntEqual(u, am.GetBit(15), 1)
+
+  c := am.Copy()
+  testutil.AssertTrue(u, c.SameBits(am))
+  c.sam = c.sam.CloneWithMutations(1, rand.New(rand.NewSource(1)))
+  testutil.AssertFalse(u, c.SameBits(am))
+  testutil.AssertIntEqual(u, c.GetBits(), 0xAAC3)
+
+  // the original constructor never rewards or punishes non-punishers
+  old := NewActionModule(true, false, false, true, false, true, true, false, 0)
+  testutil.AssertIntEqual(u, old.GetBits(), 0x96)
+}
+
+func TestTribePlayRounds(u *testing.T) {
+  rnGen := rand.New(rand.NewSource(2))
+  game := NewGame(1, 3, 1, 0.3, 4, 3)
+  // 10 agents form groups of 4, 4 and 2 in each round
+  t := NewTribe(10, 0, 0, 0, rnGen)
+  t.PlayRounds(game, rnGen)
+  testutil.AssertIntEqual(u, t.nacts, 30)
+  sum := float64(0)
+  for _, agent := range t.agents {
+    sum += agent.payout
+  }
+  testutil.AssertFloat64Equal(u, t.totalPayouts, sum)
+
+  // a single left over agent sits the round out
+  t = NewTribe(9, 0, 0, 0, rnGen)
+  t.PlayRounds(game, rnGen)
+  testutil.AssertIntEqual(u, t.nacts, 24)
+
+  next := t.CreateNextGen(rnGen)
+  testutil.AssertIntEqual(u, len(next.agents), 9)
+  testutil.AssertTrue(u, next.agents[0].tribe == next)
+}
+
+func TestRunSim(u *testing.T) {
+  params := map[string]float64 { simbase.PCON_F: 0.5, ROUNDS_F: 2 }
+  numTribes := 4
+  numAgents := 6
+  s := NewSimEngineWithRNG(numTribes, numAgents, params, rand.New(rand.NewSource(3)))
+  for g := 0; g < 5; g++ {
+    nextGen := s.PlayRounds()
+    stats := s.GetStats()
+    testutil.AssertTrue(u, (stats.Contrib >= 0) && (stats.Contrib <= 1))
+    for j := 0; j < 8; j++ {
+      testutil.AssertTrue(u, stats.Assess[j] <= numTribes)
+    }
+    for j := 0; j < NUM_AM_BITS; j++ {
+      testutil.AssertTrue(u, stats.Action[j] <= numTribes*numAgents)
+    }
+    testutil.AssertTrue(u, s.GetTotalPayouts() > 0)
+    s.EvolveTribes(nextGen)
+    s.Reset()
+  }
+  testutil.AssertIntEqual(u, len(s.tribes), numTribes)
+}
+
+func TestCheckParams(u *testing.T) {
+  testutil.AssertTrue(u, CheckParams(2, 5, map[string]float64 {}) == nil)
+  testutil.AssertFalse(u, CheckParams(0, 5, map[string]float64 {}) == nil)
+  testutil.AssertFalse(u, CheckParams(2, 5, map[string]float64 { GSIZE_F: 1 }) == nil)
+  testutil.AssertFalse(u, CheckParams(2, 5, map[string]float64 { GSIZE_F: 2.5 }) == nil)
+  testutil.AssertFalse(u, CheckParams(2, 5, map[string]float64 { simbase.PMIG_F: 2 }) == nil)
+  testutil.AssertFalse(u, CheckParams(2, 5, map[string]float64 { FINE_F: -1 }) == nil)
+}
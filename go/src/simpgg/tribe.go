@@ -0,0 +1,131 @@
+package simpgg
+
+import "math/rand"
+import "fmt"
+import "simbase"
+
+// A tribe of agents that plays public goods games in groups and uses an
+// assessment module to assign reputations to its agents.
+type Tribe struct {
+  agents []*Agent
+  assessMod *AssessModule
+  numAgents int
+  totalPayouts float64
+  ncontrib int // number of contributions made in the current generation
+  npunish int  // number of decisions to punish made in the current generation
+  nreward int  // number of decisions to reward made in the current generation
+  nspunish int // number of decisions to punish non-punishers made in the current generation
+  nacts int    // number of decisions made in the current generation
+}
+
+// Create a new tribe.
+func NewTribe(numAgents int, passerr float32, pactmut float64, pexeerr float32, rnGen *rand.Rand) *Tribe {
+  t := &Tribe { assessMod: NewRandAssessModule(passerr, rnGen), numAgents: numAgents }
+  // create the tribe's agents
+  t.agents = make([]*Agent, numAgents)
+  for i := 0; i < numAgents; i++ {
+    t.agents[i] = NewAgent(t, pactmut, pexeerr, rnGen)
+  }
+  return t
+}
+
+// return the list of agents
+func (t *Tribe) GetAgents() []*Agent {
+  return t.agents
+}
+
+// generate string representation of a tribe
+func (t *Tribe) String() string {
+  str := "{\n"
+  str = str + fmt.Sprintf("  \"num-agents\":%d\n", t.numAgents)
+  str = str + fmt.Sprintf("  \"assess-mod\":%d\n", t.assessMod.GetBits())
+  str = str + fmt.Sprintf("  \"total-payouts\":%.3f\n", t.totalPayouts)
+  str = str + "}"
+  return str
+}
+
+// Reset the tribe's agents to prepare for participation in the next generation.
+func (self *Tribe) Reset() {
+  self.totalPayouts = 0
+  self.ncontrib = 0
+  self.npunish = 0
+  self.nreward = 0
+  self.nspunish = 0
+  self.nacts = 0
+  for i := 0; i < self.numAgents; i++ {
+    self.agents[i].Reset()
+  }
+}
+
+// Play the required rounds of the game to complete the current generation.
+// In each round the agents are shuffled into groups of the game's group
+// size.  Left over agents form a smaller group, unless only one agent is
+// left over, in which case it sits the round out.
+func (self *Tribe) PlayRounds(game *Game, rnGen *rand.Rand) float64 {
+  group := make([]*Agent, 0, game.groupSize)
+  for r := 0; r < game.rounds; r++ {
+    order := rnGen.Perm(self.numAgents)
+    for start := 0; start+1 < self.numAgents; start += game.groupSize {
+      group = group[:0]
+      for _, i := range order[start:min(start+game.groupSize, self.numAgents)] {
+        group = append(group, self.agents[i])
+      }
+      if (len(group) < 2) {
+        continue
+      }
+      payouts, acts := game.PlayRound(group, self.assessMod, rnGen)
+      self.totalPayouts += payouts
+      self.ncontrib += acts.Contrib
+      self.npunish += acts.Punish
+      self.nreward += acts.Reward
+      self.nspunish += acts.SPunish
+      self.nacts += len(group)
+    }
+  }
+
+  // return the total payouts for use by the sim engine
+  return self.totalPayouts
+}
+
+// Randomly select an agent from the local population.  The chance that an
+// agent is selected is proportional to its fitness.
+func (self *Tribe) SelectParent(rnGen *rand.Rand) *Agent {
+  if (self.totalPayouts <= 0) {
+    return self.agents[rnGen.Intn(self.numAgents)]
+  }
+  r := simbase.RandPercent(rnGen)*self.totalPayouts
+  thresh := float64(0)
+  for i := 0; i < self.numAgents; i++ {
+    thresh += self.agents[i].payout
+    if (r < thresh) {
+      return self.agents[i]
+    }
+  }
+  // rounding errors can leave r just above the final threshold
+  return self.agents[self.numAgents-1]
+}
+
+// Create the next generation by propagating action modules to the next
+// generation based on the fitness those modules achieved.
+func (currentGen *Tribe) CreateNextGen(rnGen *rand.Rand) *Tribe {
+  nextGen := &Tribe { assessMod: currentGen.assessMod.Copy(), numAgents: currentGen.numAgents }
+  nextGen.agents = make([]*Agent, nextGen.numAgents)
+  for i := 0; i < nextGen.numAgents; i++ {
+    parent := currentGen.SelectParent(rnGen)
+    nextGen.agents[i] = parent.CreateChild(nextGen, rnGen)
+  }
+  return nextGen
+}
+
+// Return the average payout for an agent in this tribe
+func (self *Tribe) AvgPayout() float64 {
+  return self.totalPayouts/float64(self.numAgents)
+}
+
+func (self *Tribe) WriteSimParams() {
+  fmt.Printf("  \"nagents\":%d,\n", self.numAgents)
+  // write assess module parameters
+  self.assessMod.WriteSimParams()
+  // write agent parameters
+  self.agents[0].WriteSimParams()
+}
@@ -0,0 +1,24 @@
+package simpgg
+
+// default values of the parameters that are specific to the public goods
+// game - the other parameters and their defaults are defined by simbase
+const (
+ MULT = 3.0 // default contribution multiplier (r)
+ MULT_F = "r"
+ FINE = 1.0 // default fine paid by a non-contributor to each punisher
+ FINE_F = "fine"
+ PCOST = 0.3 // default cost paid by a punisher for each non-contributor punished
+ PCOST_F = "pcost"
+ REWARD = 0.0 // default reward received by a contributor from each rewarder
+ REWARD_F = "reward"
+ RCOST = 0.0 // default cost paid by a rewarder for each contributor rewarded
+ RCOST_F = "rcost"
+ SPFINE = 0.0 // default fine paid by a non-punisher to each second-order punisher
+ SPFINE_F = "spfine"
+ SPCOST = 0.0 // default cost paid by a second-order punisher for each non-punisher punished
+ SPCOST_F = "spcost"
+ GSIZE = 5 // default number of agents in each group
+ GSIZE_F = "n"
+ ROUNDS = 10 // default number of rounds played by each tribe per generation
+ ROUNDS_F = "rounds"
+)
@@ -0,0 +1,15 @@
+package main
+
+import "os"
+import "simcmd"
+
+/*
+Run the simulations and their analysis through subcommands that share flag
+conventions, output directories and manifests.  Run simtool with no
+arguments to list the subcommands.
+
+Author: John Maloney
+*/
+func main() {
+  os.Exit(simcmd.Main(os.Args[1:]))
+}
@@ -0,0 +1,134 @@
+package testutil
+
+import "testing"
+import "fmt"
+import "math"
+
+// Significance level of the hypothesis tests used to check random behaviour.
+// The tests use seeded random number generators, so a result that passes
+// always passes, and a change that biases the behaviour fails with a
+// probability of at least 1 - SIGNIFICANCE.
+const SIGNIFICANCE = 0.001
+
+// return the log of the probability of k successes in n trials with the
+// probability of success p
+func logBinomialPMF(k int, n int, p float64) float64 {
+  if (p == 0) {
+    if (k == 0) { return 0 }
+    return math.Inf(-1)
+  }
+  if (p == 1) {
+    if (k == n) { return 0 }
+    return math.Inf(-1)
+  }
+  lgn, _ := math.Lgamma(float64(n + 1))
+  lgk, _ := math.Lgamma(float64(k + 1))
+  lgnk, _ := math.Lgamma(float64(n - k + 1))
+  return lgn - lgk - lgnk + float64(k) * math.Log(p) + float64(n - k) * math.Log(1 - p)
+}
+
+// Return the p-value of the exact two-sided binomial test of k successes in n
+// trials, where the null hypothesis is that the probability of success is p.
+// The p-value is the probability of an outcome no more likely than k.
+func BinomialPValue(k int, n int, p float64) float64 {
+  lpk := logBinomialPMF(k, n, p)
+  if (math.IsInf(lpk, -1)) {
+    return 0
+  }
+  pvalue := 0.0
+  for i := 0; i <= n; i++ {
+    lpi := logBinomialPMF(i, n, p)
+    // -- allow for rounding error in outcomes as likely as k
+    if (lpi <= lpk + 1e-7) {
+      pvalue += math.Exp(lpi)
+    }
+  }
+  return math.Min(pvalue, 1)
+}
+
+// return the regularized upper incomplete gamma function Q(a, x)
+func gammaQ(a float64, x float64) float64 {
+  if (x <= 0) {
+    return 1
+  }
+  lga, _ := math.Lgamma(a)
+  if (x < a + 1) {
+    // series for P(a, x)
+    sum, term := 1/a, 1/a
+    for n := 1; n < 1000; n++ {
+      term *= x / (a + float64(n))
+      sum += term
+      if (math.Abs(term) < math.Abs(sum) * 1e-15) {
+        break
+      }
+    }
+    return 1 - sum * math.Exp(-x + a * math.Log(x) - lga)
+  }
+  // continued fraction for Q(a, x) (modified Lentz's method)
+  tiny := 1e-300
+  b := x + 1 - a
+  c := 1 / tiny
+  d := 1 / b
+  h := d
+  for n := 1; n < 1000; n++ {
+    an := -float64(n) * (float64(n) - a)
+    b += 2
+    d = an * d + b
+    if (math.Abs(d) < tiny) { d = tiny }
+    c = b + an / c
+    if (math.Abs(c) < tiny) { c = tiny }
+    d = 1 / d
+    delta := d * c
+    h *= delta
+    if (math.Abs(delta - 1) < 1e-15) {
+      break
+    }
+  }
+  return math.Exp(-x + a * math.Log(x) - lga) * h
+}
+
+// Return the p-value of Pearson's chi-square goodness of fit test of the
+// observed counts of outcomes, where the null hypothesis is that the outcomes
+// have the expected probabilities.  An outcome observed despite having a
+// probability of zero gives a p-value of zero.
+func ChiSquarePValue(observed []int, probs []float64) float64 {
+  n := 0
+  for _, o := range observed {
+    n += o
+  }
+  chi2 := 0.0
+  df := -1
+  for i, o := range observed {
+    if (probs[i] == 0) {
+      if (o > 0) { return 0 }
+      continue
+    }
+    e := float64(n) * probs[i]
+    chi2 += (float64(o) - e) * (float64(o) - e) / e
+    df++
+  }
+  if (df < 1) {
+    return 1
+  }
+  return gammaQ(float64(df) / 2, chi2 / 2)
+}
+
+// assert that k successes in n trials are consistent with the probability
+// of success p
+func AssertBinomial(t *testing.T, k int, n int, p float64) {
+  pvalue := BinomialPValue(k, n, p)
+  if (pvalue < SIGNIFICANCE) {
+    LogErr(t, fmt.Sprintf("%d of %d (%6.4f) is not consistent with probability %6.4f (p-value %g)",
+                          k, n, float64(k)/float64(n), p, pvalue))
+  }
+}
+
+// assert that the observed counts of outcomes are consistent with the
+// expected probabilities
+func AssertChiSquare(t *testing.T, observed []int, probs []float64) {
+  pvalue := ChiSquarePValue(observed, probs)
+  if (pvalue < SIGNIFICANCE) {
+    LogErr(t, fmt.Sprintf("counts %v are not consistent with probabilities %v (p-value %g)",
+                          observed, probs, pvalue))
+  }
+}
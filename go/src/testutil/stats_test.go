@@ -0,0 +1,38 @@
+package testutil
+
+import "testing"
+import "math"
+
+// assert that v1 is within tol of v2
+func assertNear(t *testing.T, v1 float64, v2 float64, tol float64) {
+  if (math.Abs(v1 - v2) > tol) {
+    t.Errorf("%v is not within %v of %v", v1, tol, v2)
+  }
+}
+
+func TestBinomialPValue(u *testing.T) {
+  // 5 heads in 10 tosses of a fair coin is the most likely outcome
+  assertNear(u, BinomialPValue(5, 10, 0.5), 1, 1e-9)
+  // 0 or 10 heads: 2/1024
+  assertNear(u, BinomialPValue(0, 10, 0.5), 2.0/1024, 1e-12)
+  // 2 heads: P(X <= 2) + P(X >= 8) = 2*56/1024
+  assertNear(u, BinomialPValue(2, 10, 0.5), 112.0/1024, 1e-12)
+  // 1 success in 5 trials with p = 0.2 is the most likely outcome
+  assertNear(u, BinomialPValue(1, 5, 0.2), 1, 1e-9)
+  AssertFloat64Equal(u, BinomialPValue(1, 10, 0), 0)
+  AssertFloat64Equal(u, BinomialPValue(10, 10, 1), 1)
+}
+
+func TestChiSquarePValue(u *testing.T) {
+  // chi-square of 3.841459 with 1 degree of freedom is the 5% critical value
+  assertNear(u, ChiSquarePValue([]int{ 531, 469 }, []float64{ 0.5, 0.5 }), 0.05, 0.002)
+  assertNear(u, gammaQ(0.5, 3.841459/2), 0.05, 1e-6)
+  // 9.21034 with 2 degrees of freedom is the 1% critical value
+  assertNear(u, gammaQ(1, 9.21034/2), 0.01, 1e-6)
+  // 29.588 with 10 degrees of freedom is the 0.1% critical value
+  assertNear(u, gammaQ(5, 29.588/2), 0.001, 1e-5)
+  assertNear(u, ChiSquarePValue([]int{ 10, 20, 30 }, []float64{ 1.0/6, 2.0/6, 3.0/6 }), 1, 1e-9)
+  // an outcome that can't happen
+  AssertFloat64Equal(u, ChiSquarePValue([]int{ 10, 1 }, []float64{ 1, 0 }), 0)
+  AssertFloat64Equal(u, ChiSquarePValue([]int{ 10, 0 }, []float64{ 1, 0 }), 1)
+}